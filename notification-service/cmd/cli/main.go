@@ -1,7 +1,14 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"notification-service-api/internal/shared/ratelimit"
 	"notification-service-api/pkg/di"
+	"os"
+	"strings"
+	"time"
 )
 
 var dependencies *di.Dependencies
@@ -11,5 +18,69 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) < 2 {
+		usage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "apikey:create":
+		createAPIKey(os.Args[2:])
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
 	dependencies.Logger.Info("CLI commands")
+	fmt.Println("Usage:")
+	fmt.Println("  cli apikey:create -name <name> -methods <pattern,...> [-owner <owner>] [-expires <duration>] [-rate-limits <pattern=limit,...>]")
+}
+
+func createAPIKey(args []string) {
+	fs := flag.NewFlagSet("apikey:create", flag.ExitOnError)
+	name := fs.String("name", "", "human readable name of the consumer")
+	owner := fs.String("owner", "", "team or service owning the key")
+	methods := fs.String("methods", "", "comma separated allowed methods: *, email.* or telegram.send")
+	expires := fs.Duration("expires", 0, "lifetime of the key, e.g. 720h, never expires when 0")
+	rateLimits := fs.String("rate-limits", "", "comma separated pattern=limit overrides, e.g. email.send=10/m")
+	_ = fs.Parse(args)
+
+	var patterns []string
+	for _, m := range strings.Split(*methods, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			patterns = append(patterns, m)
+		}
+	}
+
+	if *name == "" || len(patterns) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := ratelimit.ParseRules(*rateLimits)
+	if err != nil {
+		fmt.Println("invalid rate limits:", err)
+		os.Exit(1)
+	}
+	limits := make(map[string]string, len(rules))
+	for pattern, limit := range rules {
+		limits[pattern] = limit.String()
+	}
+
+	var expiresAt *time.Time
+	if *expires > 0 {
+		at := time.Now().Add(*expires)
+		expiresAt = &at
+	}
+
+	token, key, err := dependencies.APIKeyService.Create(context.Background(), *name, *owner, patterns, limits, expiresAt)
+	if err != nil {
+		fmt.Println("failed to create API key:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("API key %s created for methods %s\n", key.ID.String(), strings.Join(key.Methods, ", "))
+	fmt.Println("Token (shown only once):", token)
 }
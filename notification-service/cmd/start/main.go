@@ -7,8 +7,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"net/http"
+	authRpc "notification-service-api/internal/auth/delivery/rpc"
 	"notification-service-api/internal/notifications/delivery/queue"
 	rpc2 "notification-service-api/internal/notifications/delivery/rpc"
+	"notification-service-api/internal/notifications/delivery/updates"
 	"notification-service-api/internal/shared/rpc"
 	"notification-service-api/internal/shared/rpc/handlers"
 	"notification-service-api/internal/shared/rpc/middlewares"
@@ -31,36 +33,73 @@ func init() {
 }
 
 func main() {
-	utils.InitMigrations(dependencies.DB)
+	utils.InitMigrations(dependencies.DB, dependencies.Config.TelegramDefaultBot)
 	stopAutoFlush := dependencies.MultiCache.StartAutoFlush(5 * time.Minute)
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.LoggerWithFormatter(middlewares.GinLogFormatter), gin.Recovery())
 
 	publicGroup := r.Group("")
 
-	publicGroup.GET("/", func(c *gin.Context) {
-		c.Header("Content-Type", "text/html; charset=utf-8")
-		c.File("html/docs.html")
-	})
+	docsHandler, err := handlers.NewDocsHandler(dependencies.Registry, "html/docs.html")
+	if err != nil {
+		dependencies.Logger.Fatal("failed to load docs template", zap.Error(err))
+	}
+
+	publicGroup.GET("/", docsHandler.Page)
+	publicGroup.GET("/openrpc.json", docsHandler.OpenRPC)
+	updates.RegisterTelegramWebhook(publicGroup, dependencies)
 
 	rpcGroup := r.Group("")
 
+	rpcGroup.Use(middlewares.BodyLimitMiddleware(dependencies.Config.RPCMaxBodySize))
 	rpcGroup.Use(middlewares.LoggingContextMiddleware(dependencies.Logger))
 	rpcGroup.Use(middlewares.AccessLogMiddleware())
-	rpcGroup.Use(middlewares.AuthMiddleware(dependencies.Config, dependencies.Logger))
+	rpcGroup.Use(middlewares.AuthMiddleware(dependencies.Config, dependencies.APIKeyService, dependencies.JWT, dependencies.Signatures, dependencies.Logger))
 	rpcGroup.Use(middlewares.StatisticsMiddleware(dependencies.Influx, dependencies.Logger))
 
+	dependencies.Registry.Use("*",
+		middlewares.RecoveryInterceptor(),
+		middlewares.AccessLogInterceptor(),
+		middlewares.StatisticsInterceptor(dependencies.Influx, dependencies.Logger),
+		middlewares.ScopesInterceptor(),
+		middlewares.RateLimitInterceptor(dependencies.RateLimiter, dependencies.RateLimits, dependencies.Logger),
+		middlewares.TimeoutInterceptor(dependencies.Config.RPCMethodTimeout),
+	)
+	dependencies.Registry.Use("telegram.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("email.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("sms.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("push.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("webhook.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("slack.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("discord.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("mattermost.*", middlewares.AuditInterceptor(dependencies.Logger))
+	dependencies.Registry.Use("apikey.*", middlewares.AuditInterceptor(dependencies.Logger))
+
 	systemRpc.InitSystemProcedures(dependencies)
+	authRpc.InitAuthProcedures(dependencies)
 	rpc2.InitNotificationProcedures(dependencies)
 
-	rpcHandler := handlers.NewRPCHandler(dependencies.Registry)
+	rpcHandler := handlers.NewRPCHandler(dependencies.Registry, dependencies.Idempotency, handlers.BatchOptions{
+		Parallelism: dependencies.Config.RPCBatchParallelism,
+		MaxSize:     dependencies.Config.RPCBatchMaxSize,
+	})
+
+	wsHandler := handlers.NewWSHandler(rpcHandler, dependencies.Config.WSAllowedOrigins)
 
 	rpcGroup.POST("/rpc", rpc.Wrap(rpcHandler.MainRPCHandler))
+	rpcGroup.GET("/ws", rpc.Wrap(wsHandler.Serve))
 
 	srv := &http.Server{Addr: ":8000", Handler: r}
 
 	go queue.StartTelegramConsumers(dependencies)
 	go queue.StartEmailConsumers(dependencies)
+	go queue.StartSMSConsumers(dependencies)
+	go queue.StartPushConsumers(dependencies)
+	go queue.StartWebhookConsumers(dependencies)
+	go queue.StartChatOpsConsumers(dependencies)
+	go updates.StartTelegramUpdates(dependencies)
+	go dependencies.StatusSubscriptions.Run(context.Background())
 
 	srv.RegisterOnShutdown(func() {
 		// flush multi cache to redis
@@ -0,0 +1,32 @@
+// cmd/telegram-fake/main.go
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"notification-service-api/internal/notifications/infra/telegram/telegramtest"
+	"strings"
+)
+
+// Runs the fake Bot API server, point TELEGRAM_API_URL at it to use the Telegram channel offline.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8081", "listen address, the control endpoints have no auth")
+	tokens := flag.String("tokens", "", "comma separated bot tokens to accept, any when empty")
+	flag.Parse()
+
+	var accepted []string
+	for _, token := range strings.Split(*tokens, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			accepted = append(accepted, token)
+		}
+	}
+
+	server := telegramtest.NewServer(accepted...)
+	server.OnCall = func(call telegramtest.Call) {
+		log.Printf("%s chat_id=%s files=%d", call.Method, call.Params["chat_id"], len(call.Files))
+	}
+
+	log.Printf("telegram-fake: listening on %s, control endpoints under %s", *addr, telegramtest.ControlPrefix)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
@@ -0,0 +1,233 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"notification-service-api/internal/auth/domain"
+	"notification-service-api/internal/auth/domain/entity"
+	"notification-service-api/pkg/cache"
+	"time"
+)
+
+const (
+	apiKeyTokenPrefix  = "nsk_"
+	apiKeyPrefixLength = 12
+	apiKeyCachePrefix  = "auth:apikey:"
+	// apiKeyCacheTTL bounds how long a revoked key stays usable on other instances
+	apiKeyCacheTTL = time.Minute
+	// lastUsedResolution avoids a write on every request
+	lastUsedResolution = time.Minute
+)
+
+type APIKeyStorePort interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	FindByHash(ctx context.Context, hash string) (*entity.APIKey, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error)
+	List(ctx context.Context, owner string, withRevoked bool) ([]entity.APIKey, error)
+	UpdateHash(ctx context.Context, id uuid.UUID, hash string, prefix string) error
+	UpdateRateLimits(ctx context.Context, id uuid.UUID, rateLimits map[string]string) error
+	Revoke(ctx context.Context, id uuid.UUID) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type APIKeyCachePort interface {
+	Get(key string) (cache.MultiCacheValue, bool, error)
+	Set(key string, value interface{}, ttl time.Duration) bool
+	Del(key string) error
+}
+
+type APIKeyService struct {
+	store  APIKeyStorePort
+	cache  APIKeyCachePort
+	logger *zap.Logger
+}
+
+func NewAPIKeyService(store APIKeyStorePort, cache APIKeyCachePort, logger *zap.Logger) *APIKeyService {
+	return &APIKeyService{
+		store:  store,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// Create stores a new key and returns its secret, it is never shown again.
+func (s *APIKeyService) Create(ctx context.Context, name string, owner string, methods []string, rateLimits map[string]string, expiresAt *time.Time) (string, *entity.APIKey, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	key := &entity.APIKey{
+		ID:         uuid.New(),
+		Name:       name,
+		Owner:      owner,
+		Prefix:     token[:apiKeyPrefixLength],
+		Hash:       hashToken(token),
+		Methods:    methods,
+		RateLimits: rateLimits,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.store.Create(ctx, key); err != nil {
+		return "", nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("API key %s (%s) created for %s", key.ID.String(), key.Name, key.Owner))
+
+	return token, key, nil
+}
+
+// Authenticate resolves a secret to its key, lookups go through the multi cache.
+func (s *APIKeyService) Authenticate(ctx context.Context, token string) (*entity.APIKey, error) {
+	hash := hashToken(token)
+
+	key, err := s.lookup(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch {
+	case key.IsRevoked():
+		return nil, domain.ErrAPIKeyRevoked
+	case key.IsExpired(now):
+		return nil, domain.ErrAPIKeyExpired
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedResolution {
+		if err := s.store.TouchLastUsed(ctx, key.ID, now); err != nil {
+			s.logger.Warn(fmt.Sprintf("failed to update last use of API key %s", key.ID.String()), zap.Error(err))
+		} else {
+			touched := *key
+			touched.LastUsedAt = &now
+			s.cache.Set(apiKeyCachePrefix+hash, &touched, apiKeyCacheTTL)
+		}
+	}
+
+	return key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, owner string, withRevoked bool) ([]entity.APIKey, error) {
+	return s.store.List(ctx, owner, withRevoked)
+}
+
+// Rotate replaces the secret of a key, the old one stops working immediately on this instance
+// and within the cache TTL on the others.
+func (s *APIKeyService) Rotate(ctx context.Context, id uuid.UUID) (string, *entity.APIKey, error) {
+	key, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	if key.IsRevoked() {
+		return "", nil, domain.ErrAPIKeyRevoked
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	oldHash := key.Hash
+	key.Hash = hashToken(token)
+	key.Prefix = token[:apiKeyPrefixLength]
+
+	if err := s.store.UpdateHash(ctx, id, key.Hash, key.Prefix); err != nil {
+		return "", nil, err
+	}
+	s.forget(oldHash)
+
+	s.logger.Info(fmt.Sprintf("API key %s (%s) rotated", key.ID.String(), key.Name))
+
+	return token, key, nil
+}
+
+// SetRateLimits replaces the limits of a key, empty falls back to the service defaults.
+func (s *APIKeyService) SetRateLimits(ctx context.Context, id uuid.UUID, rateLimits map[string]string) (*entity.APIKey, error) {
+	key, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.IsRevoked() {
+		return nil, domain.ErrAPIKeyRevoked
+	}
+
+	if err := s.store.UpdateRateLimits(ctx, id, rateLimits); err != nil {
+		return nil, err
+	}
+	key.RateLimits = rateLimits
+	s.forget(key.Hash)
+
+	s.logger.Info(fmt.Sprintf("API key %s (%s) rate limits changed", key.ID.String(), key.Name))
+
+	return key, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	key, err := s.store.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !key.IsRevoked() {
+		if err := s.store.Revoke(ctx, id); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		key.RevokedAt = &now
+	}
+	s.forget(key.Hash)
+
+	s.logger.Info(fmt.Sprintf("API key %s (%s) revoked", key.ID.String(), key.Name))
+
+	return key, nil
+}
+
+func (s *APIKeyService) lookup(ctx context.Context, hash string) (*entity.APIKey, error) {
+	cached, ok, err := s.cache.Get(apiKeyCachePrefix + hash)
+	if err != nil {
+		s.logger.Warn("failed to read API key from cache", zap.Error(err))
+	}
+	if ok {
+		if key, isKey := cached.Interface().(*entity.APIKey); isKey {
+			return key, nil
+		}
+	}
+
+	key, err := s.store.FindByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Set(apiKeyCachePrefix+hash, key, apiKeyCacheTTL)
+
+	return key, nil
+}
+
+func (s *APIKeyService) forget(hash string) {
+	if err := s.cache.Del(apiKeyCachePrefix + hash); err != nil {
+		s.logger.Warn("failed to drop API key from cache", zap.Error(err))
+	}
+}
+
+func generateToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return apiKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
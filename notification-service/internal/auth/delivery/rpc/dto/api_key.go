@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+type APIKeyCreateParams struct {
+	Name       string            `json:"name" validate:"required,max=255" doc:"Human readable name of the consumer"`
+	Owner      string            `json:"owner" validate:"omitempty,max=255" doc:"Team or service owning the key"`
+	Methods    []string          `json:"methods" validate:"required,min=1,max=100,dive,required,max=128" doc:"Allowed methods: * for all, email.* for a namespace or an exact method name"`
+	RateLimits map[string]string `json:"rate_limits" validate:"omitempty,max=100" doc:"Method pattern to limit like 10/s, 600/m or 1000/h, overrides the service defaults"`
+	ExpiresAt  *time.Time        `json:"expires_at" doc:"The key stops working after this moment, never expires when empty"`
+}
+
+type APIKeyListParams struct {
+	Owner          string `json:"owner" validate:"omitempty,max=255" doc:"Only keys of this owner"`
+	IncludeRevoked bool   `json:"include_revoked" doc:"Also list revoked keys"`
+}
+
+type APIKeyIDParams struct {
+	ID string `json:"id" validate:"required,uuid" doc:"Key ID"`
+}
+
+type APIKeyRateLimitsParams struct {
+	ID         string            `json:"id" validate:"required,uuid" doc:"Key ID"`
+	RateLimits map[string]string `json:"rate_limits" validate:"max=100" doc:"Method pattern to limit like 10/s, 600/m or 1000/h, empty restores the service defaults"`
+}
+
+type APIKeyDTO struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Owner      string            `json:"owner"`
+	Prefix     string            `json:"prefix" doc:"First characters of the secret, to recognize it"`
+	Methods    []string          `json:"methods"`
+	RateLimits map[string]string `json:"rate_limits,omitempty"`
+	ExpiresAt  *time.Time        `json:"expires_at"`
+	LastUsedAt *time.Time        `json:"last_used_at"`
+	RevokedAt  *time.Time        `json:"revoked_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type APIKeySecretDTO struct {
+	Key   APIKeyDTO `json:"key"`
+	Token string    `json:"token" doc:"Secret to send as X-API-KEY, it is shown only once"`
+}
+
+type APIKeyListDTO struct {
+	Keys []APIKeyDTO `json:"keys"`
+}
@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"notification-service-api/internal/auth/app"
+	"notification-service-api/internal/auth/delivery/rpc/dto"
+	"notification-service-api/internal/auth/domain"
+	"notification-service-api/internal/auth/domain/entity"
+	"notification-service-api/internal/shared/ratelimit"
+	"notification-service-api/internal/shared/rpc"
+	"notification-service-api/internal/shared/rpc/respond"
+	"time"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *app.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *app.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) Create(c *rpc.HttpCtx, params dto.APIKeyCreateParams) (*dto.APIKeySecretDTO, *respond.RPCError) {
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", []respond.FieldError{{
+			Field:    "expires_at",
+			JSONPath: "expires_at",
+			Rule:     "future",
+			Message:  "expires_at must be in the future",
+		}})
+	}
+
+	if rpcErr := validateRateLimits(params.RateLimits); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	token, key, err := h.apiKeyService.Create(c, params.Name, params.Owner, params.Methods, params.RateLimits, params.ExpiresAt)
+	if err != nil {
+		c.Logger().Error("create_api_key", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "create_api_key", "create_api_key", err.Error())
+	}
+
+	return &dto.APIKeySecretDTO{Key: toAPIKeyDTO(key), Token: token}, nil
+}
+
+func (h *APIKeyHandler) List(c *rpc.HttpCtx, params dto.APIKeyListParams) (*dto.APIKeyListDTO, *respond.RPCError) {
+	keys, err := h.apiKeyService.List(c, params.Owner, params.IncludeRevoked)
+	if err != nil {
+		c.Logger().Error("list_api_keys", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "list_api_keys", "list_api_keys", err.Error())
+	}
+
+	result := &dto.APIKeyListDTO{Keys: make([]dto.APIKeyDTO, 0, len(keys))}
+	for i := range keys {
+		result.Keys = append(result.Keys, toAPIKeyDTO(&keys[i]))
+	}
+
+	return result, nil
+}
+
+func (h *APIKeyHandler) Rotate(c *rpc.HttpCtx, params dto.APIKeyIDParams) (*dto.APIKeySecretDTO, *respond.RPCError) {
+	id, rpcErr := rpc.ParseUUID("id", params.ID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	token, key, err := h.apiKeyService.Rotate(c, id)
+	if err != nil {
+		return nil, apiKeyError(c, "rotate_api_key", err)
+	}
+
+	return &dto.APIKeySecretDTO{Key: toAPIKeyDTO(key), Token: token}, nil
+}
+
+func (h *APIKeyHandler) Revoke(c *rpc.HttpCtx, params dto.APIKeyIDParams) (*dto.APIKeyDTO, *respond.RPCError) {
+	id, rpcErr := rpc.ParseUUID("id", params.ID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	key, err := h.apiKeyService.Revoke(c, id)
+	if err != nil {
+		return nil, apiKeyError(c, "revoke_api_key", err)
+	}
+
+	result := toAPIKeyDTO(key)
+	return &result, nil
+}
+
+func (h *APIKeyHandler) SetRateLimits(c *rpc.HttpCtx, params dto.APIKeyRateLimitsParams) (*dto.APIKeyDTO, *respond.RPCError) {
+	if rpcErr := validateRateLimits(params.RateLimits); rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	id, rpcErr := rpc.ParseUUID("id", params.ID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	key, err := h.apiKeyService.SetRateLimits(c, id, params.RateLimits)
+	if err != nil {
+		return nil, apiKeyError(c, "set_api_key_rate_limits", err)
+	}
+
+	result := toAPIKeyDTO(key)
+	return &result, nil
+}
+
+func validateRateLimits(rateLimits map[string]string) *respond.RPCError {
+	var fields []respond.FieldError
+	for pattern, limit := range rateLimits {
+		if _, err := ratelimit.ParseLimit(limit); err != nil {
+			fields = append(fields, respond.FieldError{
+				Field:    pattern,
+				JSONPath: "rate_limits[" + pattern + "]",
+				Rule:     "rate_limit",
+				Message:  err.Error(),
+			})
+		}
+	}
+
+	if len(fields) > 0 {
+		return respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", fields)
+	}
+
+	return nil
+}
+
+func apiKeyError(c *rpc.HttpCtx, action string, err error) *respond.RPCError {
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		return respond.NewRPCError(respond.NotFoundError, "api_key_not_found", "api key not found", nil)
+	case errors.Is(err, domain.ErrAPIKeyRevoked):
+		return respond.NewRPCError(respond.InvalidRequest, "api_key_revoked", "api key is revoked", nil)
+	default:
+		c.Logger().Error(action, zap.Error(err))
+		return respond.NewRPCError(respond.InternalError, action, action, err.Error())
+	}
+}
+
+func toAPIKeyDTO(key *entity.APIKey) dto.APIKeyDTO {
+	return dto.APIKeyDTO{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Owner:      key.Owner,
+		Prefix:     key.Prefix,
+		Methods:    key.Methods,
+		RateLimits: key.RateLimits,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
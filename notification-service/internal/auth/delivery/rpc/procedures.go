@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"notification-service-api/internal/auth/delivery/rpc/dto"
+	"notification-service-api/internal/shared/rpc"
+	"notification-service-api/pkg/di"
+)
+
+// InitAuthProcedures registers the admin methods. Only MASTER_TOKEN and credentials whose scopes name them,
+// "apikey.*" or the single method, can call them; a "*" scope does not.
+func InitAuthProcedures(dependencies *di.Dependencies) {
+	apiKeyHandler := NewAPIKeyHandler(dependencies.APIKeyService)
+
+	dependencies.Registry.Register("apikey.create", rpc.Typed[dto.APIKeyCreateParams](apiKeyHandler.Create).
+		WithSummary("Admin. Create an API key restricted to the given methods."))
+	dependencies.Registry.Register("apikey.list", rpc.Typed[dto.APIKeyListParams](apiKeyHandler.List).
+		WithSummary("Admin. List API keys, secrets are never returned."))
+	dependencies.Registry.Register("apikey.rotate", rpc.Typed[dto.APIKeyIDParams](apiKeyHandler.Rotate).
+		WithSummary("Admin. Replace the secret of an API key, the old one stops working."))
+	dependencies.Registry.Register("apikey.set_rate_limits", rpc.Typed[dto.APIKeyRateLimitsParams](apiKeyHandler.SetRateLimits).
+		WithSummary("Admin. Replace the rate limits of an API key."))
+	dependencies.Registry.Register("apikey.revoke", rpc.Typed[dto.APIKeyIDParams](apiKeyHandler.Revoke).
+		WithSummary("Admin. Revoke an API key."))
+}
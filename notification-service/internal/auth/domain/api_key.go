@@ -0,0 +1,9 @@
+package domain
+
+import "errors"
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyRevoked  = errors.New("api key revoked")
+	ErrAPIKeyExpired  = errors.New("api key expired")
+)
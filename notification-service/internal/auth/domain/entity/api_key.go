@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"notification-service-api/pkg/cache"
+	"time"
+)
+
+// APIKey is a caller credential, only the sha256 of the secret is stored.
+type APIKey struct {
+	ID         uuid.UUID         `gorm:"type:uuid;primaryKey" msgpack:"id"`
+	Name       string            `gorm:"type:varchar(255);not null" msgpack:"name"`
+	Owner      string            `gorm:"type:varchar(255);index" msgpack:"owner"`
+	Prefix     string            `gorm:"type:varchar(16);not null" msgpack:"prefix"`
+	Hash       string            `gorm:"type:char(64);uniqueIndex;not null" msgpack:"hash"`
+	Methods    []string          `gorm:"type:jsonb;serializer:json;not null" msgpack:"methods"`
+	RateLimits map[string]string `gorm:"type:jsonb;serializer:json" msgpack:"rate_limits"`
+	ExpiresAt  *time.Time        `msgpack:"expires_at"`
+	LastUsedAt *time.Time        `msgpack:"last_used_at"`
+	RevokedAt  *time.Time        `gorm:"index" msgpack:"revoked_at"`
+	CreatedAt  time.Time         `msgpack:"created_at"`
+	UpdatedAt  time.Time         `msgpack:"updated_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (APIKey) RegisterForCache() {
+	cache.Register((*APIKey)(nil))
+}
+
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !k.ExpiresAt.After(now)
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"notification-service-api/internal/auth/domain"
+	"notification-service-api/internal/auth/domain/entity"
+	"time"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	return r.findOne(ctx, "hash = ?", hash)
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	return r.findOne(ctx, "id = ?", id)
+}
+
+// List returns the keys newest first, revoked ones only when asked for.
+func (r *APIKeyRepository) List(ctx context.Context, owner string, withRevoked bool) ([]entity.APIKey, error) {
+	query := r.db.WithContext(ctx).Order("created_at desc")
+	if owner != "" {
+		query = query.Where("owner = ?", owner)
+	}
+	if !withRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+
+	var keys []entity.APIKey
+	if err := query.Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) UpdateHash(ctx context.Context, id uuid.UUID, hash string, prefix string) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"hash":       hash,
+		"prefix":     prefix,
+		"updated_at": time.Now(),
+	})
+}
+
+// UpdateRateLimits goes through the struct, map updates would skip the json serializer of the column.
+func (r *APIKeyRepository) UpdateRateLimits(ctx context.Context, id uuid.UUID, rateLimits map[string]string) error {
+	res := r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).
+		Select("rate_limits", "updated_at").
+		Updates(&entity.APIKey{RateLimits: rateLimits, UpdatedAt: time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.update(ctx, id, map[string]interface{}{
+		"revoked_at": now,
+		"updated_at": now,
+	})
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+func (r *APIKeyRepository) findOne(ctx context.Context, query string, arg any) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.WithContext(ctx).Where(query, arg).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) update(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	res := r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
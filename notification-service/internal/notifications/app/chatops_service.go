@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/delivery/rpc/dto"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/internal/shared/queue/notifications"
+	"notification-service-api/pkg/utils"
+	"time"
+	"unicode/utf8"
+)
+
+// ChatOpsPort is implemented by the Slack, Discord and Mattermost adapters.
+type ChatOpsPort interface {
+	Send(ctx context.Context, webhookURL string, message *entity.ChatOpsNotification) error
+}
+
+// ChatOpsChannel is a chat tool with its named incoming webhooks.
+type ChatOpsChannel struct {
+	Adapter    ChatOpsPort
+	Targets    map[string]string
+	RoutingKey string
+}
+
+// ChatOpsService serves the chat-ops channels, only the target name goes through the queue, webhook urls stay in the config.
+type ChatOpsService struct {
+	channels   map[domain.Channel]ChatOpsChannel
+	rabbitMQ   *utils.RabbitMQConnection
+	logger     *zap.Logger
+	monitoring domain.NotificationMonitoring
+	statuses   *NotificationStatusService
+}
+
+func NewChatOpsService(channels map[domain.Channel]ChatOpsChannel, rabbitMQ *utils.RabbitMQConnection, monitoring domain.NotificationMonitoring, statuses *NotificationStatusService) *ChatOpsService {
+	return &ChatOpsService{
+		channels:   channels,
+		rabbitMQ:   rabbitMQ,
+		monitoring: monitoring,
+		statuses:   statuses,
+	}
+}
+
+// WithLogger returns a copy of the service bound to the logger, so concurrent callers do not share it.
+func (s *ChatOpsService) WithLogger(logger *zap.Logger) *ChatOpsService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+func (s *ChatOpsService) EnqueueChatOps(ctx context.Context, channel domain.Channel, correlationID string, createdBy string, req dto.ChatOpsRequestSendParams) (uuid.UUID, error) {
+	target := domain.DefaultChatOpsTarget
+	if req.Target != nil {
+		target = *req.Target
+	}
+
+	chatOps, ok := s.channels[channel]
+	if !ok {
+		return uuid.Nil, domain.ErrChatOpsTargetNotFound
+	}
+	if _, ok := chatOps.Targets[target]; !ok {
+		return uuid.Nil, domain.ErrChatOpsTargetNotFound
+	}
+	// every part is within its own limit after validation, Discord also limits them together
+	if channel == domain.ChannelDiscord && chatOpsLength(req) > domain.DiscordMaxEmbedLength {
+		return uuid.Nil, domain.ErrDiscordEmbedTooLong
+	}
+
+	notificationID := uuid.New()
+
+	s.logger.Info(fmt.Sprintf("Start sending %s message to queue, ID: %s", channel, notificationID.String()))
+
+	chatOpsEvent := entity.ChatOpsNotification{
+		NotificationID: notificationID,
+		CorrelationID:  correlationID,
+		Channel:        channel,
+		Target:         target,
+		Title:          req.Title,
+		Text:           req.Text,
+		Level:          req.Level,
+		Footer:         req.Footer,
+		CreatedAt:      time.Now(),
+	}
+	if req.URL != nil {
+		chatOpsEvent.URL = *req.URL
+	}
+	if req.Username != nil {
+		chatOpsEvent.Username = *req.Username
+	}
+	if req.IconURL != nil {
+		chatOpsEvent.IconURL = *req.IconURL
+	}
+	for _, field := range req.Fields {
+		chatOpsEvent.Fields = append(chatOpsEvent.Fields, entity.ChatOpsField{
+			Title: field.Title,
+			Value: field.Value,
+			Short: field.Short,
+		})
+	}
+
+	eventBinary, err := msgpack.Marshal(chatOpsEvent)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to encode %s message", channel), zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	if err := s.statuses.Queued(ctx, notificationID, channel, correlationID, target, createdBy); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to store %s notification", channel), zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	headers := amqp.Table{notifications.HeaderNotificationID: notificationID.String()}
+	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, chatOps.RoutingKey, eventBinary, headers, &correlationID)
+	if err != nil {
+		s.statuses.Failed(ctx, notificationID, err)
+		s.logger.Error(fmt.Sprintf("failed to enqueue %s message", channel), zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("%s message queued successfully, ID: %s", channel, notificationID.String()))
+
+	return notificationID, nil
+}
+
+// chatOpsLength counts the characters of the message the way Discord counts an embed.
+func chatOpsLength(req dto.ChatOpsRequestSendParams) int {
+	n := utf8.RuneCountInString(req.Title) + utf8.RuneCountInString(req.Text) + utf8.RuneCountInString(req.Footer)
+	for _, field := range req.Fields {
+		n += utf8.RuneCountInString(field.Title) + utf8.RuneCountInString(field.Value)
+	}
+	return n
+}
+
+func (s *ChatOpsService) SendChatOps(ctx context.Context, message *entity.ChatOpsNotification) error {
+	s.logger.Info(fmt.Sprintf("Sending %s message, ID: %s", message.Channel, message.NotificationID.String()))
+	s.statuses.Sending(ctx, message.NotificationID)
+
+	err := s.send(ctx, message)
+	if err != nil {
+		s.statuses.Failed(ctx, message.NotificationID, err)
+		s.monitoring.SendError(message.Channel, 1)
+		s.logger.Error(fmt.Sprintf("failed to send %s message", message.Channel), zap.Error(err))
+		return err
+	}
+
+	s.statuses.Sent(ctx, message.NotificationID)
+	s.monitoring.SendSuccess(message.Channel, 1)
+	s.logger.Info(fmt.Sprintf("%s message sent successfully, ID: %s", message.Channel, message.NotificationID.String()))
+	return nil
+}
+
+func (s *ChatOpsService) send(ctx context.Context, message *entity.ChatOpsNotification) error {
+	chatOps, ok := s.channels[message.Channel]
+	if !ok {
+		return utils.Permanent(fmt.Errorf("%s: %w", message.Channel, domain.ErrChatOpsTargetNotFound))
+	}
+
+	// the target may have been removed from the config while the message waited in the queue
+	webhookURL, ok := chatOps.Targets[message.Target]
+	if !ok {
+		return utils.Permanent(fmt.Errorf("%s target %s: %w", message.Channel, message.Target, domain.ErrChatOpsTargetNotFound))
+	}
+
+	return chatOps.Adapter.Send(ctx, webhookURL, message)
+}
@@ -24,27 +24,31 @@ type EmailService struct {
 	rabbitMQ   *utils.RabbitMQConnection
 	logger     *zap.Logger
 	monitoring domain.NotificationMonitoring
+	statuses   *NotificationStatusService
 }
 
-func NewEmailService(emailAPI EmailPort, rabbitMQ *utils.RabbitMQConnection, monitoring domain.NotificationMonitoring) *EmailService {
+func NewEmailService(emailAPI EmailPort, rabbitMQ *utils.RabbitMQConnection, monitoring domain.NotificationMonitoring, statuses *NotificationStatusService) *EmailService {
 	return &EmailService{
 		emailAPI:   emailAPI,
 		rabbitMQ:   rabbitMQ,
 		monitoring: monitoring,
+		statuses:   statuses,
 	}
 }
 
+// WithLogger returns a copy of the service bound to the logger, so concurrent callers do not share it.
 func (s *EmailService) WithLogger(logger *zap.Logger) *EmailService {
-	s.logger = logger
-	return s
+	clone := *s
+	clone.logger = logger
+	return &clone
 }
 
-func (s *EmailService) EnqueueEmail(ctx context.Context, correlationID string, req dto.EmailRequestSendParams) (uuid.UUID, error) {
+func (s *EmailService) EnqueueEmail(ctx context.Context, correlationID string, createdBy string, req dto.EmailRequestSendParams) (uuid.UUID, error) {
 	notificationID := uuid.New()
 
 	s.logger.Info(fmt.Sprintf("Start sending email to queue, ID: %s", notificationID.String()))
 
-	attachments := make([]entity.EmailAttachment, len(req.Attachments))
+	attachments := make([]entity.EmailAttachment, 0, len(req.Attachments))
 	for _, attachment := range req.Attachments {
 		data, err := attachment.Bytes()
 		if err != nil {
@@ -56,6 +60,8 @@ func (s *EmailService) EnqueueEmail(ctx context.Context, correlationID string, r
 			Filename:    attachment.Filename,
 			Data:        data,
 			ContentType: attachment.ContentType,
+			ContentID:   attachment.ContentID,
+			Inline:      attachment.Disposition == "inline" || (attachment.Disposition == "" && attachment.ContentID != ""),
 		})
 	}
 
@@ -64,15 +70,15 @@ func (s *EmailService) EnqueueEmail(ctx context.Context, correlationID string, r
 		CorrelationID:  correlationID,
 		To:             req.To,
 		Subject:        req.Subject,
-		Body:           req.Body,
-		ContentType:    req.ContentType,
 		CC:             req.CC,
 		BCC:            req.BCC,
 		From:           req.From,
 		ReplyTo:        req.ReplyTo,
 		Attachments:    attachments,
+		CalendarEvent:  toEmailCalendarEvent(notificationID, req.CalendarEvent),
 		CreatedAt:      time.Now(),
 	}
+	emailEvent.HTML, emailEvent.Text = toEmailBodies(req)
 
 	s.logger.Info(fmt.Sprintf("Email: %v, ID: %s", emailEvent, notificationID.String()))
 
@@ -82,8 +88,15 @@ func (s *EmailService) EnqueueEmail(ctx context.Context, correlationID string, r
 		return uuid.Nil, err
 	}
 
-	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, notifications.RoutingEmailSend, eventBinary, amqp.Table{}, &correlationID)
+	if err := s.statuses.Queued(ctx, notificationID, domain.ChannelEmail, correlationID, req.To, createdBy); err != nil {
+		s.logger.Error("failed to store email notification", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	headers := amqp.Table{notifications.HeaderNotificationID: notificationID.String()}
+	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, notifications.RoutingEmailSend, eventBinary, headers, &correlationID)
 	if err != nil {
+		s.statuses.Failed(ctx, notificationID, err)
 		s.logger.Error("failed to enqueue email", zap.Error(err))
 		return uuid.Nil, err
 	}
@@ -95,14 +108,68 @@ func (s *EmailService) EnqueueEmail(ctx context.Context, correlationID string, r
 
 func (s *EmailService) SendEmail(ctx context.Context, email *entity.EmailNotification) error {
 	s.logger.Info(fmt.Sprintf("Sending email, ID: %s", email.NotificationID.String()))
+	s.statuses.Sending(ctx, email.NotificationID)
+
 	err := s.emailAPI.SendEmailViaSMTP(ctx, email)
 	if err != nil {
+		s.statuses.Failed(ctx, email.NotificationID, err)
 		s.monitoring.SendError(domain.ChannelEmail, 1)
 		s.logger.Error("failed to send email", zap.Error(err))
 		return err
 	}
 
+	s.statuses.Sent(ctx, email.NotificationID)
 	s.monitoring.SendSuccess(domain.ChannelEmail, 1)
 	s.logger.Info(fmt.Sprintf("Email sent successfully, ID: %s", email.NotificationID.String()))
 	return nil
 }
+
+// toEmailCalendarEvent copies the invite, an event without uid is identified by the notification.
+func toEmailCalendarEvent(notificationID uuid.UUID, event *dto.EmailCalendarEvent) *entity.EmailCalendarEvent {
+	if event == nil {
+		return nil
+	}
+
+	uid := event.UID
+	if uid == "" {
+		uid = notificationID.String() + "@notification-service"
+	}
+
+	attendees := make([]entity.EmailCalendarPerson, 0, len(event.Attendees))
+	for _, attendee := range event.Attendees {
+		attendees = append(attendees, entity.EmailCalendarPerson(attendee))
+	}
+
+	return &entity.EmailCalendarEvent{
+		UID:         uid,
+		Sequence:    event.Sequence,
+		Summary:     event.Summary,
+		Description: event.Description,
+		Location:    event.Location,
+		Start:       event.Start,
+		End:         event.End,
+		Timezone:    event.Timezone,
+		Organizer:   entity.EmailCalendarPerson(event.Organizer),
+		Attendees:   attendees,
+	}
+}
+
+// toEmailBodies returns the HTML and text bodies of the request, body is one of them by its content_type.
+func toEmailBodies(req dto.EmailRequestSendParams) (html string, text string) {
+	if req.HTML != nil {
+		html = *req.HTML
+	}
+	if req.Text != nil {
+		text = *req.Text
+	}
+
+	if req.Body != "" {
+		if req.ContentType == "text/html" {
+			html = req.Body
+		} else {
+			text = req.Body
+		}
+	}
+
+	return html, text
+}
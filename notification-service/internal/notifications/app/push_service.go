@@ -0,0 +1,221 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/delivery/rpc/dto"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/internal/shared/queue/notifications"
+	"notification-service-api/pkg/utils"
+	"time"
+)
+
+// PushPort is implemented by every push provider, tokens the provider rejects for good are reported as domain.ErrInvalidDeviceToken.
+type PushPort interface {
+	Send(ctx context.Context, token string, message *entity.PushNotification) error
+}
+
+type DeviceTokenStorePort interface {
+	Upsert(ctx context.Context, device *entity.DeviceToken) error
+	FindByToken(ctx context.Context, owner string, token string) (*entity.DeviceToken, error)
+	ListByUser(ctx context.Context, owner string, userID string) ([]entity.DeviceToken, error)
+	Delete(ctx context.Context, owner string, token string) error
+	DeleteTokens(ctx context.Context, tokens []string) error
+	TouchLastUsed(ctx context.Context, tokens []string, at time.Time) error
+}
+
+type PushService struct {
+	providers  map[domain.Platform]PushPort
+	devices    DeviceTokenStorePort
+	rabbitMQ   *utils.RabbitMQConnection
+	logger     *zap.Logger
+	monitoring domain.NotificationMonitoring
+	statuses   *NotificationStatusService
+}
+
+func NewPushService(providers map[domain.Platform]PushPort, devices DeviceTokenStorePort, rabbitMQ *utils.RabbitMQConnection, monitoring domain.NotificationMonitoring, statuses *NotificationStatusService) *PushService {
+	return &PushService{
+		providers:  providers,
+		devices:    devices,
+		rabbitMQ:   rabbitMQ,
+		monitoring: monitoring,
+		statuses:   statuses,
+	}
+}
+
+// WithLogger returns a copy of the service bound to the logger, so concurrent callers do not share it.
+func (s *PushService) WithLogger(logger *zap.Logger) *PushService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+func (s *PushService) Register(ctx context.Context, owner string, req dto.PushRegisterParams) (*entity.DeviceToken, error) {
+	if domain.Platform(req.Platform) == domain.PlatformAPNs && !domain.IsAPNsToken(req.Token) {
+		return nil, domain.ErrMalformedAPNsToken
+	}
+
+	now := time.Now()
+	device := &entity.DeviceToken{
+		ID:        uuid.New(),
+		Owner:     owner,
+		UserID:    req.UserID,
+		Platform:  domain.Platform(req.Platform),
+		Token:     req.Token,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := s.devices.Upsert(ctx, device); err != nil {
+		return nil, err
+	}
+
+	// an already registered token keeps its id
+	return s.devices.FindByToken(ctx, owner, req.Token)
+}
+
+func (s *PushService) Unregister(ctx context.Context, owner string, token string) error {
+	return s.devices.Delete(ctx, owner, token)
+}
+
+func (s *PushService) EnqueuePush(ctx context.Context, correlationID string, createdBy string, req dto.PushRequestSendParams) (uuid.UUID, error) {
+	notificationID := uuid.New()
+
+	s.logger.Info(fmt.Sprintf("Start sending push to queue, ID: %s", notificationID.String()))
+
+	pushEvent := entity.PushNotification{
+		NotificationID: notificationID,
+		CorrelationID:  correlationID,
+		Owner:          createdBy,
+		Title:          req.Title,
+		Body:           req.Body,
+		Data:           req.Data,
+		Badge:          req.Badge,
+		CreatedAt:      time.Now(),
+	}
+	if req.Sound != nil {
+		pushEvent.Sound = *req.Sound
+	}
+
+	// unknown targets are rejected right away instead of dying in the queue
+	var recipient string
+	if req.Token != nil {
+		if _, err := s.devices.FindByToken(ctx, createdBy, *req.Token); err != nil {
+			return uuid.Nil, err
+		}
+		pushEvent.Token = *req.Token
+		recipient = "token:" + *req.Token
+	} else {
+		devices, err := s.devices.ListByUser(ctx, createdBy, *req.UserID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if len(devices) == 0 {
+			return uuid.Nil, domain.ErrNoDevices
+		}
+		pushEvent.UserID = *req.UserID
+		recipient = "user:" + *req.UserID
+	}
+
+	eventBinary, err := msgpack.Marshal(pushEvent)
+	if err != nil {
+		s.logger.Error("failed to encode push", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	if err := s.statuses.Queued(ctx, notificationID, domain.ChannelPush, correlationID, recipient, createdBy); err != nil {
+		s.logger.Error("failed to store push notification", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	headers := amqp.Table{notifications.HeaderNotificationID: notificationID.String()}
+	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, notifications.RoutingPushSend, eventBinary, headers, &correlationID)
+	if err != nil {
+		s.statuses.Failed(ctx, notificationID, err)
+		s.logger.Error("failed to enqueue push", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Push queued successfully, ID: %s", notificationID.String()))
+
+	return notificationID, nil
+}
+
+// SendPush delivers to every device of the target. Tokens rejected by the provider are pruned,
+// the message is retried only when no device got it, so retries never duplicate a delivered push.
+func (s *PushService) SendPush(ctx context.Context, push *entity.PushNotification) error {
+	s.logger.Info(fmt.Sprintf("Sending push, ID: %s", push.NotificationID.String()))
+	s.statuses.Sending(ctx, push.NotificationID)
+
+	devices, err := s.targets(ctx, push)
+	if err != nil {
+		return s.failed(ctx, push, err)
+	}
+
+	var delivered, invalid []string
+	var lastErr error
+	for _, device := range devices {
+		provider, ok := s.providers[device.Platform]
+		if !ok {
+			lastErr = fmt.Errorf("push provider %s is not configured", device.Platform)
+			continue
+		}
+
+		err := provider.Send(ctx, device.Token, push)
+		switch {
+		case err == nil:
+			delivered = append(delivered, device.Token)
+		case errors.Is(err, domain.ErrInvalidDeviceToken):
+			invalid = append(invalid, device.Token)
+			s.logger.Warn(fmt.Sprintf("Pruning %s device token of user %s: %v", device.Platform, device.UserID, err))
+		default:
+			lastErr = err
+			s.logger.Error(fmt.Sprintf("failed to send push to %s device", device.Platform), zap.Error(err))
+		}
+	}
+
+	if err := s.devices.DeleteTokens(ctx, invalid); err != nil {
+		s.logger.Error("failed to prune device tokens", zap.Error(err))
+	}
+
+	if len(delivered) == 0 {
+		if lastErr == nil {
+			lastErr = domain.ErrNoDevices
+		}
+		return s.failed(ctx, push, lastErr)
+	}
+
+	if err := s.devices.TouchLastUsed(ctx, delivered, time.Now()); err != nil {
+		s.logger.Error("failed to touch device tokens", zap.Error(err))
+	}
+
+	s.statuses.Sent(ctx, push.NotificationID)
+	s.monitoring.SendSuccess(domain.ChannelPush, int64(len(delivered)))
+	s.logger.Info(fmt.Sprintf("Push sent successfully to %d of %d devices, ID: %s", len(delivered), len(devices), push.NotificationID.String()))
+	return nil
+}
+
+func (s *PushService) targets(ctx context.Context, push *entity.PushNotification) ([]entity.DeviceToken, error) {
+	if push.Token != "" {
+		device, err := s.devices.FindByToken(ctx, push.Owner, push.Token)
+		if err != nil {
+			return nil, err
+		}
+		return []entity.DeviceToken{*device}, nil
+	}
+
+	return s.devices.ListByUser(ctx, push.Owner, push.UserID)
+}
+
+func (s *PushService) failed(ctx context.Context, push *entity.PushNotification, err error) error {
+	s.statuses.Failed(ctx, push.NotificationID, err)
+	s.monitoring.SendError(domain.ChannelPush, 1)
+	s.logger.Error("failed to send push", zap.Error(err))
+	return err
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/delivery/rpc/dto"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/internal/shared/queue/notifications"
+	"notification-service-api/pkg/utils"
+	"time"
+)
+
+// SMSPort is implemented by every SMS provider.
+type SMSPort interface {
+	SendSMS(ctx context.Context, message *entity.SMSNotification) error
+}
+
+type SMSService struct {
+	provider   SMSPort
+	rabbitMQ   *utils.RabbitMQConnection
+	logger     *zap.Logger
+	monitoring domain.NotificationMonitoring
+	statuses   *NotificationStatusService
+}
+
+func NewSMSService(provider SMSPort, rabbitMQ *utils.RabbitMQConnection, monitoring domain.NotificationMonitoring, statuses *NotificationStatusService) *SMSService {
+	return &SMSService{
+		provider:   provider,
+		rabbitMQ:   rabbitMQ,
+		monitoring: monitoring,
+		statuses:   statuses,
+	}
+}
+
+// WithLogger returns a copy of the service bound to the logger, so concurrent callers do not share it.
+func (s *SMSService) WithLogger(logger *zap.Logger) *SMSService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// Enabled reports whether SMS_PROVIDER is set, without it sms.send is refused and the queue is not consumed.
+func (s *SMSService) Enabled() bool {
+	return s.provider != nil
+}
+
+func (s *SMSService) EnqueueSMS(ctx context.Context, correlationID string, createdBy string, req dto.SMSRequestSendParams) (uuid.UUID, error) {
+	if !s.Enabled() {
+		return uuid.Nil, domain.ErrChannelDisabled
+	}
+
+	notificationID := uuid.New()
+
+	s.logger.Info(fmt.Sprintf("Start sending sms to queue, ID: %s", notificationID.String()))
+
+	sender := ""
+	if req.Sender != nil {
+		sender = *req.Sender
+	}
+
+	smsEvent := entity.SMSNotification{
+		NotificationID: notificationID,
+		CorrelationID:  correlationID,
+		To:             req.To,
+		Message:        req.Message,
+		Sender:         sender,
+		CreatedAt:      time.Now(),
+	}
+
+	eventBinary, err := msgpack.Marshal(smsEvent)
+	if err != nil {
+		s.logger.Error("failed to encode sms", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	if err := s.statuses.Queued(ctx, notificationID, domain.ChannelSMS, correlationID, req.To, createdBy); err != nil {
+		s.logger.Error("failed to store sms notification", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	headers := amqp.Table{notifications.HeaderNotificationID: notificationID.String()}
+	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, notifications.RoutingSMSSend, eventBinary, headers, &correlationID)
+	if err != nil {
+		s.statuses.Failed(ctx, notificationID, err)
+		s.logger.Error("failed to enqueue sms", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("SMS queued successfully, ID: %s", notificationID.String()))
+
+	return notificationID, nil
+}
+
+func (s *SMSService) SendSMS(ctx context.Context, sms *entity.SMSNotification) error {
+	s.logger.Info(fmt.Sprintf("Sending sms, ID: %s", sms.NotificationID.String()))
+	s.statuses.Sending(ctx, sms.NotificationID)
+
+	err := s.provider.SendSMS(ctx, sms)
+	if err != nil {
+		s.statuses.Failed(ctx, sms.NotificationID, err)
+		s.monitoring.SendError(domain.ChannelSMS, 1)
+		s.logger.Error("failed to send sms", zap.Error(err))
+		return err
+	}
+
+	s.statuses.Sent(ctx, sms.NotificationID)
+	s.monitoring.SendSuccess(domain.ChannelSMS, 1)
+	s.logger.Info(fmt.Sprintf("SMS sent successfully, ID: %s", sms.NotificationID.String()))
+	return nil
+}
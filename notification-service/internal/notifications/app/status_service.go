@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"time"
+)
+
+type NotificationStorePort interface {
+	Create(ctx context.Context, notification *entity.Notification) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.Status, lastError *string) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.Notification, error)
+}
+
+type StatusEventsPort interface {
+	Publish(ctx context.Context, event domain.StatusEvent) error
+}
+
+type NotificationStatusService struct {
+	store  NotificationStorePort
+	events StatusEventsPort
+	logger *zap.Logger
+}
+
+func NewNotificationStatusService(store NotificationStorePort, events StatusEventsPort, logger *zap.Logger) *NotificationStatusService {
+	return &NotificationStatusService{
+		store:  store,
+		events: events,
+		logger: logger,
+	}
+}
+
+// Queued stores a new notification, createdBy is the principal that is allowed to follow it.
+func (s *NotificationStatusService) Queued(ctx context.Context, id uuid.UUID, channel domain.Channel, correlationID string, recipient string, createdBy string) error {
+	now := time.Now()
+
+	return s.store.Create(ctx, &entity.Notification{
+		ID:            id,
+		Channel:       channel,
+		Status:        domain.StatusQueued,
+		CorrelationID: correlationID,
+		Recipient:     recipient,
+		CreatedBy:     createdBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+}
+
+func (s *NotificationStatusService) Sending(ctx context.Context, id uuid.UUID) {
+	s.transition(ctx, id, domain.StatusSending, nil)
+}
+
+func (s *NotificationStatusService) Sent(ctx context.Context, id uuid.UUID) {
+	s.transition(ctx, id, domain.StatusSent, nil)
+}
+
+func (s *NotificationStatusService) Failed(ctx context.Context, id uuid.UUID, cause error) {
+	s.transition(ctx, id, domain.StatusFailed, cause)
+}
+
+func (s *NotificationStatusService) Retrying(ctx context.Context, id uuid.UUID, cause error) {
+	s.transition(ctx, id, domain.StatusRetrying, cause)
+}
+
+func (s *NotificationStatusService) Dead(ctx context.Context, id uuid.UUID, cause error) {
+	s.transition(ctx, id, domain.StatusDead, cause)
+}
+
+func (s *NotificationStatusService) Get(ctx context.Context, id uuid.UUID) (*entity.Notification, error) {
+	return s.store.FindByID(ctx, id)
+}
+
+// transition never fails the delivery itself: a lost status update is logged, not retried.
+func (s *NotificationStatusService) transition(ctx context.Context, id uuid.UUID, status domain.Status, cause error) {
+	var lastError *string
+	if cause != nil {
+		msg := cause.Error()
+		lastError = &msg
+	}
+
+	if err := s.store.UpdateStatus(ctx, id, status, lastError); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to mark notification %s as %s", id.String(), status), zap.Error(err))
+		return
+	}
+
+	event := domain.StatusEvent{
+		NotificationID: id,
+		Status:         status,
+		LastError:      lastError,
+		At:             time.Now(),
+	}
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to publish status %s of notification %s", status, id.String()), zap.Error(err))
+	}
+}
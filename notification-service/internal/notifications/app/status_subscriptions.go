@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/delivery/rpc/dto"
+	"notification-service-api/internal/notifications/domain"
+	"sync"
+)
+
+const MethodNotificationStatus = "notification.status"
+
+type StatusEventsSourcePort interface {
+	Subscribe(ctx context.Context, handle func(event domain.StatusEvent)) error
+}
+
+// StatusSubscriberPort is a connection that receives pushed status changes (rpc.Session).
+type StatusSubscriberPort interface {
+	ID() string
+	Notify(method string, params any) error
+	Done() <-chan struct{}
+	Close()
+}
+
+// statusSubscriberBuffer is how many events a subscriber may fall behind before its connection is closed.
+const statusSubscriberBuffer = 64
+
+// statusSubscriber is a connection with the events queued for it, its own goroutine pushes them
+// so a slow connection never holds up the others.
+type statusSubscriber struct {
+	port   StatusSubscriberPort
+	events chan dto.NotificationStatusEventDTO
+	ids    map[uuid.UUID]struct{}
+}
+
+// StatusSubscriptions pushes status events to the connections that subscribed to a notification.
+type StatusSubscriptions struct {
+	source StatusEventsSourcePort
+	logger *zap.Logger
+
+	mu             sync.Mutex
+	byNotification map[uuid.UUID]map[string]*statusSubscriber
+	bySubscriber   map[string]*statusSubscriber
+}
+
+func NewStatusSubscriptions(source StatusEventsSourcePort, logger *zap.Logger) *StatusSubscriptions {
+	return &StatusSubscriptions{
+		source:         source,
+		logger:         logger,
+		byNotification: make(map[uuid.UUID]map[string]*statusSubscriber),
+		bySubscriber:   make(map[string]*statusSubscriber),
+	}
+}
+
+// Run delivers events until ctx is done.
+func (s *StatusSubscriptions) Run(ctx context.Context) {
+	if err := s.source.Subscribe(ctx, s.dispatch); err != nil {
+		s.logger.Error("status events subscription stopped", zap.Error(err))
+	}
+}
+
+func (s *StatusSubscriptions) Subscribe(port StatusSubscriberPort, id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriber, known := s.bySubscriber[port.ID()]
+	if !known {
+		subscriber = &statusSubscriber{
+			port:   port,
+			events: make(chan dto.NotificationStatusEventDTO, statusSubscriberBuffer),
+			ids:    make(map[uuid.UUID]struct{}),
+		}
+		s.bySubscriber[port.ID()] = subscriber
+
+		// the subscriber is forgotten with its connection
+		go s.push(subscriber)
+	}
+	subscriber.ids[id] = struct{}{}
+
+	subscribers, ok := s.byNotification[id]
+	if !ok {
+		subscribers = make(map[string]*statusSubscriber)
+		s.byNotification[id] = subscribers
+	}
+	subscribers[port.ID()] = subscriber
+}
+
+func (s *StatusSubscriptions) Unsubscribe(port StatusSubscriberPort, id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(port.ID(), id)
+}
+
+// push sends the queued events of a subscriber in order until its connection is gone.
+func (s *StatusSubscriptions) push(subscriber *statusSubscriber) {
+	for {
+		select {
+		case params := <-subscriber.events:
+			if err := subscriber.port.Notify(MethodNotificationStatus, params); err != nil {
+				s.logger.Warn(fmt.Sprintf("failed to push status of notification %s", params.NotificationID), zap.Error(err))
+			}
+		case <-subscriber.port.Done():
+			s.forget(subscriber.port.ID())
+			return
+		}
+	}
+}
+
+func (s *StatusSubscriptions) forget(subscriberID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subscriber, ok := s.bySubscriber[subscriberID]; ok {
+		for id := range subscriber.ids {
+			s.remove(subscriberID, id)
+		}
+	}
+	delete(s.bySubscriber, subscriberID)
+}
+
+// remove must be called with s.mu held.
+func (s *StatusSubscriptions) remove(subscriberID string, id uuid.UUID) {
+	if subscribers, ok := s.byNotification[id]; ok {
+		delete(subscribers, subscriberID)
+		if len(subscribers) == 0 {
+			delete(s.byNotification, id)
+		}
+	}
+	if subscriber, ok := s.bySubscriber[subscriberID]; ok {
+		delete(subscriber.ids, id)
+	}
+}
+
+// dispatch queues the event for its subscribers without waiting, a subscriber whose queue is full is disconnected
+// rather than silently missing the event, its client reconnects and reads the current status.
+func (s *StatusSubscriptions) dispatch(event domain.StatusEvent) {
+	s.mu.Lock()
+	subscribers := make([]*statusSubscriber, 0, len(s.byNotification[event.NotificationID]))
+	for _, subscriber := range s.byNotification[event.NotificationID] {
+		subscribers = append(subscribers, subscriber)
+	}
+	if event.Status.IsFinal() {
+		for _, subscriber := range subscribers {
+			s.remove(subscriber.port.ID(), event.NotificationID)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(subscribers) == 0 {
+		return
+	}
+
+	params := dto.NotificationStatusEventDTO{
+		NotificationID: event.NotificationID.String(),
+		Status:         event.Status.String(),
+		LastError:      event.LastError,
+		At:             event.At,
+		Final:          event.Status.IsFinal(),
+	}
+
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber.events <- params:
+		default:
+			s.logger.Warn(fmt.Sprintf("status subscriber %s is too slow, closing its connection", subscriber.port.ID()))
+			subscriber.port.Close()
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"notification-service-api/internal/notifications/domain"
+	"sort"
+	"strings"
+)
+
+// TelegramBot is a configured bot with its own Bot API client, send limits and monitoring series.
+// A Local bot talks to a self-hosted Bot API server, which also sends files by file:// url from the LocalFiles directory.
+type TelegramBot struct {
+	Name       string
+	Username   string
+	API        TelegramPort
+	Throttle   TelegramThrottlePort
+	Monitoring domain.NotificationMonitoring
+	Local      bool
+	LocalFiles string
+}
+
+// TelegramBots is the registry of configured bots, requests choose one by name.
+type TelegramBots struct {
+	bots       map[string]TelegramBot
+	defaultBot string
+}
+
+func NewTelegramBots(bots []TelegramBot, defaultBot string) *TelegramBots {
+	registry := &TelegramBots{
+		bots:       make(map[string]TelegramBot, len(bots)),
+		defaultBot: defaultBot,
+	}
+	for _, bot := range bots {
+		bot.Username = strings.TrimPrefix(bot.Username, "@")
+		registry.bots[bot.Name] = bot
+	}
+
+	return registry
+}
+
+// Get returns the bot by name, an empty name is the default bot.
+func (r *TelegramBots) Get(name string) (TelegramBot, bool) {
+	if name == "" {
+		name = r.defaultBot
+	}
+	bot, ok := r.bots[name]
+	return bot, ok
+}
+
+// Names returns the names of the configured bots in order.
+func (r *TelegramBots) Names() []string {
+	names := make([]string, 0, len(r.bots))
+	for name := range r.bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve returns the bot a request names, an unknown name is reported like a failed validation.
+func (r *TelegramBots) resolve(name *string) (TelegramBot, error) {
+	var requested string
+	if name != nil {
+		requested = *name
+	}
+
+	bot, ok := r.Get(requested)
+	if !ok {
+		return TelegramBot{}, &domain.TelegramLimitError{
+			Field:   "bot",
+			Rule:    "oneof",
+			Message: fmt.Sprintf("bot must be one of %v", r.Names()),
+		}
+	}
+
+	return bot, nil
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TelegramLinkStorePort interface {
+	Create(ctx context.Context, token string, link domain.TelegramLink, ttl time.Duration) error
+	// Take returns the link once, a used or expired token is domain.ErrTelegramLinkNotFound.
+	Take(ctx context.Context, token string) (*domain.TelegramLink, error)
+}
+
+type TelegramBindingStorePort interface {
+	Upsert(ctx context.Context, binding *entity.TelegramBinding) error
+	FindByUser(ctx context.Context, owner string, userID string, bot string) (*entity.TelegramBinding, error)
+	DeleteByChat(ctx context.Context, bot string, chatID int64) (int64, error)
+	// MoveChat follows a group that was upgraded to a supergroup with a new id.
+	MoveChat(ctx context.Context, bot string, chatID int64, newChatID int64) error
+}
+
+const (
+	telegramReplyLinked   = "Notifications are connected. Send /stop to unsubscribe."
+	telegramReplyExpired  = "This link has expired or was already used, please request a new one."
+	telegramReplyNoToken  = "Open the link you were given to connect notifications."
+	telegramReplyStopped  = "You will no longer receive notifications."
+	telegramReplyNotBound = "This chat is not subscribed to notifications."
+)
+
+// TelegramLinkService binds Telegram chats to callers' users through /start deep links and answers the bot commands.
+// A chat is bound per bot, a user reached by two bots links with each of them.
+type TelegramLinkService struct {
+	bots     *TelegramBots
+	links    TelegramLinkStorePort
+	bindings TelegramBindingStorePort
+	linkTTL  time.Duration
+	logger   *zap.Logger
+}
+
+func NewTelegramLinkService(bots *TelegramBots, links TelegramLinkStorePort, bindings TelegramBindingStorePort, linkTTL time.Duration, logger *zap.Logger) *TelegramLinkService {
+	return &TelegramLinkService{
+		bots:     bots,
+		links:    links,
+		bindings: bindings,
+		linkTTL:  linkTTL,
+		logger:   logger,
+	}
+}
+
+// WithLogger returns a copy of the service bound to the logger, so concurrent callers do not share it.
+func (s *TelegramLinkService) WithLogger(logger *zap.Logger) *TelegramLinkService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// CreateLink returns a one-time /start token of the bot for the user and the t.me deep link with it,
+// the link is empty when the bot username is not configured. A nil bot is the default bot.
+func (s *TelegramLinkService) CreateLink(ctx context.Context, owner string, userID string, botName *string) (token string, link string, expiresAt time.Time, err error) {
+	bot, err := s.bots.resolve(botName)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", time.Time{}, err
+	}
+	// deep link payloads allow A-Z, a-z, 0-9, _ and -
+	token = base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := s.links.Create(ctx, token, domain.TelegramLink{Owner: owner, UserID: userID, Bot: bot.Name}, s.linkTTL); err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	if bot.Username != "" {
+		link = fmt.Sprintf("https://t.me/%s?start=%s", bot.Username, token)
+	}
+
+	s.logger.Info(fmt.Sprintf("Telegram link of bot %s created for user %s of %s", bot.Name, userID, owner))
+
+	return token, link, time.Now().Add(s.linkTTL), nil
+}
+
+// HandleMessage answers /start <token> and /stop received by the named bot, other messages are ignored.
+func (s *TelegramLinkService) HandleMessage(ctx context.Context, botName string, chatID int64, username string, text string) error {
+	bot, ok := s.bots.Get(botName)
+	if !ok {
+		return fmt.Errorf("telegram bot %q is not configured", botName)
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// in groups commands may be addressed as /start@bot_username
+	command, _, _ := strings.Cut(fields[0], "@")
+
+	switch command {
+	case "/start":
+		if len(fields) < 2 {
+			return s.reply(ctx, bot, chatID, telegramReplyNoToken)
+		}
+		return s.link(ctx, bot, chatID, username, fields[1])
+	case "/stop":
+		return s.unlink(ctx, bot, chatID)
+	default:
+		return nil
+	}
+}
+
+func (s *TelegramLinkService) link(ctx context.Context, bot TelegramBot, chatID int64, username string, token string) error {
+	link, err := s.links.Take(ctx, token)
+	if errors.Is(err, domain.ErrTelegramLinkNotFound) {
+		return s.reply(ctx, bot, chatID, telegramReplyExpired)
+	}
+	if err != nil {
+		return err
+	}
+	// a token opened with another bot is used up, that bot could not reach the chat anyway
+	if link.Bot != bot.Name {
+		return s.reply(ctx, bot, chatID, telegramReplyExpired)
+	}
+
+	now := time.Now()
+	err = s.bindings.Upsert(ctx, &entity.TelegramBinding{
+		ID:        uuid.New(),
+		Owner:     link.Owner,
+		UserID:    link.UserID,
+		Bot:       bot.Name,
+		ChatID:    chatID,
+		Username:  username,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info(fmt.Sprintf("Telegram chat %d of bot %s linked to user %s of %s", chatID, bot.Name, link.UserID, link.Owner))
+
+	return s.reply(ctx, bot, chatID, telegramReplyLinked)
+}
+
+func (s *TelegramLinkService) unlink(ctx context.Context, bot TelegramBot, chatID int64) error {
+	removed, err := s.bindings.DeleteByChat(ctx, bot.Name, chatID)
+	if err != nil {
+		return err
+	}
+
+	if removed == 0 {
+		return s.reply(ctx, bot, chatID, telegramReplyNotBound)
+	}
+
+	s.logger.Info(fmt.Sprintf("Telegram chat %d of bot %s unlinked from %d users", chatID, bot.Name, removed))
+
+	return s.reply(ctx, bot, chatID, telegramReplyStopped)
+}
+
+// reply answers in the chat right away, a lost answer does not undo the command.
+func (s *TelegramLinkService) reply(ctx context.Context, bot TelegramBot, chatID int64, text string) error {
+	_, err := bot.API.SendMessage(ctx, &entity.TelegramNotification{To: strconv.FormatInt(chatID, 10), Payload: text})
+	if err != nil {
+		s.logger.Warn(fmt.Sprintf("failed to reply to telegram chat %d", chatID), zap.Error(err))
+	}
+
+	return nil
+}
@@ -2,91 +2,860 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/streadway/amqp"
 	"github.com/vmihailenco/msgpack/v5"
 	"go.uber.org/zap"
+	"net/url"
 	"notification-service-api/internal/notifications/delivery/rpc/dto"
 	"notification-service-api/internal/notifications/domain"
 	"notification-service-api/internal/notifications/domain/entity"
 	"notification-service-api/internal/shared/queue/notifications"
 	"notification-service-api/pkg/utils"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type TelegramPort interface {
-	SendMessage(ctx context.Context, to string, message string, parseMode string) error
+	SendMessage(ctx context.Context, message *entity.TelegramNotification) (domain.TelegramSentMessage, error)
+	// SendMedia sends a single photo or document, or an album of 2-10 items.
+	SendMedia(ctx context.Context, message *entity.TelegramNotification) ([]domain.TelegramSentMessage, error)
+	// EditMessage replaces the text, or the caption of a media message, and the keyboard of a sent message.
+	EditMessage(ctx context.Context, target entity.TelegramMessage, message *entity.TelegramNotification) error
+	DeleteMessages(ctx context.Context, chatID int64, messageIDs []int64) error
 }
 
+// TelegramThrottlePort reserves a send slot for a chat, a positive duration means none is free yet.
+type TelegramThrottlePort interface {
+	Reserve(ctx context.Context, chatID string) (time.Duration, error)
+	// Block gives no slots for the chat for d.
+	Block(ctx context.Context, chatID string, d time.Duration) error
+}
+
+// TelegramMessageStorePort keeps the ids of delivered messages, for edits and deletes and to resume a split message.
+type TelegramMessageStorePort interface {
+	Save(ctx context.Context, messages []entity.TelegramMessage) error
+	ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]entity.TelegramMessage, error)
+	DeleteByNotification(ctx context.Context, notificationID uuid.UUID) error
+}
+
+// telegramMaxInlineWait is the longest a worker sleeps for a send slot, longer waits go through the delay queue.
+const telegramMaxInlineWait = time.Second
+
 type TelegramService struct {
-	tg         TelegramPort
-	rabbitMQ   *utils.RabbitMQConnection
-	logger     *zap.Logger
-	monitoring domain.NotificationMonitoring
+	bots     *TelegramBots
+	messages TelegramMessageStorePort
+	bindings TelegramBindingStorePort
+	rabbitMQ *utils.RabbitMQConnection
+	logger   *zap.Logger
+	statuses *NotificationStatusService
+	// plainTextFallback resends a message Telegram cannot parse without formatting
+	plainTextFallback bool
 }
 
-func NewTelegramService(t TelegramPort, rabbitMQ *utils.RabbitMQConnection, monitoring domain.NotificationMonitoring) *TelegramService {
+func NewTelegramService(bots *TelegramBots, messages TelegramMessageStorePort, bindings TelegramBindingStorePort, plainTextFallback bool, rabbitMQ *utils.RabbitMQConnection, statuses *NotificationStatusService) *TelegramService {
 	return &TelegramService{
-		tg:         t,
-		rabbitMQ:   rabbitMQ,
-		monitoring: monitoring,
+		bots:              bots,
+		messages:          messages,
+		bindings:          bindings,
+		rabbitMQ:          rabbitMQ,
+		statuses:          statuses,
+		plainTextFallback: plainTextFallback,
 	}
 }
 
+// WithLogger returns a copy of the service bound to the logger, so concurrent callers do not share it.
 func (s *TelegramService) WithLogger(logger *zap.Logger) *TelegramService {
-	s.logger = logger
-	return s
+	clone := *s
+	clone.logger = logger
+	return &clone
 }
 
-func (s *TelegramService) EnqueueTelegram(ctx context.Context, correlationID string, req dto.TelegramRequestSendParams) (uuid.UUID, error) {
+func (s *TelegramService) EnqueueTelegram(ctx context.Context, correlationID string, createdBy string, req dto.TelegramRequestSendParams) (uuid.UUID, error) {
 	notificationID := uuid.New()
 
 	s.logger.Info(fmt.Sprintf("Start sending tg notification to queue, ID: %s", notificationID.String()))
 
-	parseMode := "Markdown"
-	if req.ParseMode != nil {
-		parseMode = *req.ParseMode
+	bot, err := s.bots.resolve(req.Bot)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	to, err := s.resolveChat(ctx, createdBy, bot.Name, req)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	parseMode, err := toTelegramParseMode(req.ParseMode)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	message := req.Message
+	if req.Escape {
+		message = domain.EscapeTelegramText(message, parseMode)
+	}
+
+	media, err := toTelegramMedia(req, bot, message, parseMode)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	keyboard, err := toTelegramKeyboard(req.InlineKeyboard)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var parts []string
+	if len(media) == 0 {
+		parts, err = toTelegramParts(message, parseMode)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	tgEvent := entity.TelegramNotification{
+		NotificationID:           notificationID,
+		CorrelationID:            correlationID,
+		Bot:                      bot.Name,
+		To:                       to,
+		Payload:                  message,
+		Parts:                    parts,
+		ParseMode:                parseMode,
+		InlineKeyboard:           keyboard,
+		DisableNotification:      req.DisableNotification,
+		LinkPreview:              toTelegramLinkPreview(req.DisableWebPagePreview, req.LinkPreviewOptions),
+		MessageThreadID:          req.MessageThreadID,
+		ReplyToMessageID:         req.ReplyToMessageID,
+		AllowSendingWithoutReply: req.AllowSendingWithoutReply,
+		ProtectContent:           req.ProtectContent,
+		Media:                    media,
+		CreatedAt:                time.Now(),
+	}
+
+	s.logger.Info(fmt.Sprintf("Telegram notification to %s by bot %s with %d media in %d parts, ID: %s", tgEvent.To, tgEvent.Bot, len(tgEvent.Media), max(len(parts), 1), notificationID.String()))
+
+	if err := s.publish(ctx, &tgEvent, notifications.RoutingTelegramSend, to, createdBy); err != nil {
+		return uuid.Nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Telegram notification sent successfully, ID: %s", notificationID.String()))
+
+	return notificationID, nil
+}
+
+// EnqueueEdit queues a change of the text and keyboard of a message sent by the caller's telegram.send.
+// The edit is a notification of its own, tracked and retried like a send.
+func (s *TelegramService) EnqueueEdit(ctx context.Context, correlationID string, createdBy string, req dto.TelegramRequestEditParams) (uuid.UUID, error) {
+	target, err := s.findTarget(ctx, req.NotificationID, createdBy)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	parseMode, err := toTelegramParseMode(req.ParseMode)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	message := req.Message
+	if req.Escape {
+		message = domain.EscapeTelegramText(message, parseMode)
+	}
+
+	// an edit replaces a single message, it cannot grow into several
+	parts, err := toTelegramParts(message, parseMode)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if len(parts) > 1 {
+		return uuid.Nil, &domain.TelegramLimitError{
+			Field:   "message",
+			Rule:    "max",
+			Message: fmt.Sprintf("message must be at most %d characters long", domain.TelegramMaxMessageLength),
+		}
+	}
+
+	keyboard, err := toTelegramKeyboard(req.InlineKeyboard)
+	if err != nil {
+		return uuid.Nil, err
 	}
 
 	tgEvent := entity.TelegramNotification{
-		NotificationID: notificationID,
+		NotificationID: uuid.New(),
 		CorrelationID:  correlationID,
-		To:             req.To,
-		Payload:        req.Message,
+		Action:         domain.TelegramActionEdit,
+		TargetID:       target.ID,
+		To:             target.Recipient,
+		Payload:        message,
 		ParseMode:      parseMode,
+		InlineKeyboard: keyboard,
+		LinkPreview:    toTelegramLinkPreview(req.DisableWebPagePreview, req.LinkPreviewOptions),
+		CreatedAt:      time.Now(),
+	}
+
+	s.logger.Info(fmt.Sprintf("Queueing edit of telegram notification %s, ID: %s", target.ID.String(), tgEvent.NotificationID.String()))
+
+	if err := s.publish(ctx, &tgEvent, notifications.RoutingTelegramEdit, target.Recipient, createdBy); err != nil {
+		return uuid.Nil, err
+	}
+
+	return tgEvent.NotificationID, nil
+}
+
+// EnqueueDelete queues the deletion of every message sent for the caller's telegram.send notification.
+func (s *TelegramService) EnqueueDelete(ctx context.Context, correlationID string, createdBy string, req dto.TelegramRequestDeleteParams) (uuid.UUID, error) {
+	target, err := s.findTarget(ctx, req.NotificationID, createdBy)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	tgEvent := entity.TelegramNotification{
+		NotificationID: uuid.New(),
+		CorrelationID:  correlationID,
+		Action:         domain.TelegramActionDelete,
+		TargetID:       target.ID,
+		To:             target.Recipient,
 		CreatedAt:      time.Now(),
 	}
 
-	s.logger.Info(fmt.Sprintf("Telegram notification: %v, ID: %s", tgEvent, notificationID.String()))
+	s.logger.Info(fmt.Sprintf("Queueing delete of telegram notification %s, ID: %s", target.ID.String(), tgEvent.NotificationID.String()))
+
+	if err := s.publish(ctx, &tgEvent, notifications.RoutingTelegramDelete, target.Recipient, createdBy); err != nil {
+		return uuid.Nil, err
+	}
+
+	return tgEvent.NotificationID, nil
+}
+
+// resolveChat returns the chat of the request, the one linked to its user_id with the bot when given.
+func (s *TelegramService) resolveChat(ctx context.Context, owner string, bot string, req dto.TelegramRequestSendParams) (string, error) {
+	if req.UserID == nil {
+		return req.To, nil
+	}
+
+	binding, err := s.bindings.FindByUser(ctx, owner, *req.UserID, bot)
+	if errors.Is(err, domain.ErrTelegramUserNotLinked) {
+		return "", &domain.TelegramLimitError{Field: "user_id", Rule: "linked", Message: fmt.Sprintf("user_id has not linked a Telegram chat with bot %s, send them a telegram.link link first", bot)}
+	}
+	if err != nil {
+		return "", err
+	}
 
+	return strconv.FormatInt(binding.ChatID, 10), nil
+}
+
+// findTarget returns the caller's own telegram notification, any other is reported as missing.
+// Only a delivered send can be changed: failed sends and edits or deletes have no messages of their own.
+func (s *TelegramService) findTarget(ctx context.Context, rawID string, createdBy string) (*entity.Notification, error) {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return nil, &domain.TelegramLimitError{Field: "notification_id", Rule: "uuid", Message: "notification_id must be a valid UUID"}
+	}
+
+	target, err := s.statuses.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if target.CreatedBy != createdBy || target.Channel != domain.ChannelTelegram {
+		return nil, domain.ErrNotificationNotFound
+	}
+
+	notSent := &domain.TelegramLimitError{Field: "notification_id", Rule: "sent", Message: "notification_id must be a telegram.send that was sent and not deleted"}
+	if target.Status != domain.StatusSent {
+		return nil, notSent
+	}
+	sent, err := s.messages.ListByNotification(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sent) == 0 {
+		return nil, notSent
+	}
+
+	return target, nil
+}
+
+func (s *TelegramService) publish(ctx context.Context, tgEvent *entity.TelegramNotification, routingKey string, recipient string, createdBy string) error {
 	eventBinary, err := msgpack.Marshal(tgEvent)
 	if err != nil {
 		s.logger.Error("failed to encode telegram notification", zap.Error(err))
-		return uuid.Nil, err
+		return err
+	}
+
+	if err := s.statuses.Queued(ctx, tgEvent.NotificationID, domain.ChannelTelegram, tgEvent.CorrelationID, recipient, createdBy); err != nil {
+		s.logger.Error("failed to store telegram notification", zap.Error(err))
+		return err
 	}
 
-	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, notifications.RoutingTelegramSend, eventBinary, amqp.Table{}, &correlationID)
+	headers := amqp.Table{notifications.HeaderNotificationID: tgEvent.NotificationID.String()}
+	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, routingKey, eventBinary, headers, &tgEvent.CorrelationID)
 	if err != nil {
+		s.statuses.Failed(ctx, tgEvent.NotificationID, err)
 		s.logger.Error("failed to enqueue telegram notification", zap.Error(err))
-		return uuid.Nil, err
+		return err
 	}
 
-	s.logger.Info(fmt.Sprintf("Telegram notification sent successfully, ID: %s", notificationID.String()))
-
-	return notificationID, nil
+	return nil
 }
 
 func (s *TelegramService) SendNotification(ctx context.Context, notification *entity.TelegramNotification) error {
-	s.logger.Info(fmt.Sprintf("Sending notification to Telegram, ID: %s", notification.NotificationID.String()))
-	err := s.tg.SendMessage(ctx, notification.To, notification.Payload, notification.ParseMode)
+	bot, err := s.botFor(notification.Bot)
+	if err != nil {
+		return s.finish(ctx, bot, notification, err)
+	}
+
+	if err := s.reserveSlot(ctx, bot, notification.To); err != nil {
+		return err
+	}
+
+	s.logger.Info(fmt.Sprintf("Sending notification to Telegram by bot %s, ID: %s", bot.Name, notification.NotificationID.String()))
+	s.statuses.Sending(ctx, notification.NotificationID)
+
+	if len(notification.Parts) > 1 {
+		err = s.sendParts(ctx, bot, notification)
+	} else {
+		var sent []domain.TelegramSentMessage
+		if sent, err = s.sendMigrating(ctx, bot, notification); err == nil {
+			s.saveMessages(ctx, bot, notification.NotificationID, 0, sent)
+		}
+	}
+
+	return s.finish(ctx, bot, notification, err)
+}
+
+// EditMessage edits the message of the target notification, the caption of the first item for an album.
+// Only the bot that sent a message can edit it.
+func (s *TelegramService) EditMessage(ctx context.Context, notification *entity.TelegramNotification) error {
+	bot, targets, err := s.targetMessages(ctx, notification)
+	if err != nil {
+		return s.finish(ctx, bot, notification, err)
+	}
+	if len(targets) > 1 && targets[0].Type == domain.TelegramMessageText {
+		return s.finish(ctx, bot, notification, utils.Permanent(domain.ErrTelegramNotEditable))
+	}
+
+	target := targets[0]
+	if err := s.reserveSlot(ctx, bot, strconv.FormatInt(target.ChatID, 10)); err != nil {
+		return err
+	}
+
+	s.logger.Info(fmt.Sprintf("Editing telegram message %d of notification %s, ID: %s", target.MessageID, notification.TargetID.String(), notification.NotificationID.String()))
+	s.statuses.Sending(ctx, notification.NotificationID)
+
+	err = s.withPlainTextFallback(notification, func(message *entity.TelegramNotification) error {
+		return bot.API.EditMessage(ctx, target, message)
+	})
+	// a retried edit finds its text already in place
+	if isTelegramError(err, "message is not modified") {
+		err = nil
+	}
+
+	return s.finish(ctx, bot, notification, err)
+}
+
+// DeleteMessages deletes every message of the target notification with the bot that sent them and forgets their ids.
+func (s *TelegramService) DeleteMessages(ctx context.Context, notification *entity.TelegramNotification) error {
+	bot, targets, err := s.targetMessages(ctx, notification)
+	if err != nil {
+		return s.finish(ctx, bot, notification, err)
+	}
+
+	if err := s.reserveSlot(ctx, bot, strconv.FormatInt(targets[0].ChatID, 10)); err != nil {
+		return err
+	}
+
+	s.logger.Info(fmt.Sprintf("Deleting %d telegram messages of notification %s, ID: %s", len(targets), notification.TargetID.String(), notification.NotificationID.String()))
+	s.statuses.Sending(ctx, notification.NotificationID)
+
+	// the messages of a notification whose chat migrated are in two chats
+	byChat := make(map[int64][]int64)
+	for _, target := range targets {
+		byChat[target.ChatID] = append(byChat[target.ChatID], target.MessageID)
+	}
+	for chatID, messageIDs := range byChat {
+		if err = bot.API.DeleteMessages(ctx, chatID, messageIDs); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		if err := s.messages.DeleteByNotification(ctx, notification.TargetID); err != nil {
+			s.logger.Warn("failed to forget deleted telegram messages", zap.Error(err))
+		}
+	}
+
+	return s.finish(ctx, bot, notification, err)
+}
+
+// targetMessages loads the messages an edit or delete applies to and the bot that sent them.
+// The target was delivered when the change was queued, none left means a delete queued before it removed them.
+func (s *TelegramService) targetMessages(ctx context.Context, notification *entity.TelegramNotification) (TelegramBot, []entity.TelegramMessage, error) {
+	bot, _ := s.bots.Get("")
+
+	targets, err := s.messages.ListByNotification(ctx, notification.TargetID)
 	if err != nil {
-		s.monitoring.SendError(domain.ChannelTelegram, 1)
-		s.logger.Error("failed to send notification to telegram", zap.Error(err))
+		return bot, nil, err
+	}
+	if len(targets) == 0 {
+		return bot, nil, utils.Permanent(domain.ErrTelegramMessageNotFound)
+	}
+
+	bot, err = s.botFor(targets[0].Bot)
+	return bot, targets, err
+}
+
+// botFor returns the bot of a queued notification. A bot removed from the configuration
+// fails its notifications for good, they are counted in a series of its own name.
+func (s *TelegramService) botFor(name string) (TelegramBot, error) {
+	if bot, ok := s.bots.Get(name); ok {
+		return bot, nil
+	}
+
+	fallback, _ := s.bots.Get("")
+	return TelegramBot{Name: name, Monitoring: fallback.Monitoring.With(map[string]string{"bot": name})},
+		utils.Permanent(fmt.Errorf("telegram bot %q is not configured", name))
+}
+
+// finish records the outcome of a send, edit or delete and tells the consumer how to go on.
+func (s *TelegramService) finish(ctx context.Context, bot TelegramBot, notification *entity.TelegramNotification, err error) error {
+	// a later part waits for its send slot, the delivered ones are skipped on redelivery
+	if _, ok := utils.DelayFor(err); ok {
 		return err
 	}
 
-	s.monitoring.SendSuccess(domain.ChannelTelegram, 1)
+	var apiErr *domain.TelegramAPIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		s.logger.Warn(fmt.Sprintf("Telegram flood control, retry after %s, ID: %s", apiErr.RetryAfter, notification.NotificationID.String()))
+		s.blockChat(ctx, bot, notification.To, apiErr.RetryAfter)
+		return utils.Delay(err, apiErr.RetryAfter)
+	}
+
+	if err != nil {
+		// bad requests and blocked or missing chats fail the same way on every retry
+		if errors.As(err, &apiErr) && apiErr.Code >= 400 && apiErr.Code < 500 {
+			err = utils.Permanent(err)
+		}
+		if isUnreachableChat(err) {
+			s.unbindChat(ctx, bot, notification.To)
+		}
+
+		s.statuses.Failed(ctx, notification.NotificationID, err)
+		bot.Monitoring.SendError(domain.ChannelTelegram, 1)
+		s.logger.Error("failed to send notification to telegram", zap.String("bot", bot.Name), zap.String("action", notification.Action), zap.Error(err))
+		return err
+	}
+
+	s.statuses.Sent(ctx, notification.NotificationID)
+	bot.Monitoring.SendSuccess(domain.ChannelTelegram, 1)
 	s.logger.Info(fmt.Sprintf("Notification sent to telegram successfully, ID: %s", notification.NotificationID.String()))
 	return nil
 }
+
+// sendParts sends the parts of a long message in order, a retry resumes after the parts already stored as delivered.
+// The keyboard goes under the last part and only the first one replies.
+func (s *TelegramService) sendParts(ctx context.Context, bot TelegramBot, notification *entity.TelegramNotification) error {
+	delivered, err := s.messages.ListByNotification(ctx, notification.NotificationID)
+	if err != nil {
+		// without knowing what was delivered a resend could duplicate parts
+		return err
+	}
+
+	for i := len(delivered); i < len(notification.Parts); i++ {
+		if i > len(delivered) {
+			if err := s.reserveSlot(ctx, bot, notification.To); err != nil {
+				return err
+			}
+		}
+
+		part := *notification
+		part.Payload = notification.Parts[i]
+		part.Parts = nil
+		if i > 0 {
+			part.ReplyToMessageID = nil
+		}
+		if i < len(notification.Parts)-1 {
+			part.InlineKeyboard = nil
+		}
+
+		sent, err := s.sendMigrating(ctx, bot, &part)
+		if err != nil {
+			return err
+		}
+		// later parts follow the chat to its new id
+		notification.To = part.To
+
+		s.saveMessages(ctx, bot, notification.NotificationID, i, sent)
+	}
+
+	return nil
+}
+
+// saveMessages stores the ids of delivered messages from position on. The messages are out already,
+// so a failed write is only logged, the notification then cannot be edited.
+func (s *TelegramService) saveMessages(ctx context.Context, bot TelegramBot, notificationID uuid.UUID, position int, sent []domain.TelegramSentMessage) {
+	messages := make([]entity.TelegramMessage, 0, len(sent))
+	for i, m := range sent {
+		messages = append(messages, entity.TelegramMessage{
+			NotificationID: notificationID,
+			Position:       position + i,
+			Bot:            bot.Name,
+			ChatID:         m.ChatID,
+			MessageID:      m.MessageID,
+			Type:           m.Type,
+			CreatedAt:      time.Now(),
+		})
+	}
+
+	if err := s.messages.Save(ctx, messages); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to store telegram message ids, ID: %s", notificationID.String()), zap.Error(err))
+	}
+}
+
+// sendMigrating sends once more right away when a group was upgraded to a supergroup and got a new id.
+func (s *TelegramService) sendMigrating(ctx context.Context, bot TelegramBot, notification *entity.TelegramNotification) ([]domain.TelegramSentMessage, error) {
+	sent, err := s.sendFormatted(ctx, bot, notification)
+
+	var apiErr *domain.TelegramAPIError
+	if errors.As(err, &apiErr) && apiErr.MigrateToChatID != 0 {
+		s.logger.Warn(fmt.Sprintf("Telegram chat %s migrated to %d, resending", notification.To, apiErr.MigrateToChatID))
+		s.moveChat(ctx, bot, notification.To, apiErr.MigrateToChatID)
+		notification.To = strconv.FormatInt(apiErr.MigrateToChatID, 10)
+		sent, err = s.sendFormatted(ctx, bot, notification)
+	}
+
+	return sent, err
+}
+
+// moveChat points the bindings of a migrated group to its new id. The send goes on either way, so a failure is only logged.
+func (s *TelegramService) moveChat(ctx context.Context, bot TelegramBot, to string, newChatID int64) {
+	chatID, err := strconv.ParseInt(to, 10, 64)
+	if err != nil {
+		return
+	}
+	if err := s.bindings.MoveChat(ctx, bot.Name, chatID, newChatID); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to move telegram bindings of chat %d to %d", chatID, newChatID), zap.String("bot", bot.Name), zap.Error(err))
+	}
+}
+
+// unbindChat drops the bindings of a chat the bot can no longer write to, later sends by user id then fail early.
+func (s *TelegramService) unbindChat(ctx context.Context, bot TelegramBot, to string) {
+	chatID, err := strconv.ParseInt(to, 10, 64)
+	if err != nil {
+		return
+	}
+	if _, err := s.bindings.DeleteByChat(ctx, bot.Name, chatID); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to drop telegram bindings of chat %d", chatID), zap.String("bot", bot.Name), zap.Error(err))
+	}
+}
+
+func (s *TelegramService) sendFormatted(ctx context.Context, bot TelegramBot, notification *entity.TelegramNotification) ([]domain.TelegramSentMessage, error) {
+	var sent []domain.TelegramSentMessage
+	err := s.withPlainTextFallback(notification, func(message *entity.TelegramNotification) error {
+		var err error
+		sent, err = s.send(ctx, bot, message)
+		return err
+	})
+
+	return sent, err
+}
+
+// withPlainTextFallback calls the Bot API, and once more as plain text when Telegram cannot parse the markup.
+func (s *TelegramService) withPlainTextFallback(notification *entity.TelegramNotification, call func(message *entity.TelegramNotification) error) error {
+	err := call(notification)
+	if !s.plainTextFallback || notification.ParseMode == domain.TelegramParseModeNone || !isTelegramError(err, "can't parse entities") {
+		return err
+	}
+
+	s.logger.Warn(fmt.Sprintf("Telegram could not parse the message, resending as plain text, ID: %s", notification.NotificationID.String()), zap.Error(err))
+
+	plain := *notification
+	plain.ParseMode = domain.TelegramParseModeNone
+	plain.Payload = domain.TelegramPlainText(notification.Payload, notification.ParseMode)
+	plain.Media = make([]entity.TelegramMedia, len(notification.Media))
+	for i, m := range notification.Media {
+		m.Caption = domain.TelegramPlainText(m.Caption, notification.ParseMode)
+		plain.Media[i] = m
+	}
+
+	return call(&plain)
+}
+
+func (s *TelegramService) send(ctx context.Context, bot TelegramBot, notification *entity.TelegramNotification) ([]domain.TelegramSentMessage, error) {
+	if len(notification.Media) > 0 {
+		return bot.API.SendMedia(ctx, notification)
+	}
+
+	sent, err := bot.API.SendMessage(ctx, notification)
+	if err != nil {
+		return nil, err
+	}
+	return []domain.TelegramSentMessage{sent}, nil
+}
+
+// isTelegramError reports a bad request whose description contains reason.
+func isTelegramError(err error, reason string) bool {
+	var apiErr *domain.TelegramAPIError
+	return errors.As(err, &apiErr) && apiErr.Code == 400 && strings.Contains(strings.ToLower(apiErr.Description), reason)
+}
+
+// isUnreachableChat reports whether Telegram refused a send because the bot was blocked, kicked or the user deleted.
+func isUnreachableChat(err error) bool {
+	var apiErr *domain.TelegramAPIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 403 {
+		return false
+	}
+	description := strings.ToLower(apiErr.Description)
+	return strings.Contains(description, "blocked") || strings.Contains(description, "kicked") || strings.Contains(description, "deactivated")
+}
+
+// reserveSlot waits for a free send slot of the bot in the chat, or asks to redeliver the message when the wait is long.
+// Without Redis the message is sent unthrottled rather than not at all.
+func (s *TelegramService) reserveSlot(ctx context.Context, bot TelegramBot, chatID string) error {
+	if bot.Throttle == nil {
+		return nil
+	}
+
+	for {
+		wait, err := bot.Throttle.Reserve(ctx, chatID)
+		if err != nil {
+			s.logger.Warn("telegram throttle is unavailable, sending without it", zap.Error(err))
+			return nil
+		}
+		if wait <= 0 {
+			return nil
+		}
+		if wait > telegramMaxInlineWait {
+			return utils.Delay(errors.New("telegram send rate limit"), wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// blockChat keeps the other workers from sending to the chat until the flood wait is over.
+func (s *TelegramService) blockChat(ctx context.Context, bot TelegramBot, chatID string, d time.Duration) {
+	if bot.Throttle == nil {
+		return
+	}
+	if err := bot.Throttle.Block(ctx, chatID, d); err != nil {
+		s.logger.Warn("failed to block telegram chat in the throttle", zap.String("bot", bot.Name), zap.Error(err))
+	}
+}
+
+func toTelegramParseMode(raw *string) (string, error) {
+	if raw == nil {
+		return domain.TelegramParseModeMarkdown, nil
+	}
+
+	mode, ok := domain.NormalizeTelegramParseMode(*raw)
+	if !ok {
+		return "", &domain.TelegramLimitError{Field: "parse_mode", Rule: "oneof", Message: "parse_mode must be one of [Markdown MarkdownV2 HTML none]"}
+	}
+
+	return mode, nil
+}
+
+func toTelegramKeyboard(rows [][]dto.TelegramInlineButton) ([][]entity.TelegramInlineButton, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	keyboard := make([][]entity.TelegramInlineButton, 0, len(rows))
+	for i, row := range rows {
+		buttons := make([]entity.TelegramInlineButton, 0, len(row))
+		for j, button := range row {
+			b := entity.TelegramInlineButton{Text: button.Text}
+			if button.URL != nil {
+				b.URL = *button.URL
+			}
+			if button.CallbackData != nil {
+				b.CallbackData = *button.CallbackData
+			}
+			// the validator counts characters, Telegram counts bytes
+			if len(b.CallbackData) > domain.TelegramMaxCallbackData {
+				field := fmt.Sprintf("inline_keyboard[%d][%d].callback_data", i, j)
+				return nil, &domain.TelegramLimitError{
+					Field:   field,
+					Rule:    "max_bytes",
+					Message: fmt.Sprintf("%s must be at most %d bytes long", field, domain.TelegramMaxCallbackData),
+				}
+			}
+			buttons = append(buttons, b)
+		}
+		keyboard = append(keyboard, buttons)
+	}
+
+	return keyboard, nil
+}
+
+// toTelegramLinkPreview folds the legacy disable_web_page_preview flag into the link preview options.
+func toTelegramLinkPreview(disabled bool, options *dto.TelegramLinkPreviewOptions) *entity.TelegramLinkPreview {
+	if options == nil && !disabled {
+		return nil
+	}
+
+	preview := &entity.TelegramLinkPreview{IsDisabled: disabled}
+	if options != nil {
+		preview.IsDisabled = preview.IsDisabled || options.IsDisabled
+		preview.PreferSmallMedia = options.PreferSmallMedia
+		preview.PreferLargeMedia = options.PreferLargeMedia
+		preview.ShowAboveText = options.ShowAboveText
+		if options.URL != nil {
+			preview.URL = *options.URL
+		}
+	}
+
+	return preview
+}
+
+// toTelegramMedia decodes uploaded files and enforces the Bot API limits, so a message that Telegram would reject is never enqueued.
+func toTelegramMedia(req dto.TelegramRequestSendParams, bot TelegramBot, message string, parseMode string) ([]entity.TelegramMedia, error) {
+	if len(req.Media) == 0 {
+		return nil, nil
+	}
+
+	isAlbum := len(req.Media) > 1
+	if isAlbum && len(req.InlineKeyboard) > 0 {
+		return nil, &domain.TelegramLimitError{Field: "inline_keyboard", Rule: "album", Message: "inline_keyboard cannot be sent with an album"}
+	}
+
+	// the message is the caption of the first item, it cannot have a caption of its own as well
+	if req.Message != "" && req.Media[0].Caption != nil {
+		return nil, &domain.TelegramLimitError{
+			Field:   "media[0].caption",
+			Rule:    "excluded_with",
+			Message: "media[0].caption cannot be used together with message, message is the caption of the first item",
+		}
+	}
+
+	uploaded := 0
+	media := make([]entity.TelegramMedia, 0, len(req.Media))
+	for i, item := range req.Media {
+		path := fmt.Sprintf("media[%d]", i)
+
+		if isAlbum && item.Type != req.Media[0].Type {
+			return nil, &domain.TelegramLimitError{Field: "media", Rule: "album_types", Message: "an album cannot mix photos and documents"}
+		}
+
+		data, err := item.Bytes()
+		if err != nil {
+			return nil, &domain.TelegramLimitError{Field: path + ".data", Rule: "base64", Message: path + ".data must be valid base64"}
+		}
+
+		if item.URL != nil {
+			if err := checkTelegramMediaURL(*item.URL, bot, path+".url"); err != nil {
+				return nil, err
+			}
+		}
+
+		limit := domain.TelegramMaxDocumentSize
+		if item.Type == domain.TelegramMediaPhoto {
+			limit = domain.TelegramMaxPhotoSize
+		}
+		if len(data) > limit {
+			return nil, &domain.TelegramLimitError{
+				Field:   path + ".data",
+				Rule:    "max_size",
+				Message: fmt.Sprintf("%s.data exceeds the %d MB limit of a %s", path, limit>>20, item.Type),
+			}
+		}
+		uploaded += len(data)
+		if uploaded > domain.TelegramMaxUploadSize {
+			return nil, &domain.TelegramLimitError{
+				Field:   "media",
+				Rule:    "max_size",
+				Message: fmt.Sprintf("media data must be at most %d MB together, send larger files by url", domain.TelegramMaxUploadSize>>20),
+			}
+		}
+
+		// the message is the caption of a single file or of the first album item
+		caption, captionField := "", path+".caption"
+		if item.Caption != nil {
+			caption = *item.Caption
+			if req.Escape {
+				caption = domain.EscapeTelegramText(caption, parseMode)
+			}
+		} else if i == 0 {
+			caption, captionField = message, "message"
+		}
+		if err := domain.ValidateTelegramText(caption, parseMode); err != nil {
+			return nil, telegramFormatError(captionField, parseMode, err)
+		}
+		if domain.TelegramTextLength(caption) > domain.TelegramMaxCaptionLength {
+			return nil, &domain.TelegramLimitError{
+				Field:   captionField,
+				Rule:    "max",
+				Message: fmt.Sprintf("%s is a caption and must be at most %d UTF-16 units long, emoji count as two", captionField, domain.TelegramMaxCaptionLength),
+			}
+		}
+
+		m := entity.TelegramMedia{Type: item.Type, Data: data, Filename: item.Filename, Caption: caption}
+		if item.URL != nil {
+			m.URL = *item.URL
+		}
+		if m.Data != nil && m.Filename == "" {
+			m.Filename = fmt.Sprintf("%s%d", item.Type, i+1)
+		}
+		media = append(media, m)
+	}
+
+	return media, nil
+}
+
+// checkTelegramMediaURL allows http urls Telegram fetches, and file urls on the host of a local Bot API server.
+// A file url must point into the LocalFiles directory of the bot, the server would read any file it can open.
+func checkTelegramMediaURL(raw string, bot TelegramBot, field string) error {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return &domain.TelegramLimitError{Field: field, Rule: "url", Message: field + " must be a valid URL"}
+	}
+
+	switch target.Scheme {
+	case "http", "https":
+		return nil
+	case domain.TelegramFileScheme:
+		if !bot.Local || bot.LocalFiles == "" {
+			return &domain.TelegramLimitError{Field: field, Rule: "local_bot", Message: field + " can be a file:// url only with a bot using a local Bot API server and local files"}
+		}
+		dir := strings.TrimSuffix(path.Clean(bot.LocalFiles), "/") + "/"
+		if target.Host != "" || target.RawQuery != "" || path.Clean(target.Path) != target.Path || !strings.HasPrefix(target.Path, dir) {
+			return &domain.TelegramLimitError{Field: field, Rule: "local_files", Message: field + " must be a file:///path inside " + dir}
+		}
+		return nil
+	default:
+		return &domain.TelegramLimitError{Field: field, Rule: "url", Message: field + " must be an http, https or file url"}
+	}
+}
+
+// toTelegramParts splits a message longer than Telegram allows, a short one is sent as is.
+func toTelegramParts(message string, parseMode string) ([]string, error) {
+	parts, err := domain.SplitTelegramText(message, parseMode, domain.TelegramMaxMessageLength)
+	if err != nil {
+		return nil, telegramFormatError("message", parseMode, err)
+	}
+
+	if len(parts) > domain.TelegramMaxMessageParts {
+		return nil, &domain.TelegramLimitError{
+			Field:   "message",
+			Rule:    "max",
+			Message: fmt.Sprintf("message must fit in %d messages of %d characters", domain.TelegramMaxMessageParts, domain.TelegramMaxMessageLength),
+		}
+	}
+	if len(parts) == 1 {
+		return nil, nil
+	}
+
+	return parts, nil
+}
+
+func telegramFormatError(field string, parseMode string, err error) error {
+	return &domain.TelegramLimitError{Field: field, Rule: "format", Message: fmt.Sprintf("%s is not valid %s: %v", field, parseMode, err)}
+}
@@ -0,0 +1,374 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http/httptest"
+	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/notifications/delivery/rpc/dto"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/internal/notifications/infra/telegram"
+	"notification-service-api/internal/notifications/infra/telegram/telegramtest"
+	"notification-service-api/pkg/utils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type noopMonitoring struct{}
+
+func (noopMonitoring) Send(domain.Channel, domain.NotificationType, int64) {}
+func (noopMonitoring) SendSuccess(domain.Channel, int64)                   {}
+func (noopMonitoring) SendError(domain.Channel, int64)                     {}
+func (m noopMonitoring) With(map[string]string) domain.NotificationMonitoring {
+	return m
+}
+
+type noopEvents struct{}
+
+func (noopEvents) Publish(context.Context, domain.StatusEvent) error { return nil }
+
+// memoryStore keeps notification statuses, telegram message ids, bindings and throttle blocks in memory.
+type memoryStore struct {
+	mu            sync.Mutex
+	notifications map[uuid.UUID]entity.Notification
+	messages      map[uuid.UUID][]entity.TelegramMessage
+	bindings      []entity.TelegramBinding
+	blocks        map[string]time.Duration
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		notifications: make(map[uuid.UUID]entity.Notification),
+		messages:      make(map[uuid.UUID][]entity.TelegramMessage),
+		blocks:        make(map[string]time.Duration),
+	}
+}
+
+func (s *memoryStore) Create(_ context.Context, notification *entity.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (s *memoryStore) UpdateStatus(_ context.Context, id uuid.UUID, status domain.Status, _ *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notification := s.notifications[id]
+	notification.ID = id
+	notification.Status = status
+	s.notifications[id] = notification
+	return nil
+}
+
+func (s *memoryStore) FindByID(_ context.Context, id uuid.UUID) (*entity.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notification, ok := s.notifications[id]
+	if !ok {
+		return nil, domain.ErrNotificationNotFound
+	}
+	return &notification, nil
+}
+
+func (s *memoryStore) status(id uuid.UUID) domain.Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notifications[id].Status
+}
+
+func (s *memoryStore) Save(_ context.Context, messages []entity.TelegramMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range messages {
+		s.messages[m.NotificationID] = append(s.messages[m.NotificationID], m)
+	}
+	return nil
+}
+
+func (s *memoryStore) ListByNotification(_ context.Context, id uuid.UUID) ([]entity.TelegramMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages[id], nil
+}
+
+func (s *memoryStore) DeleteByNotification(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, id)
+	return nil
+}
+
+func (s *memoryStore) Upsert(_ context.Context, binding *entity.TelegramBinding) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bindings = append(s.bindings, *binding)
+	return nil
+}
+
+func (s *memoryStore) FindByUser(_ context.Context, owner string, userID string, bot string) (*entity.TelegramBinding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range s.bindings {
+		if b.Owner == owner && b.UserID == userID && b.Bot == bot {
+			return &b, nil
+		}
+	}
+	return nil, domain.ErrTelegramUserNotLinked
+}
+
+func (s *memoryStore) DeleteByChat(_ context.Context, bot string, chatID int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.bindings[:0]
+	for _, b := range s.bindings {
+		if b.Bot != bot || b.ChatID != chatID {
+			kept = append(kept, b)
+		}
+	}
+	removed := int64(len(s.bindings) - len(kept))
+	s.bindings = kept
+	return removed, nil
+}
+
+func (s *memoryStore) MoveChat(_ context.Context, bot string, chatID int64, newChatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, b := range s.bindings {
+		if b.Bot == bot && b.ChatID == chatID {
+			s.bindings[i].ChatID = newChatID
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Reserve(context.Context, string) (time.Duration, error) {
+	return 0, nil
+}
+
+func (s *memoryStore) Block(_ context.Context, chatID string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[chatID] = d
+	return nil
+}
+
+func newTelegramService(t *testing.T, plainTextFallback bool) (*app.TelegramService, *telegramtest.Server, *memoryStore) {
+	t.Helper()
+
+	fake := telegramtest.NewServer()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store := newMemoryStore()
+	bots := app.NewTelegramBots([]app.TelegramBot{{
+		Name:       "default",
+		API:        telegram.NewTGApiClient("default", "1:secret", server.URL, 5*time.Second, nil),
+		Monitoring: noopMonitoring{},
+		Throttle:   store,
+	}}, "default")
+	statuses := app.NewNotificationStatusService(store, noopEvents{}, zap.NewNop())
+
+	service := app.NewTelegramService(bots, store, store, plainTextFallback, nil, statuses).WithLogger(zap.NewNop())
+	return service, fake, store
+}
+
+func TestSendNotificationDelaysOnRetryAfter(t *testing.T) {
+	service, fake, store := newTelegramService(t, true)
+	fake.Respond("sendMessage", telegramtest.TooManyRequests(5))
+
+	notification := &entity.TelegramNotification{NotificationID: uuid.New(), Bot: "default", To: "42", Payload: "hi"}
+	err := service.SendNotification(context.Background(), notification)
+
+	if after, ok := utils.DelayFor(err); !ok || after != 5*time.Second {
+		t.Fatalf("err = %v, want a delay of 5s", err)
+	}
+	if status := store.status(notification.NotificationID); status == domain.StatusFailed {
+		t.Fatalf("status = %s, a flood wait must not fail the notification", status)
+	}
+	if block := store.blocks["42"]; block != 5*time.Second {
+		t.Fatalf("chat blocked for %s, want 5s", block)
+	}
+}
+
+func TestSendNotificationFollowsMigratedChat(t *testing.T) {
+	service, fake, store := newTelegramService(t, true)
+	fake.Respond("sendMessage", telegramtest.Migrated(-1002))
+	_ = store.Upsert(context.Background(), &entity.TelegramBinding{Owner: "key", UserID: "u1", Bot: "default", ChatID: -42})
+
+	notification := &entity.TelegramNotification{NotificationID: uuid.New(), Bot: "default", To: "-42", Payload: "hi"}
+	if err := service.SendNotification(context.Background(), notification); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.Calls("sendMessage")
+	if len(calls) != 2 || calls[0].Params["chat_id"] != "-42" || calls[1].Params["chat_id"] != "-1002" {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	messages, _ := store.ListByNotification(context.Background(), notification.NotificationID)
+	if len(messages) != 1 || messages[0].ChatID != -1002 {
+		t.Fatalf("unexpected stored messages %+v", messages)
+	}
+	if status := store.status(notification.NotificationID); status != domain.StatusSent {
+		t.Fatalf("status = %s, want %s", status, domain.StatusSent)
+	}
+	if binding, err := store.FindByUser(context.Background(), "key", "u1", "default"); err != nil || binding.ChatID != -1002 {
+		t.Fatalf("binding = %+v, %v, want it moved to the new chat", binding, err)
+	}
+}
+
+func TestSendNotificationUnbindsBlockedChat(t *testing.T) {
+	service, fake, store := newTelegramService(t, true)
+	fake.Respond("sendMessage", telegramtest.Forbidden("Forbidden: bot was blocked by the user"))
+	_ = store.Upsert(context.Background(), &entity.TelegramBinding{Owner: "key", UserID: "u1", Bot: "default", ChatID: 42})
+
+	notification := &entity.TelegramNotification{NotificationID: uuid.New(), Bot: "default", To: "42", Payload: "hi"}
+	if err := service.SendNotification(context.Background(), notification); !utils.IsPermanent(err) {
+		t.Fatalf("err = %v, want a permanent error", err)
+	}
+
+	if _, err := store.FindByUser(context.Background(), "key", "u1", "default"); !errors.Is(err, domain.ErrTelegramUserNotLinked) {
+		t.Fatalf("err = %v, want the binding dropped", err)
+	}
+}
+
+func TestSendNotificationFallsBackToPlainText(t *testing.T) {
+	service, fake, store := newTelegramService(t, true)
+	fake.Respond("sendMessage", telegramtest.BadRequest("can't parse entities: Can't find end of the entity starting at byte offset 0"))
+
+	notification := &entity.TelegramNotification{
+		NotificationID: uuid.New(),
+		Bot:            "default",
+		To:             "42",
+		Payload:        "*bold",
+		ParseMode:      domain.TelegramParseModeMarkdown,
+	}
+	if err := service.SendNotification(context.Background(), notification); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.Calls("sendMessage")
+	if len(calls) != 2 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	if calls[0].Params["parse_mode"] != "Markdown" || calls[1].Params["parse_mode"] != "" {
+		t.Fatalf("resend keeps parse_mode: %v", calls[1].Params)
+	}
+	if calls[1].Params["text"] != domain.TelegramPlainText("*bold", domain.TelegramParseModeMarkdown) {
+		t.Fatalf("unexpected plain text %q", calls[1].Params["text"])
+	}
+	if status := store.status(notification.NotificationID); status != domain.StatusSent {
+		t.Fatalf("status = %s, want %s", status, domain.StatusSent)
+	}
+}
+
+func TestSendNotificationFailsParseErrorWithoutFallback(t *testing.T) {
+	service, fake, store := newTelegramService(t, false)
+	fake.Respond("sendMessage", telegramtest.BadRequest("can't parse entities: Can't find end of the entity starting at byte offset 0"))
+
+	notification := &entity.TelegramNotification{
+		NotificationID: uuid.New(),
+		Bot:            "default",
+		To:             "42",
+		Payload:        "*bold",
+		ParseMode:      domain.TelegramParseModeMarkdown,
+	}
+	err := service.SendNotification(context.Background(), notification)
+
+	if !utils.IsPermanent(err) {
+		t.Fatalf("err = %v, want a permanent error", err)
+	}
+	if calls := fake.Calls("sendMessage"); len(calls) != 1 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	if status := store.status(notification.NotificationID); status != domain.StatusFailed {
+		t.Fatalf("status = %s, want %s", status, domain.StatusFailed)
+	}
+}
+
+func TestEnqueueEditRejectsTargetsThatWereNotSent(t *testing.T) {
+	service, _, store := newTelegramService(t, true)
+	ctx := context.Background()
+
+	notification := func(status domain.Status) uuid.UUID {
+		id := uuid.New()
+		store.notifications[id] = entity.Notification{ID: id, Channel: domain.ChannelTelegram, Status: status, CreatedBy: "key"}
+		return id
+	}
+
+	sent := notification(domain.StatusSent)
+	_ = store.Save(ctx, []entity.TelegramMessage{{NotificationID: sent, Bot: "default", ChatID: 42, MessageID: 1}})
+
+	tests := []struct {
+		name   string
+		target uuid.UUID
+	}{
+		{name: "failed send", target: notification(domain.StatusFailed)},
+		{name: "send in flight", target: notification(domain.StatusSending)},
+		// an edit or delete is sent too, but has no messages of its own
+		{name: "sent edit", target: notification(domain.StatusSent)},
+		{name: "deleted send", target: func() uuid.UUID {
+			id := notification(domain.StatusSent)
+			_ = store.Save(ctx, []entity.TelegramMessage{{NotificationID: id, Bot: "default", ChatID: 42, MessageID: 2}})
+			_ = store.DeleteByNotification(ctx, id)
+			return id
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.EnqueueEdit(ctx, "", "key", dto.TelegramRequestEditParams{NotificationID: tt.target.String(), Message: "changed"})
+
+			var limitErr *domain.TelegramLimitError
+			if !errors.As(err, &limitErr) || limitErr.Field != "notification_id" || limitErr.Rule != "sent" {
+				t.Fatalf("err = %v, want a sent error of notification_id", err)
+			}
+		})
+	}
+
+	t.Run("somebody else's send", func(t *testing.T) {
+		_, err := service.EnqueueDelete(ctx, "", "other", dto.TelegramRequestDeleteParams{NotificationID: sent.String()})
+		if !errors.Is(err, domain.ErrNotificationNotFound) {
+			t.Fatalf("err = %v, want %v", err, domain.ErrNotificationNotFound)
+		}
+	})
+}
+
+func TestEnqueueTelegramCountsCallbackDataInBytes(t *testing.T) {
+	service, _, _ := newTelegramService(t, true)
+
+	// 40 characters, 80 bytes
+	data := strings.Repeat("я", 40)
+	_, err := service.EnqueueTelegram(context.Background(), "", "key", dto.TelegramRequestSendParams{
+		To:             "42",
+		Message:        "hi",
+		InlineKeyboard: [][]dto.TelegramInlineButton{{{Text: "Yes", CallbackData: &data}}},
+	})
+
+	var limitErr *domain.TelegramLimitError
+	if !errors.As(err, &limitErr) || limitErr.Field != "inline_keyboard[0][0].callback_data" || limitErr.Rule != "max_bytes" {
+		t.Fatalf("err = %v, want a max_bytes error of the callback_data", err)
+	}
+}
+
+func TestEnqueueTelegramCountsCaptionInUTF16(t *testing.T) {
+	service, _, _ := newTelegramService(t, true)
+
+	// 600 characters, 1200 UTF-16 units
+	photo := "https://example.com/a.png"
+	_, err := service.EnqueueTelegram(context.Background(), "", "key", dto.TelegramRequestSendParams{
+		To:      "42",
+		Message: strings.Repeat("😀", 600),
+		Media:   []dto.TelegramMedia{{Type: domain.TelegramMediaPhoto, URL: &photo}},
+	})
+
+	var limitErr *domain.TelegramLimitError
+	if !errors.As(err, &limitErr) || limitErr.Field != "message" || limitErr.Rule != "max" {
+		t.Fatalf("err = %v, want a max error of the caption", err)
+	}
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"net/url"
+	"notification-service-api/internal/notifications/delivery/rpc/dto"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/internal/shared/queue/notifications"
+	"notification-service-api/pkg/utils"
+	"strings"
+	"time"
+)
+
+type WebhookPort interface {
+	Send(ctx context.Context, message *entity.WebhookNotification) error
+}
+
+type WebhookService struct {
+	client       WebhookPort
+	allowedHosts []string
+	rabbitMQ     *utils.RabbitMQConnection
+	logger       *zap.Logger
+	monitoring   domain.NotificationMonitoring
+	statuses     *NotificationStatusService
+}
+
+func NewWebhookService(client WebhookPort, allowedHosts []string, rabbitMQ *utils.RabbitMQConnection, monitoring domain.NotificationMonitoring, statuses *NotificationStatusService) *WebhookService {
+	return &WebhookService{
+		client:       client,
+		allowedHosts: allowedHosts,
+		rabbitMQ:     rabbitMQ,
+		monitoring:   monitoring,
+		statuses:     statuses,
+	}
+}
+
+// WithLogger returns a copy of the service bound to the logger, so concurrent callers do not share it.
+func (s *WebhookService) WithLogger(logger *zap.Logger) *WebhookService {
+	clone := *s
+	clone.logger = logger
+	return &clone
+}
+
+// Enabled reports whether WEBHOOK_SECRET is set, without it webhook.send is refused and the queue is not consumed.
+func (s *WebhookService) Enabled() bool {
+	return s.client != nil
+}
+
+func (s *WebhookService) EnqueueWebhook(ctx context.Context, correlationID string, createdBy string, req dto.WebhookRequestSendParams) (uuid.UUID, error) {
+	if !s.Enabled() {
+		return uuid.Nil, domain.ErrChannelDisabled
+	}
+	if !s.isAllowed(req.URL) {
+		return uuid.Nil, domain.ErrWebhookHostNotAllowed
+	}
+
+	notificationID := uuid.New()
+
+	s.logger.Info(fmt.Sprintf("Start sending webhook to queue, ID: %s", notificationID.String()))
+
+	webhookEvent := entity.WebhookNotification{
+		NotificationID: notificationID,
+		CorrelationID:  correlationID,
+		URL:            req.URL,
+		Event:          req.Event,
+		Payload:        req.Payload,
+		Headers:        req.Headers,
+		CreatedAt:      time.Now(),
+	}
+	if req.TimeoutMS != nil {
+		webhookEvent.Timeout = time.Duration(*req.TimeoutMS) * time.Millisecond
+	}
+
+	eventBinary, err := msgpack.Marshal(webhookEvent)
+	if err != nil {
+		s.logger.Error("failed to encode webhook", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	if err := s.statuses.Queued(ctx, notificationID, domain.ChannelWebhook, correlationID, webhookRecipient(req.URL), createdBy); err != nil {
+		s.logger.Error("failed to store webhook notification", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	headers := amqp.Table{notifications.HeaderNotificationID: notificationID.String()}
+	err = s.rabbitMQ.PublishMsgpack(ctx, notifications.ExchangeNotifications, notifications.RoutingWebhookSend, eventBinary, headers, &correlationID)
+	if err != nil {
+		s.statuses.Failed(ctx, notificationID, err)
+		s.logger.Error("failed to enqueue webhook", zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Webhook queued successfully, ID: %s", notificationID.String()))
+
+	return notificationID, nil
+}
+
+func (s *WebhookService) SendWebhook(ctx context.Context, webhook *entity.WebhookNotification) error {
+	s.logger.Info(fmt.Sprintf("Sending webhook, ID: %s", webhook.NotificationID.String()))
+	s.statuses.Sending(ctx, webhook.NotificationID)
+
+	err := s.client.Send(ctx, webhook)
+	if err != nil {
+		s.statuses.Failed(ctx, webhook.NotificationID, err)
+		s.monitoring.SendError(domain.ChannelWebhook, 1)
+		s.logger.Error("failed to send webhook", zap.Bool("permanent", utils.IsPermanent(err)), zap.Error(err))
+		return err
+	}
+
+	s.statuses.Sent(ctx, webhook.NotificationID)
+	s.monitoring.SendSuccess(domain.ChannelWebhook, 1)
+	s.logger.Info(fmt.Sprintf("Webhook sent successfully, ID: %s", webhook.NotificationID.String()))
+	return nil
+}
+
+// isAllowed matches the target host against WEBHOOK_ALLOWED_HOSTS, "*.example.com" also matches subdomains.
+// An empty list allows every public host, the client refuses private addresses either way.
+func (s *WebhookService) isAllowed(rawURL string) bool {
+	if len(s.allowedHosts) == 0 {
+		return true
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(target.Hostname())
+
+	for _, allowed := range s.allowedHosts {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == host {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webhookRecipient keeps only the scheme and host of the target, the path and query may carry credentials
+// and do not fit the recipient column.
+func webhookRecipient(rawURL string) string {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return target.Scheme + "://" + target.Host
+}
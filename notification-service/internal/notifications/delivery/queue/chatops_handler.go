@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"context"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/pkg/utils"
+)
+
+type ChatOpsHandler struct {
+	logger         *zap.Logger
+	chatOpsService *app.ChatOpsService
+}
+
+func NewChatOpsHandler(logger *zap.Logger, chatOpsService *app.ChatOpsService) *ChatOpsHandler {
+	return &ChatOpsHandler{
+		logger:         logger,
+		chatOpsService: chatOpsService,
+	}
+}
+
+func (h *ChatOpsHandler) Handle(ctx context.Context, d amqp.Delivery) error {
+	logger := h.logger.With(zap.String("request_id", d.CorrelationId))
+	chatOpsService := h.chatOpsService.WithLogger(logger)
+
+	logger.Info("Handling chat-ops message...")
+
+	var message *entity.ChatOpsNotification
+	if err := msgpack.Unmarshal(d.Body, &message); err != nil {
+		logger.Error("failed to unmarshal chat-ops message", zap.Error(err))
+		return utils.Permanent(err)
+	}
+
+	return chatOpsService.SendChatOps(ctx, message)
+}
@@ -14,6 +14,7 @@ func StartTelegramConsumers(dependencies *di.Dependencies) {
 	ctx := context.Background()
 
 	handler := NewTelegramHandler(dependencies.Logger, dependencies.TelegramService)
+	hooks := NewStatusHooks(dependencies.StatusService)
 
 	err := dependencies.RabbitMQ.Consume(ctx, utils.ConsumeOptions{
 		Queue:           notifications.QueueTelegram,
@@ -24,6 +25,9 @@ func StartTelegramConsumers(dependencies *di.Dependencies) {
 		RetryMax:        3,
 		RetryRoutingKey: notifications.RoutingTelegramSendRetry,
 		DLQRoutingKey:   notifications.RoutingTelegramSendDLQ,
+		DelayRoutingKey: notifications.RoutingTelegramSend,
+		OnRetry:         hooks.OnRetry,
+		OnDead:          hooks.OnDead,
 	}, handler.Handle)
 	if err != nil {
 		dependencies.Logger.Error("failed to register telegram consumer", zap.Error(err))
@@ -37,6 +41,7 @@ func StartEmailConsumers(dependencies *di.Dependencies) {
 	ctx := context.Background()
 
 	handler := NewEmailHandler(dependencies.Logger, dependencies.EmailService)
+	hooks := NewStatusHooks(dependencies.StatusService)
 
 	err := dependencies.RabbitMQ.Consume(ctx, utils.ConsumeOptions{
 		Queue:           notifications.QueueEmail,
@@ -47,6 +52,8 @@ func StartEmailConsumers(dependencies *di.Dependencies) {
 		RetryMax:        3,
 		RetryRoutingKey: notifications.RoutingEmailSendRetry,
 		DLQRoutingKey:   notifications.RoutingEmailSendDLQ,
+		OnRetry:         hooks.OnRetry,
+		OnDead:          hooks.OnDead,
 	}, handler.Handle)
 	if err != nil {
 		dependencies.Logger.Error("failed to register email consumer", zap.Error(err))
@@ -55,3 +62,127 @@ func StartEmailConsumers(dependencies *di.Dependencies) {
 
 	dependencies.Logger.Info("Email consumer registered")
 }
+
+func StartSMSConsumers(dependencies *di.Dependencies) {
+	if !dependencies.SMSService.Enabled() {
+		return
+	}
+
+	ctx := context.Background()
+
+	handler := NewSMSHandler(dependencies.Logger, dependencies.SMSService)
+	hooks := NewStatusHooks(dependencies.StatusService)
+
+	err := dependencies.RabbitMQ.Consume(ctx, utils.ConsumeOptions{
+		Queue:           notifications.QueueSMS,
+		Workers:         5,
+		Prefetch:        5,
+		Args:            amqp.Table{},
+		RetryBackoff:    30 * time.Second,
+		RetryMax:        3,
+		RetryRoutingKey: notifications.RoutingSMSSendRetry,
+		DLQRoutingKey:   notifications.RoutingSMSSendDLQ,
+		OnRetry:         hooks.OnRetry,
+		OnDead:          hooks.OnDead,
+	}, handler.Handle)
+	if err != nil {
+		dependencies.Logger.Error("failed to register sms consumer", zap.Error(err))
+		return
+	}
+
+	dependencies.Logger.Info("SMS consumer registered")
+}
+
+func StartPushConsumers(dependencies *di.Dependencies) {
+	ctx := context.Background()
+
+	handler := NewPushHandler(dependencies.Logger, dependencies.PushService)
+	hooks := NewStatusHooks(dependencies.StatusService)
+
+	err := dependencies.RabbitMQ.Consume(ctx, utils.ConsumeOptions{
+		Queue:           notifications.QueuePush,
+		Workers:         5,
+		Prefetch:        5,
+		Args:            amqp.Table{},
+		RetryBackoff:    30 * time.Second,
+		RetryMax:        3,
+		RetryRoutingKey: notifications.RoutingPushSendRetry,
+		DLQRoutingKey:   notifications.RoutingPushSendDLQ,
+		OnRetry:         hooks.OnRetry,
+		OnDead:          hooks.OnDead,
+	}, handler.Handle)
+	if err != nil {
+		dependencies.Logger.Error("failed to register push consumer", zap.Error(err))
+		return
+	}
+
+	dependencies.Logger.Info("Push consumer registered")
+}
+
+func StartWebhookConsumers(dependencies *di.Dependencies) {
+	if !dependencies.WebhookService.Enabled() {
+		return
+	}
+
+	ctx := context.Background()
+
+	handler := NewWebhookHandler(dependencies.Logger, dependencies.WebhookService)
+	hooks := NewStatusHooks(dependencies.StatusService)
+
+	err := dependencies.RabbitMQ.Consume(ctx, utils.ConsumeOptions{
+		Queue:           notifications.QueueWebhook,
+		Workers:         5,
+		Prefetch:        5,
+		Args:            amqp.Table{},
+		RetryBackoff:    30 * time.Second,
+		RetryMax:        5,
+		RetryRoutingKey: notifications.RoutingWebhookSendRetry,
+		DLQRoutingKey:   notifications.RoutingWebhookSendDLQ,
+		OnRetry:         hooks.OnRetry,
+		OnDead:          hooks.OnDead,
+	}, handler.Handle)
+	if err != nil {
+		dependencies.Logger.Error("failed to register webhook consumer", zap.Error(err))
+		return
+	}
+
+	dependencies.Logger.Info("Webhook consumer registered")
+}
+
+func StartChatOpsConsumers(dependencies *di.Dependencies) {
+	ctx := context.Background()
+
+	handler := NewChatOpsHandler(dependencies.Logger, dependencies.ChatOpsService)
+	hooks := NewStatusHooks(dependencies.StatusService)
+
+	queues := []struct {
+		queue string
+		retry string
+		dlq   string
+	}{
+		{notifications.QueueSlack, notifications.RoutingSlackSendRetry, notifications.RoutingSlackSendDLQ},
+		{notifications.QueueDiscord, notifications.RoutingDiscordSendRetry, notifications.RoutingDiscordSendDLQ},
+		{notifications.QueueMattermost, notifications.RoutingMattermostSendRetry, notifications.RoutingMattermostSendDLQ},
+	}
+
+	for _, q := range queues {
+		err := dependencies.RabbitMQ.Consume(ctx, utils.ConsumeOptions{
+			Queue:           q.queue,
+			Workers:         2,
+			Prefetch:        5,
+			Args:            amqp.Table{},
+			RetryBackoff:    30 * time.Second,
+			RetryMax:        3,
+			RetryRoutingKey: q.retry,
+			DLQRoutingKey:   q.dlq,
+			OnRetry:         hooks.OnRetry,
+			OnDead:          hooks.OnDead,
+		}, handler.Handle)
+		if err != nil {
+			dependencies.Logger.Error("failed to register chat-ops consumer", zap.String("queue", q.queue), zap.Error(err))
+			continue
+		}
+
+		dependencies.Logger.Info("Chat-ops consumer registered", zap.String("queue", q.queue))
+	}
+}
@@ -23,7 +23,7 @@ func NewEmailHandler(logger *zap.Logger, emailService *app.EmailService) *EmailH
 
 func (h *EmailHandler) Handle(ctx context.Context, d amqp.Delivery) error {
 	logger := h.logger.With(zap.String("request_id", d.CorrelationId))
-	h.emailService.WithLogger(logger)
+	emailService := h.emailService.WithLogger(logger)
 
 	logger.Info("Handling email...")
 
@@ -33,5 +33,5 @@ func (h *EmailHandler) Handle(ctx context.Context, d amqp.Delivery) error {
 		return err
 	}
 
-	return h.emailService.SendEmail(ctx, email)
+	return emailService.SendEmail(ctx, email)
 }
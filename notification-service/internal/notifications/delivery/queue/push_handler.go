@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"context"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/notifications/domain/entity"
+)
+
+type PushHandler struct {
+	logger      *zap.Logger
+	pushService *app.PushService
+}
+
+func NewPushHandler(logger *zap.Logger, pushService *app.PushService) *PushHandler {
+	return &PushHandler{
+		logger:      logger,
+		pushService: pushService,
+	}
+}
+
+func (h *PushHandler) Handle(ctx context.Context, d amqp.Delivery) error {
+	logger := h.logger.With(zap.String("request_id", d.CorrelationId))
+	pushService := h.pushService.WithLogger(logger)
+
+	logger.Info("Handling push...")
+
+	var push *entity.PushNotification
+	if err := msgpack.Unmarshal(d.Body, &push); err != nil {
+		logger.Error("failed to unmarshal push", zap.Error(err))
+		return err
+	}
+
+	return pushService.SendPush(ctx, push)
+}
@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"context"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/notifications/domain/entity"
+)
+
+type SMSHandler struct {
+	logger     *zap.Logger
+	smsService *app.SMSService
+}
+
+func NewSMSHandler(logger *zap.Logger, smsService *app.SMSService) *SMSHandler {
+	return &SMSHandler{
+		logger:     logger,
+		smsService: smsService,
+	}
+}
+
+func (h *SMSHandler) Handle(ctx context.Context, d amqp.Delivery) error {
+	logger := h.logger.With(zap.String("request_id", d.CorrelationId))
+	smsService := h.smsService.WithLogger(logger)
+
+	logger.Info("Handling sms...")
+
+	var sms *entity.SMSNotification
+	if err := msgpack.Unmarshal(d.Body, &sms); err != nil {
+		logger.Error("failed to unmarshal sms", zap.Error(err))
+		return err
+	}
+
+	return smsService.SendSMS(ctx, sms)
+}
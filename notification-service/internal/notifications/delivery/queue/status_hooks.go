@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/shared/queue/notifications"
+)
+
+type StatusHooks struct {
+	statusService *app.NotificationStatusService
+}
+
+func NewStatusHooks(statusService *app.NotificationStatusService) *StatusHooks {
+	return &StatusHooks{
+		statusService: statusService,
+	}
+}
+
+func (h *StatusHooks) OnRetry(ctx context.Context, d amqp.Delivery, attempts int64, err error) {
+	if id, ok := notificationIDFromHeaders(d.Headers); ok {
+		h.statusService.Retrying(ctx, id, err)
+	}
+}
+
+func (h *StatusHooks) OnDead(ctx context.Context, d amqp.Delivery, attempts int64, err error) {
+	if id, ok := notificationIDFromHeaders(d.Headers); ok {
+		h.statusService.Dead(ctx, id, err)
+	}
+}
+
+func notificationIDFromHeaders(headers amqp.Table) (uuid.UUID, bool) {
+	raw, ok := headers[notifications.HeaderNotificationID].(string)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
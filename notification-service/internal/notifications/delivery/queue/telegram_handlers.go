@@ -6,6 +6,7 @@ import (
 	"github.com/vmihailenco/msgpack/v5"
 	"go.uber.org/zap"
 	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/notifications/domain"
 	"notification-service-api/internal/notifications/domain/entity"
 )
 
@@ -23,7 +24,7 @@ func NewTelegramHandler(logger *zap.Logger, telegramService *app.TelegramService
 
 func (h *TelegramHandler) Handle(ctx context.Context, d amqp.Delivery) error {
 	logger := h.logger.With(zap.String("request_id", d.CorrelationId))
-	h.TelegramService.WithLogger(logger)
+	telegramService := h.TelegramService.WithLogger(logger)
 
 	logger.Info("Handling telegram message...")
 
@@ -33,5 +34,12 @@ func (h *TelegramHandler) Handle(ctx context.Context, d amqp.Delivery) error {
 		return err
 	}
 
-	return h.TelegramService.SendNotification(ctx, notification)
+	switch notification.Action {
+	case domain.TelegramActionEdit:
+		return telegramService.EditMessage(ctx, notification)
+	case domain.TelegramActionDelete:
+		return telegramService.DeleteMessages(ctx, notification)
+	default:
+		return telegramService.SendNotification(ctx, notification)
+	}
 }
@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"context"
+	"github.com/streadway/amqp"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/pkg/utils"
+)
+
+type WebhookHandler struct {
+	logger         *zap.Logger
+	webhookService *app.WebhookService
+}
+
+func NewWebhookHandler(logger *zap.Logger, webhookService *app.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		logger:         logger,
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) Handle(ctx context.Context, d amqp.Delivery) error {
+	logger := h.logger.With(zap.String("request_id", d.CorrelationId))
+	webhookService := h.webhookService.WithLogger(logger)
+
+	logger.Info("Handling webhook...")
+
+	var webhook *entity.WebhookNotification
+	if err := msgpack.Unmarshal(d.Body, &webhook); err != nil {
+		logger.Error("failed to unmarshal webhook", zap.Error(err))
+		// a broken message will not decode on the next attempt either
+		return utils.Permanent(err)
+	}
+
+	return webhookService.SendWebhook(ctx, webhook)
+}
@@ -0,0 +1,24 @@
+package dto
+
+type ChatOpsField struct {
+	Title string `json:"title" validate:"required,max=256" doc:"Field name"`
+	Value string `json:"value" validate:"required,max=1024" doc:"Field value"`
+	Short bool   `json:"short,omitempty" doc:"Render side by side with other short fields"`
+}
+
+type ChatOpsRequestSendParams struct {
+	Target   *string        `json:"target,omitempty" validate:"omitempty,max=64" doc:"Name of a configured incoming webhook, defaults to default"`
+	Title    string         `json:"title,omitempty" validate:"max=256" doc:"Message title"`
+	Text     string         `json:"text" validate:"required,max=4000" doc:"Message text, markdown of the chat tool"`
+	Level    string         `json:"level,omitempty" validate:"omitempty,oneof=info success warning error" doc:"info, success, warning or error, sets the color"`
+	URL      *string        `json:"url,omitempty" validate:"omitempty,http_url" doc:"Link of the title"`
+	Fields   []ChatOpsField `json:"fields,omitempty" validate:"max=10,dive" doc:"Key/value fields"`
+	Footer   string         `json:"footer,omitempty" validate:"max=256" doc:"Small text under the message"`
+	Username *string        `json:"username,omitempty" validate:"omitempty,max=80" doc:"Overrides the webhook's name where the tool allows it"`
+	IconURL  *string        `json:"icon_url,omitempty" validate:"omitempty,http_url" doc:"Overrides the webhook's avatar where the tool allows it"`
+}
+
+type ChatOpsResponseSendDTO struct {
+	NotificationID string `json:"notification_id"`
+	Queued         bool   `json:"queued"`
+}
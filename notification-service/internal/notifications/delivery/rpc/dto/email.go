@@ -1,23 +1,50 @@
 package dto
 
-import "encoding/base64"
+import (
+	"encoding/base64"
+	"time"
+)
 
 type EmailAttachment struct {
-	Filename    string `json:"filename"`
-	ContentType string `json:"content_type"`
-	Data        string `json:"data"` // base64 encoded
+	Filename    string `json:"filename" doc:"File name"`
+	ContentType string `json:"content_type" doc:"MIME type (e.g. image/jpeg)"`
+	Data        string `json:"data" doc:"File contents as base64"` // base64 encoded
+	ContentID   string `json:"content_id,omitempty" validate:"omitempty,max=255,printascii,excludesall=<> " doc:"ID to show the file in html as <img src=\"cid:content_id\">"`
+	Disposition string `json:"disposition,omitempty" validate:"omitempty,oneof=attachment inline" doc:"attachment or inline, defaults to inline when content_id is set"`
+}
+
+type EmailCalendarEvent struct {
+	UID         string                `json:"uid,omitempty" validate:"omitempty,max=255,printascii" doc:"Event ID, send the same one with a higher sequence to update the event, defaults to the notification ID"`
+	Sequence    int                   `json:"sequence,omitempty" validate:"min=0" doc:"Revision of the event"`
+	Summary     string                `json:"summary" validate:"required,max=255" doc:"Event title"`
+	Description string                `json:"description,omitempty" doc:"Event details"`
+	Location    string                `json:"location,omitempty" validate:"max=255" doc:"Place or meeting link"`
+	Start       time.Time             `json:"start" validate:"required" doc:"Start as RFC 3339"`
+	End         time.Time             `json:"end" validate:"required,gtfield=Start" doc:"End as RFC 3339"`
+	Timezone    string                `json:"timezone,omitempty" validate:"omitempty,timezone" doc:"IANA time zone the event is shown in, e.g. Europe/Berlin, defaults to UTC"`
+	Organizer   EmailCalendarPerson   `json:"organizer" validate:"required" doc:"Who the answers go to"`
+	Attendees   []EmailCalendarPerson `json:"attendees" validate:"required,min=1,max=100,dive" doc:"Invited people"`
+}
+
+type EmailCalendarPerson struct {
+	Email    string `json:"email" validate:"required,email" doc:"Email address"`
+	Name     string `json:"name,omitempty" validate:"max=255" doc:"Display name"`
+	Optional bool   `json:"optional,omitempty" doc:"Attendance is optional"`
 }
 
 type EmailRequestSendParams struct {
-	To          string            `json:"to" validate:"required,email"`
-	Subject     string            `json:"subject" validate:"required"`
-	Body        string            `json:"body" validate:"required"`
-	ContentType string            `json:"content_type" validate:"required"`
-	ReplyTo     *string           `json:"reply_to" validate:"omitempty,email"`
-	From        *string           `json:"from" validate:"omitempty,email"`
-	CC          []string          `json:"cc"`
-	BCC         []string          `json:"bcc"`
-	Attachments []EmailAttachment `json:"attachments"`
+	To            string              `json:"to" validate:"required,email" doc:"Recipient"`
+	Subject       string              `json:"subject" validate:"required" doc:"Email subject"`
+	Body          string              `json:"body,omitempty" validate:"required_without_all=HTML Text,excluded_with=HTML Text" doc:"Email body of content_type, html and text replace it"`
+	ContentType   string              `json:"content_type,omitempty" validate:"required_with=Body" doc:"text/plain or text/html, the type of body"`
+	HTML          *string             `json:"html,omitempty" validate:"omitempty,min=1" doc:"HTML body, a plain text alternative is generated from it when text is omitted"`
+	Text          *string             `json:"text,omitempty" validate:"omitempty,min=1" doc:"Plain text body, sent as the alternative of html when both are given"`
+	ReplyTo       *string             `json:"reply_to" validate:"omitempty,email" doc:"Reply-To address"`
+	From          *string             `json:"from" validate:"omitempty,email" doc:"Sender, defaults to config"`
+	CC            []string            `json:"cc" doc:"Carbon copy recipients"`
+	BCC           []string            `json:"bcc" doc:"Blind carbon copy recipients"`
+	Attachments   []EmailAttachment   `json:"attachments" validate:"omitempty,dive" doc:"List of attachments"`
+	CalendarEvent *EmailCalendarEvent `json:"calendar_event,omitempty" doc:"Meeting invite, mail clients show it with accept and decline buttons"`
 }
 
 type EmailRequestSendDTO struct {
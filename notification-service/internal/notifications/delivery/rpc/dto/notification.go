@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+type NotificationGetParams struct {
+	NotificationID string `json:"notification_id" validate:"required,uuid" doc:"ID returned by one of your send methods"`
+}
+
+type NotificationStatusDTO struct {
+	NotificationID string     `json:"notification_id"`
+	Channel        string     `json:"channel"`
+	Status         string     `json:"status" doc:"queued, sending, sent, retrying, failed or dead"`
+	Attempts       int        `json:"attempts" doc:"Number of delivery attempts"`
+	LastError      *string    `json:"last_error" doc:"Error of the last failed attempt"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+}
+
+type NotificationSubscribeParams struct {
+	NotificationIDs []string `json:"notification_ids" validate:"required,min=1,max=100,dive,uuid" doc:"IDs returned by send methods, only your own notifications can be followed"`
+}
+
+type NotificationSubscribeDTO struct {
+	Notifications []NotificationStatusDTO `json:"notifications" doc:"Current state, later changes are pushed as notification.status"`
+}
+
+type NotificationUnsubscribeParams struct {
+	NotificationIDs []string `json:"notification_ids" validate:"required,min=1,max=100,dive,uuid"`
+}
+
+type NotificationUnsubscribeDTO struct {
+	Unsubscribed int `json:"unsubscribed"`
+}
+
+// NotificationStatusEventDTO is the params of the server-pushed notification.status message.
+type NotificationStatusEventDTO struct {
+	NotificationID string    `json:"notification_id"`
+	Status         string    `json:"status"`
+	LastError      *string   `json:"last_error,omitempty"`
+	At             time.Time `json:"at"`
+	Final          bool      `json:"final" doc:"No further changes will be pushed for this notification"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+type PushRegisterParams struct {
+	UserID   string `json:"user_id" validate:"required,max=255" doc:"Your identifier of the user owning the device"`
+	Token    string `json:"token" validate:"required,max=512" doc:"FCM registration token or hex encoded APNs device token"`
+	Platform string `json:"platform" validate:"required,oneof=fcm apns" doc:"fcm or apns"`
+}
+
+type PushRegisterDTO struct {
+	DeviceID  string    `json:"device_id"`
+	UserID    string    `json:"user_id"`
+	Platform  string    `json:"platform"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PushUnregisterParams struct {
+	Token string `json:"token" validate:"required,max=512" doc:"Previously registered token"`
+}
+
+type PushUnregisterDTO struct {
+	Unregistered bool `json:"unregistered"`
+}
+
+type PushRequestSendParams struct {
+	UserID *string           `json:"user_id,omitempty" validate:"required_without=Token,excluded_with=Token,omitempty,max=255" doc:"Send to every registered device of the user"`
+	Token  *string           `json:"token,omitempty" validate:"required_without=UserID,omitempty,max=512" doc:"Send to a single registered device"`
+	Title  string            `json:"title" validate:"required,max=256" doc:"Notification title"`
+	Body   string            `json:"body" validate:"required,max=4096" doc:"Notification text"`
+	Data   map[string]string `json:"data,omitempty" validate:"max=50" doc:"Custom key/value payload for the app"`
+	Badge  *int              `json:"badge,omitempty" validate:"omitempty,min=0" doc:"iOS badge counter"`
+	Sound  *string           `json:"sound,omitempty" validate:"omitempty,max=64" doc:"Sound name, default for the system sound"`
+}
+
+type PushResponseSendDTO struct {
+	NotificationID string `json:"notification_id"`
+	Queued         bool   `json:"queued"`
+}
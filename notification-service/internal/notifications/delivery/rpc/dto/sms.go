@@ -0,0 +1,12 @@
+package dto
+
+type SMSRequestSendParams struct {
+	To      string  `json:"to" validate:"required,e164" doc:"Phone number in E.164 format (e.g. +15551234567)"`
+	Message string  `json:"message" validate:"required,max=1600" doc:"Message text"`
+	Sender  *string `json:"sender,omitempty" validate:"omitempty,max=15" doc:"Sender ID, defaults to SMS_SENDER"`
+}
+
+type SMSResponseSendDTO struct {
+	NotificationID string `json:"notification_id"`
+	Queued         bool   `json:"queued"`
+}
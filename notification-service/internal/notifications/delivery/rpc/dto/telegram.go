@@ -1,12 +1,83 @@
 package dto
 
+import (
+	"encoding/base64"
+	"time"
+)
+
 type TelegramRequestSendParams struct {
-	To        string  `json:"to" validate:"required"`
-	Message   string  `json:"message" validate:"required"`
-	ParseMode *string `json:"parse_mode,omitempty"`
+	To                       string                      `json:"to,omitempty" validate:"required_without=UserID,excluded_with=UserID" doc:"Telegram chat ID"`
+	UserID                   *string                     `json:"user_id,omitempty" validate:"required_without=To,omitempty,max=255" doc:"Your user linked with telegram.link, sent to their chat"`
+	Bot                      *string                     `json:"bot,omitempty" validate:"omitempty,max=64" doc:"Configured bot to send with, defaults to TELEGRAM_DEFAULT_BOT"`
+	Message                  string                      `json:"message" validate:"required_without=Media" doc:"Message text, the caption when media is sent"`
+	ParseMode                *string                     `json:"parse_mode,omitempty" doc:"Markdown, MarkdownV2, HTML or none, defaults to Markdown"`
+	Escape                   bool                        `json:"escape,omitempty" doc:"Treat message and captions as plain text and escape them for parse_mode"`
+	InlineKeyboard           [][]TelegramInlineButton    `json:"inline_keyboard,omitempty" validate:"omitempty,max=100,dive,min=1,max=8,dive" doc:"Rows of buttons under the message"`
+	DisableNotification      bool                        `json:"disable_notification,omitempty" doc:"Deliver silently"`
+	DisableWebPagePreview    bool                        `json:"disable_web_page_preview,omitempty" doc:"Shortcut for link_preview_options.is_disabled"`
+	LinkPreviewOptions       *TelegramLinkPreviewOptions `json:"link_preview_options,omitempty" doc:"Link preview generation options"`
+	MessageThreadID          *int64                      `json:"message_thread_id,omitempty" validate:"omitempty,min=1" doc:"Forum topic of the chat"`
+	ReplyToMessageID         *int64                      `json:"reply_to_message_id,omitempty" validate:"omitempty,min=1" doc:"Message to reply to"`
+	AllowSendingWithoutReply bool                        `json:"allow_sending_without_reply,omitempty" doc:"Send even if the replied message is deleted"`
+	ProtectContent           bool                        `json:"protect_content,omitempty" doc:"Forbid forwarding and saving"`
+	Media                    []TelegramMedia             `json:"media,omitempty" validate:"omitempty,max=10,dive" doc:"Photos or documents, 2-10 items are sent as an album"`
+}
+
+type TelegramMedia struct {
+	Type     string  `json:"type" validate:"required,oneof=photo document" doc:"photo or document"`
+	URL      *string `json:"url,omitempty" validate:"required_without=Data,excluded_with=Data,omitempty,url,max=2048" doc:"Public file URL fetched by Telegram, or file:///path on the host of a local Bot API server for files larger than an upload"`
+	Data     *string `json:"data,omitempty" validate:"required_without=URL,omitempty,base64" doc:"File contents as base64"`
+	Filename string  `json:"filename,omitempty" validate:"max=255" doc:"File name of uploaded data"`
+	Caption  *string `json:"caption,omitempty" doc:"Caption of this item, message is the caption of the first item and cannot be combined with its caption"`
+}
+
+type TelegramInlineButton struct {
+	Text         string  `json:"text" validate:"required,max=64" doc:"Button label"`
+	URL          *string `json:"url,omitempty" validate:"required_without=CallbackData,excluded_with=CallbackData,omitempty,url" doc:"Link opened by the button"`
+	CallbackData *string `json:"callback_data,omitempty" validate:"required_without=URL,omitempty,max=64" doc:"Data sent to the bot when pressed, up to 64 bytes, so fewer characters outside ASCII"`
+}
+
+type TelegramLinkPreviewOptions struct {
+	IsDisabled       bool    `json:"is_disabled,omitempty" doc:"Do not generate a preview"`
+	URL              *string `json:"url,omitempty" validate:"omitempty,url" doc:"Link to preview instead of the first one in the text"`
+	PreferSmallMedia bool    `json:"prefer_small_media,omitempty"`
+	PreferLargeMedia bool    `json:"prefer_large_media,omitempty"`
+	ShowAboveText    bool    `json:"show_above_text,omitempty"`
 }
 
 type TelegramResponseSendDTO struct {
 	NotificationID string `json:"notification_id"`
 	Queued         bool   `json:"queued"`
 }
+
+func (m TelegramMedia) Bytes() ([]byte, error) {
+	if m.Data == nil {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(*m.Data)
+}
+
+type TelegramLinkParams struct {
+	UserID string  `json:"user_id" validate:"required,max=255" doc:"Your identifier of the user, telegram.send accepts it once they open the link"`
+	Bot    *string `json:"bot,omitempty" validate:"omitempty,max=64" doc:"Bot the user links with, telegram.send must name the same bot"`
+}
+
+type TelegramLinkDTO struct {
+	Token     string    `json:"token" doc:"Payload of the /start command, usable once"`
+	URL       string    `json:"url,omitempty" doc:"t.me deep link, when the username of the bot is configured"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type TelegramRequestEditParams struct {
+	NotificationID        string                      `json:"notification_id" validate:"required,uuid" doc:"ID returned by telegram.send, once its status is sent"`
+	Message               string                      `json:"message" validate:"required" doc:"New text, or the new caption of a photo, document or album"`
+	ParseMode             *string                     `json:"parse_mode,omitempty" doc:"Markdown, MarkdownV2, HTML or none, defaults to Markdown"`
+	Escape                bool                        `json:"escape,omitempty" doc:"Treat message as plain text and escape it for parse_mode"`
+	InlineKeyboard        [][]TelegramInlineButton    `json:"inline_keyboard,omitempty" validate:"omitempty,max=100,dive,min=1,max=8,dive" doc:"Replaces the buttons, they are removed when omitted"`
+	DisableWebPagePreview bool                        `json:"disable_web_page_preview,omitempty" doc:"Shortcut for link_preview_options.is_disabled"`
+	LinkPreviewOptions    *TelegramLinkPreviewOptions `json:"link_preview_options,omitempty" doc:"Link preview generation options"`
+}
+
+type TelegramRequestDeleteParams struct {
+	NotificationID string `json:"notification_id" validate:"required,uuid" doc:"ID returned by telegram.send, once its status is sent, every message sent for it is deleted"`
+}
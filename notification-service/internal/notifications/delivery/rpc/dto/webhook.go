@@ -0,0 +1,16 @@
+package dto
+
+import "github.com/goccy/go-json"
+
+type WebhookRequestSendParams struct {
+	URL       string            `json:"url" validate:"required,http_url,max=2048" doc:"Target URL, the payload is POSTed to it"`
+	Event     string            `json:"event,omitempty" validate:"max=128" doc:"Event name, sent as X-Webhook-Event"`
+	Payload   json.RawMessage   `json:"payload" validate:"required" doc:"JSON body sent as is"`
+	Headers   map[string]string `json:"headers,omitempty" validate:"max=20" doc:"Extra request headers, the X-Webhook-* headers cannot be overridden"`
+	TimeoutMS *int              `json:"timeout_ms,omitempty" validate:"omitempty,min=100,max=60000" doc:"Request timeout of this target, defaults to WEBHOOK_TIMEOUT"`
+}
+
+type WebhookResponseSendDTO struct {
+	NotificationID string `json:"notification_id"`
+	Queued         bool   `json:"queued"`
+}
@@ -1,55 +1,344 @@
 package rpc
 
 import (
-	"github.com/go-playground/validator/v10"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"notification-service-api/internal/notifications/app"
 	"notification-service-api/internal/notifications/delivery/rpc/dto"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
 	"notification-service-api/internal/shared/rpc"
 	"notification-service-api/internal/shared/rpc/respond"
+	"strconv"
+	"strings"
 )
 
 type NotificationHandler struct {
-	validator       *validator.Validate
-	telegramService *app.TelegramService
-	emailService    *app.EmailService
+	telegramService     *app.TelegramService
+	telegramLinkService *app.TelegramLinkService
+	emailService        *app.EmailService
+	smsService          *app.SMSService
+	pushService         *app.PushService
+	webhookService      *app.WebhookService
+	chatOpsService      *app.ChatOpsService
+	statusService       *app.NotificationStatusService
+	subscriptions       *app.StatusSubscriptions
 }
 
-func NewNotificationHandler(validator *validator.Validate, telegramService *app.TelegramService, emailService *app.EmailService) *NotificationHandler {
+func NewNotificationHandler(telegramService *app.TelegramService, telegramLinkService *app.TelegramLinkService, emailService *app.EmailService, smsService *app.SMSService, pushService *app.PushService, webhookService *app.WebhookService, chatOpsService *app.ChatOpsService, statusService *app.NotificationStatusService, subscriptions *app.StatusSubscriptions) *NotificationHandler {
 	return &NotificationHandler{
-		validator:       validator,
-		telegramService: telegramService,
-		emailService:    emailService,
+		telegramService:     telegramService,
+		telegramLinkService: telegramLinkService,
+		emailService:        emailService,
+		smsService:          smsService,
+		pushService:         pushService,
+		webhookService:      webhookService,
+		chatOpsService:      chatOpsService,
+		statusService:       statusService,
+		subscriptions:       subscriptions,
 	}
 }
 
-func (h *NotificationHandler) SendToTelegram(c *rpc.HttpCtx, params dto.TelegramRequestSendParams) (any, *respond.RPCError) {
-	if err := h.validator.Struct(params); err != nil {
-		return nil, respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", err.Error())
+func (h *NotificationHandler) SendToTelegram(c *rpc.HttpCtx, params dto.TelegramRequestSendParams) (*dto.TelegramResponseSendDTO, *respond.RPCError) {
+	id, err := h.telegramService.WithLogger(c.Logger()).EnqueueTelegram(c, c.RequestID(), principalID(c), params)
+	if err != nil {
+		return nil, telegramEnqueueError(c, "enqueue_telegram", err)
+	}
+
+	return &dto.TelegramResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
+
+func (h *NotificationHandler) EditTelegram(c *rpc.HttpCtx, params dto.TelegramRequestEditParams) (*dto.TelegramResponseSendDTO, *respond.RPCError) {
+	id, err := h.telegramService.WithLogger(c.Logger()).EnqueueEdit(c, c.RequestID(), principalID(c), params)
+	if err != nil {
+		return nil, telegramEnqueueError(c, "enqueue_telegram_edit", err)
 	}
 
-	h.telegramService.WithLogger(c.Logger())
+	return &dto.TelegramResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
+
+func (h *NotificationHandler) DeleteTelegram(c *rpc.HttpCtx, params dto.TelegramRequestDeleteParams) (*dto.TelegramResponseSendDTO, *respond.RPCError) {
+	id, err := h.telegramService.WithLogger(c.Logger()).EnqueueDelete(c, c.RequestID(), principalID(c), params)
+	if err != nil {
+		return nil, telegramEnqueueError(c, "enqueue_telegram_delete", err)
+	}
+
+	return &dto.TelegramResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
 
-	id, err := h.telegramService.EnqueueTelegram(c.Context, c.RequestID(), params)
+func (h *NotificationHandler) LinkTelegram(c *rpc.HttpCtx, params dto.TelegramLinkParams) (*dto.TelegramLinkDTO, *respond.RPCError) {
+	token, url, expiresAt, err := h.telegramLinkService.WithLogger(c.Logger()).CreateLink(c, principalID(c), params.UserID, params.Bot)
 	if err != nil {
-		c.Logger().Error("enqueue_telegram", zap.Error(err))
-		return nil, respond.NewRPCError(respond.InternalError, "enqueue_telegram", "enqueue_telegram", err.Error())
+		return nil, telegramEnqueueError(c, "telegram_link", err)
 	}
 
-	return dto.TelegramResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+	return &dto.TelegramLinkDTO{Token: token, URL: url, ExpiresAt: expiresAt}, nil
 }
 
-func (h *NotificationHandler) SendToEmail(c *rpc.HttpCtx, params dto.EmailRequestSendParams) (any, *respond.RPCError) {
-	if err := h.validator.Struct(params); err != nil {
-		return nil, respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", err.Error())
+func telegramEnqueueError(c *rpc.HttpCtx, code string, err error) *respond.RPCError {
+	var limitErr *domain.TelegramLimitError
+	if errors.As(err, &limitErr) {
+		return respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", []respond.FieldError{{
+			Field:    limitErr.Field[strings.LastIndex(limitErr.Field, ".")+1:],
+			JSONPath: limitErr.Field,
+			Rule:     limitErr.Rule,
+			Message:  limitErr.Message,
+		}})
 	}
+	if errors.Is(err, domain.ErrNotificationNotFound) {
+		return respond.NewRPCError(respond.NotFoundError, "notification_not_found", "notification not found", nil)
+	}
+
+	c.Logger().Error(code, zap.Error(err))
+	return respond.NewRPCError(respond.InternalError, code, code, err.Error())
+}
 
-	h.emailService.WithLogger(c.Logger())
-	id, err := h.emailService.EnqueueEmail(c.Context, c.RequestID(), params)
+func (h *NotificationHandler) SendToEmail(c *rpc.HttpCtx, params dto.EmailRequestSendParams) (*dto.EmailRequestSendDTO, *respond.RPCError) {
+	id, err := h.emailService.WithLogger(c.Logger()).EnqueueEmail(c, c.RequestID(), principalID(c), params)
 	if err != nil {
 		c.Logger().Error("enqueue_email", zap.Error(err))
 		return nil, respond.NewRPCError(respond.InternalError, "enqueue_email", "enqueue_email", err.Error())
 	}
 
-	return dto.EmailRequestSendDTO{NotificationID: id.String(), Queued: true}, nil
+	return &dto.EmailRequestSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
+
+func (h *NotificationHandler) SendToSMS(c *rpc.HttpCtx, params dto.SMSRequestSendParams) (*dto.SMSResponseSendDTO, *respond.RPCError) {
+	id, err := h.smsService.WithLogger(c.Logger()).EnqueueSMS(c, c.RequestID(), principalID(c), params)
+	if err != nil {
+		if errors.Is(err, domain.ErrChannelDisabled) {
+			return nil, respond.NewRPCError(respond.InvalidRequest, "channel_disabled", "sms channel is not configured", nil)
+		}
+
+		c.Logger().Error("enqueue_sms", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "enqueue_sms", "enqueue_sms", err.Error())
+	}
+
+	return &dto.SMSResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
+
+func (h *NotificationHandler) SendToPush(c *rpc.HttpCtx, params dto.PushRequestSendParams) (*dto.PushResponseSendDTO, *respond.RPCError) {
+	id, err := h.pushService.WithLogger(c.Logger()).EnqueuePush(c, c.RequestID(), principalID(c), params)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDeviceTokenNotFound):
+			return nil, respond.NewRPCError(respond.NotFoundError, "device_not_found", "device token is not registered", nil)
+		case errors.Is(err, domain.ErrNoDevices):
+			return nil, respond.NewRPCError(respond.NotFoundError, "no_devices", "user has no registered devices", nil)
+		}
+
+		c.Logger().Error("enqueue_push", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "enqueue_push", "enqueue_push", err.Error())
+	}
+
+	return &dto.PushResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
+
+func (h *NotificationHandler) SendToWebhook(c *rpc.HttpCtx, params dto.WebhookRequestSendParams) (*dto.WebhookResponseSendDTO, *respond.RPCError) {
+	id, err := h.webhookService.WithLogger(c.Logger()).EnqueueWebhook(c, c.RequestID(), principalID(c), params)
+	if err != nil {
+		if errors.Is(err, domain.ErrChannelDisabled) {
+			return nil, respond.NewRPCError(respond.InvalidRequest, "channel_disabled", "webhook channel is not configured", nil)
+		}
+		if errors.Is(err, domain.ErrWebhookHostNotAllowed) {
+			return nil, respond.NewRPCError(respond.InvalidParams, "webhook_host_not_allowed", "webhook host is not allowed", []respond.FieldError{{
+				Field:    "url",
+				JSONPath: "url",
+				Rule:     "allowed_host",
+				Message:  "url host is not in WEBHOOK_ALLOWED_HOSTS",
+			}})
+		}
+
+		c.Logger().Error("enqueue_webhook", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "enqueue_webhook", "enqueue_webhook", err.Error())
+	}
+
+	return &dto.WebhookResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
+
+func (h *NotificationHandler) SendToSlack(c *rpc.HttpCtx, params dto.ChatOpsRequestSendParams) (*dto.ChatOpsResponseSendDTO, *respond.RPCError) {
+	return h.sendToChatOps(c, domain.ChannelSlack, params)
+}
+
+func (h *NotificationHandler) SendToDiscord(c *rpc.HttpCtx, params dto.ChatOpsRequestSendParams) (*dto.ChatOpsResponseSendDTO, *respond.RPCError) {
+	return h.sendToChatOps(c, domain.ChannelDiscord, params)
+}
+
+func (h *NotificationHandler) SendToMattermost(c *rpc.HttpCtx, params dto.ChatOpsRequestSendParams) (*dto.ChatOpsResponseSendDTO, *respond.RPCError) {
+	return h.sendToChatOps(c, domain.ChannelMattermost, params)
+}
+
+func (h *NotificationHandler) sendToChatOps(c *rpc.HttpCtx, channel domain.Channel, params dto.ChatOpsRequestSendParams) (*dto.ChatOpsResponseSendDTO, *respond.RPCError) {
+	id, err := h.chatOpsService.WithLogger(c.Logger()).EnqueueChatOps(c, channel, c.RequestID(), principalID(c), params)
+	if err != nil {
+		if errors.Is(err, domain.ErrChatOpsTargetNotFound) {
+			return nil, respond.NewRPCError(respond.InvalidParams, "target_not_found", "chat-ops target is not configured", []respond.FieldError{{
+				Field:    "target",
+				JSONPath: "target",
+				Rule:     "configured_target",
+				Message:  "target is not a configured " + channel.String() + " webhook",
+			}})
+		}
+		if errors.Is(err, domain.ErrDiscordEmbedTooLong) {
+			return nil, respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", []respond.FieldError{{
+				Field:    "text",
+				JSONPath: "text",
+				Rule:     "max",
+				Param:    strconv.Itoa(domain.DiscordMaxEmbedLength),
+				Message:  fmt.Sprintf("title, text, fields and footer must be at most %d characters long together", domain.DiscordMaxEmbedLength),
+			}})
+		}
+
+		c.Logger().Error("enqueue_"+channel.String(), zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "enqueue_"+channel.String(), "enqueue_"+channel.String(), err.Error())
+	}
+
+	return &dto.ChatOpsResponseSendDTO{NotificationID: id.String(), Queued: true}, nil
+}
+
+// RegisterDevice stores a push token, tokens are scoped to the caller so only it can send to them.
+func (h *NotificationHandler) RegisterDevice(c *rpc.HttpCtx, params dto.PushRegisterParams) (*dto.PushRegisterDTO, *respond.RPCError) {
+	device, err := h.pushService.WithLogger(c.Logger()).Register(c, principalID(c), params)
+	if errors.Is(err, domain.ErrMalformedAPNsToken) {
+		return nil, respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", []respond.FieldError{{
+			Field:    "token",
+			JSONPath: "token",
+			Rule:     "hexadecimal",
+			Message:  "token must be the hex encoded APNs device token",
+		}})
+	}
+	if err != nil {
+		c.Logger().Error("register_device", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "register_device", "register_device", err.Error())
+	}
+
+	return &dto.PushRegisterDTO{
+		DeviceID:  device.ID.String(),
+		UserID:    device.UserID,
+		Platform:  device.Platform.String(),
+		CreatedAt: device.CreatedAt,
+	}, nil
+}
+
+func (h *NotificationHandler) UnregisterDevice(c *rpc.HttpCtx, params dto.PushUnregisterParams) (*dto.PushUnregisterDTO, *respond.RPCError) {
+	if err := h.pushService.WithLogger(c.Logger()).Unregister(c, principalID(c), params.Token); err != nil {
+		if errors.Is(err, domain.ErrDeviceTokenNotFound) {
+			return nil, respond.NewRPCError(respond.NotFoundError, "device_not_found", "device token is not registered", nil)
+		}
+
+		c.Logger().Error("unregister_device", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "unregister_device", "unregister_device", err.Error())
+	}
+
+	return &dto.PushUnregisterDTO{Unregistered: true}, nil
+}
+
+func (h *NotificationHandler) GetNotification(c *rpc.HttpCtx, params dto.NotificationGetParams) (*dto.NotificationStatusDTO, *respond.RPCError) {
+	id, rpcErr := rpc.ParseUUID("notification_id", params.NotificationID)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	notification, err := h.statusService.Get(c, id)
+	// another caller's notification is reported as missing, so ids cannot be probed
+	if errors.Is(err, domain.ErrNotificationNotFound) || (err == nil && notification.CreatedBy != principalID(c)) {
+		return nil, respond.NewRPCError(respond.NotFoundError, "notification_not_found", "notification not found", nil)
+	}
+	if err != nil {
+		c.Logger().Error("get_notification", zap.Error(err))
+		return nil, respond.NewRPCError(respond.InternalError, "get_notification", "failed to get notification", nil)
+	}
+
+	return toNotificationStatusDTO(notification), nil
+}
+
+// SubscribeNotifications follows the delivery state of the caller's own notifications over the WebSocket connection.
+func (h *NotificationHandler) SubscribeNotifications(c *rpc.HttpCtx, params dto.NotificationSubscribeParams) (*dto.NotificationSubscribeDTO, *respond.RPCError) {
+	session := c.Session()
+	if session == nil {
+		return nil, respond.NewRPCError(respond.InvalidRequest, "websocket_required", "subscriptions are only available over /ws", nil)
+	}
+
+	ids, rpcErr := parseNotificationIDs(params.NotificationIDs)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	result := &dto.NotificationSubscribeDTO{Notifications: make([]dto.NotificationStatusDTO, 0, len(ids))}
+	for i, id := range ids {
+		raw := params.NotificationIDs[i]
+
+		notification, err := h.statusService.Get(c, id)
+		// somebody else's notification is reported as missing, its existence is not disclosed
+		if errors.Is(err, domain.ErrNotificationNotFound) || (err == nil && notification.CreatedBy != principalID(c)) {
+			return nil, respond.NewRPCError(respond.NotFoundError, "notification_not_found", "notification not found", map[string]string{
+				"notification_id": raw,
+			})
+		}
+		if err != nil {
+			c.Logger().Error("subscribe_notification", zap.Error(err))
+			return nil, respond.NewRPCError(respond.InternalError, "subscribe_notification", "subscribe_notification", err.Error())
+		}
+
+		result.Notifications = append(result.Notifications, *toNotificationStatusDTO(notification))
+	}
+
+	for _, id := range ids {
+		h.subscriptions.Subscribe(session, id)
+	}
+
+	return result, nil
+}
+
+func (h *NotificationHandler) UnsubscribeNotifications(c *rpc.HttpCtx, params dto.NotificationUnsubscribeParams) (*dto.NotificationUnsubscribeDTO, *respond.RPCError) {
+	session := c.Session()
+	if session == nil {
+		return nil, respond.NewRPCError(respond.InvalidRequest, "websocket_required", "subscriptions are only available over /ws", nil)
+	}
+
+	ids, rpcErr := parseNotificationIDs(params.NotificationIDs)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	for _, id := range ids {
+		h.subscriptions.Unsubscribe(session, id)
+	}
+
+	return &dto.NotificationUnsubscribeDTO{Unsubscribed: len(params.NotificationIDs)}, nil
+}
+
+func parseNotificationIDs(raw []string) ([]uuid.UUID, *respond.RPCError) {
+	ids := make([]uuid.UUID, 0, len(raw))
+	for i, r := range raw {
+		id, rpcErr := rpc.ParseUUID("notification_ids["+strconv.Itoa(i)+"]", r)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func toNotificationStatusDTO(notification *entity.Notification) *dto.NotificationStatusDTO {
+	return &dto.NotificationStatusDTO{
+		NotificationID: notification.ID.String(),
+		Channel:        notification.Channel.String(),
+		Status:         notification.Status.String(),
+		Attempts:       notification.Attempts,
+		LastError:      notification.LastError,
+		CreatedAt:      notification.CreatedAt,
+		UpdatedAt:      notification.UpdatedAt,
+		SentAt:         notification.SentAt,
+	}
+}
+
+func principalID(c *rpc.HttpCtx) string {
+	if p := c.Principal(); p != nil {
+		return p.Key()
+	}
+	return ""
 }
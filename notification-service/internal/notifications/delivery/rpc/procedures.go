@@ -7,8 +7,38 @@ import (
 )
 
 func InitNotificationProcedures(dependencies *di.Dependencies) {
-	notificationHandler := NewNotificationHandler(dependencies.Validator, dependencies.TelegramService, dependencies.EmailService)
+	notificationHandler := NewNotificationHandler(dependencies.TelegramService, dependencies.TelegramLinkService, dependencies.EmailService, dependencies.SMSService, dependencies.PushService, dependencies.WebhookService, dependencies.ChatOpsService, dependencies.StatusService, dependencies.StatusSubscriptions)
 
-	dependencies.Registry.Register("telegram.send", rpc.Typed[dto.TelegramRequestSendParams](notificationHandler.SendToTelegram))
-	dependencies.Registry.Register("email.send", rpc.Typed[dto.EmailRequestSendParams](notificationHandler.SendToEmail))
+	dependencies.Registry.Register("telegram.send", rpc.Typed[dto.TelegramRequestSendParams](notificationHandler.SendToTelegram).
+		WithSummary("Send a message to Telegram.").Idempotent())
+	dependencies.Registry.Register("telegram.edit", rpc.Typed[dto.TelegramRequestEditParams](notificationHandler.EditTelegram).
+		WithSummary("Edit the text or caption and the buttons of a message sent with telegram.send. Split messages cannot be edited.").Idempotent())
+	dependencies.Registry.Register("telegram.delete", rpc.Typed[dto.TelegramRequestDeleteParams](notificationHandler.DeleteTelegram).
+		WithSummary("Delete the messages sent for a telegram.send notification, Telegram allows it for 48 hours.").Idempotent())
+	dependencies.Registry.Register("telegram.link", rpc.Typed[dto.TelegramLinkParams](notificationHandler.LinkTelegram).
+		WithSummary("Create a one-time /start deep link. The chat that opens it is linked to the user, telegram.send then accepts user_id."))
+	dependencies.Registry.Register("email.send", rpc.Typed[dto.EmailRequestSendParams](notificationHandler.SendToEmail).
+		WithSummary("Send an email.").Idempotent())
+	dependencies.Registry.Register("sms.send", rpc.Typed[dto.SMSRequestSendParams](notificationHandler.SendToSMS).
+		WithSummary("Send an SMS.").Idempotent())
+	dependencies.Registry.Register("push.send", rpc.Typed[dto.PushRequestSendParams](notificationHandler.SendToPush).
+		WithSummary("Send a push notification to a registered device or to every device of a user.").Idempotent())
+	dependencies.Registry.Register("webhook.send", rpc.Typed[dto.WebhookRequestSendParams](notificationHandler.SendToWebhook).
+		WithSummary("POST a signed JSON payload to a URL. 5xx and 429 answers are retried, other 4xx fail at once.").Idempotent())
+	dependencies.Registry.Register("slack.send", rpc.Typed[dto.ChatOpsRequestSendParams](notificationHandler.SendToSlack).
+		WithSummary("Post a message to a Slack incoming webhook configured in SLACK_WEBHOOKS.").Idempotent())
+	dependencies.Registry.Register("discord.send", rpc.Typed[dto.ChatOpsRequestSendParams](notificationHandler.SendToDiscord).
+		WithSummary("Post a message to a Discord webhook configured in DISCORD_WEBHOOKS.").Idempotent())
+	dependencies.Registry.Register("mattermost.send", rpc.Typed[dto.ChatOpsRequestSendParams](notificationHandler.SendToMattermost).
+		WithSummary("Post a message to a Mattermost incoming webhook configured in MATTERMOST_WEBHOOKS.").Idempotent())
+	dependencies.Registry.Register("push.register", rpc.Typed[dto.PushRegisterParams](notificationHandler.RegisterDevice).
+		WithSummary("Register a device token for push notifications, registering a known token moves it to the user."))
+	dependencies.Registry.Register("push.unregister", rpc.Typed[dto.PushUnregisterParams](notificationHandler.UnregisterDevice).
+		WithSummary("Remove a device token."))
+	dependencies.Registry.Register("notification.get", rpc.Typed[dto.NotificationGetParams](notificationHandler.GetNotification).
+		WithSummary("Get the delivery state of a previously enqueued notification."))
+	dependencies.Registry.Register("notification.subscribe", rpc.Typed[dto.NotificationSubscribeParams](notificationHandler.SubscribeNotifications).
+		WithSummary("WebSocket only. Push notification.status messages when the delivery state of the notifications changes."))
+	dependencies.Registry.Register("notification.unsubscribe", rpc.Typed[dto.NotificationUnsubscribeParams](notificationHandler.UnsubscribeNotifications).
+		WithSummary("WebSocket only. Stop pushing status changes of the notifications."))
 }
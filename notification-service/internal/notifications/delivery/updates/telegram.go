@@ -0,0 +1,74 @@
+package updates
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"notification-service-api/pkg/di"
+	"sync"
+)
+
+const (
+	TelegramUpdatesWebhook = "webhook"
+	TelegramUpdatesPolling = "polling"
+
+	TelegramWebhookPath = "/telegram/webhook"
+)
+
+// RegisterTelegramWebhook adds a webhook route per bot when updates come by webhook, the bare path is the default bot.
+// Without a secret anybody could post fake /start commands, so the service does not start then.
+func RegisterTelegramWebhook(router gin.IRoutes, dependencies *di.Dependencies) {
+	config := dependencies.Config
+	if config.TelegramUpdates != TelegramUpdatesWebhook {
+		return
+	}
+	if config.TelegramWebhookSecret == "" {
+		dependencies.Logger.Fatal("TELEGRAM_WEBHOOK_SECRET is required to receive telegram updates by webhook")
+	}
+
+	handler := NewTelegramUpdateHandler(dependencies.Logger, dependencies.TelegramLinkService)
+	router.POST(TelegramWebhookPath, handler.Webhook(config.TelegramDefaultBot, config.TelegramWebhookSecret))
+	for name := range dependencies.TelegramAPIs {
+		router.POST(TelegramWebhookPath+"/"+name, handler.Webhook(name, config.TelegramWebhookSecret))
+	}
+
+	dependencies.Logger.Info("Telegram webhooks registered")
+}
+
+// StartTelegramUpdates points every bot at its webhook, TELEGRAM_WEBHOOK_URL followed by the bot name,
+// or polls getUpdates for every bot.
+func StartTelegramUpdates(dependencies *di.Dependencies) {
+	ctx := context.Background()
+	config := dependencies.Config
+
+	switch config.TelegramUpdates {
+	case TelegramUpdatesWebhook:
+		if config.TelegramWebhookURL == "" || config.TelegramWebhookSecret == "" {
+			return
+		}
+		for name, api := range dependencies.TelegramAPIs {
+			if err := api.SetWebhook(ctx, config.TelegramWebhookURL+"/"+name, config.TelegramWebhookSecret); err != nil {
+				dependencies.Logger.Error("failed to set telegram webhook", zap.String("bot", name), zap.Error(err))
+				continue
+			}
+
+			dependencies.Logger.Info("Telegram webhook set", zap.String("bot", name))
+		}
+	case TelegramUpdatesPolling:
+		handler := NewTelegramUpdateHandler(dependencies.Logger, dependencies.TelegramLinkService)
+
+		var wg sync.WaitGroup
+		for name, poller := range dependencies.TelegramPollers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				poller.Run(ctx, func(ctx context.Context, update []byte) error {
+					return handler.Handle(ctx, name, update)
+				})
+			}()
+		}
+
+		dependencies.Logger.Info("Telegram updates polling started")
+		wg.Wait()
+	}
+}
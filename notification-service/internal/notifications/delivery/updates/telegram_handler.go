@@ -0,0 +1,89 @@
+package updates
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"notification-service-api/internal/notifications/app"
+)
+
+// maxUpdateSize is far above any message update, it only bounds what an unauthenticated caller can make us read.
+const maxUpdateSize = 1 << 20
+
+// telegramUpdate is the part of a Bot API Update the bot reacts to.
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID       int64  `json:"id"`
+			Username string `json:"username"`
+		} `json:"chat"`
+		From *struct {
+			Username string `json:"username"`
+		} `json:"from"`
+	} `json:"message"`
+}
+
+type TelegramUpdateHandler struct {
+	logger      *zap.Logger
+	linkService *app.TelegramLinkService
+}
+
+func NewTelegramUpdateHandler(logger *zap.Logger, linkService *app.TelegramLinkService) *TelegramUpdateHandler {
+	return &TelegramUpdateHandler{
+		logger:      logger,
+		linkService: linkService,
+	}
+}
+
+// Handle reacts to a single update received by the named bot, from the webhook or from getUpdates.
+func (h *TelegramUpdateHandler) Handle(ctx context.Context, bot string, raw []byte) error {
+	var update telegramUpdate
+	if err := json.Unmarshal(raw, &update); err != nil {
+		h.logger.Error("failed to unmarshal telegram update", zap.Error(err))
+		return nil
+	}
+	if update.Message == nil || update.Message.Text == "" {
+		return nil
+	}
+
+	logger := h.logger.With(zap.String("bot", bot), zap.Int64("update_id", update.UpdateID))
+
+	username := update.Message.Chat.Username
+	if update.Message.From != nil && update.Message.From.Username != "" {
+		username = update.Message.From.Username
+	}
+
+	return h.linkService.WithLogger(logger).HandleMessage(ctx, bot, update.Message.Chat.ID, username, update.Message.Text)
+}
+
+// Webhook receives updates pushed by Telegram to the bot. Only requests carrying the secret given to setWebhook are accepted,
+// a failed update answers 500 so Telegram delivers it again.
+func (h *TelegramUpdateHandler) Webhook(bot string, secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxUpdateSize))
+		if err != nil {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+
+		if err := h.Handle(c.Request.Context(), bot, raw); err != nil {
+			h.logger.Error("failed to handle telegram update", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		c.Status(http.StatusOK)
+	}
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "errors"
+
+// DefaultChatOpsTarget is used when a chat-ops send names no target.
+const DefaultChatOpsTarget = "default"
+
+const (
+	ChatOpsLevelInfo    = "info"
+	ChatOpsLevelSuccess = "success"
+	ChatOpsLevelWarning = "warning"
+	ChatOpsLevelError   = "error"
+)
+
+// DiscordMaxEmbedLength is the limit of title, description, field names and values and footer of an embed together.
+const DiscordMaxEmbedLength = 6000
+
+var (
+	ErrChatOpsTargetNotFound = errors.New("chat-ops target is not configured")
+	ErrDiscordEmbedTooLong   = errors.New("title, text, fields and footer together exceed the discord embed limit")
+)
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"notification-service-api/internal/notifications/domain"
+	"time"
+)
+
+// DeviceToken is a push token of a user's device, scoped to the caller that registered it.
+type DeviceToken struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primaryKey"`
+	Owner      string          `gorm:"type:varchar(255);not null;uniqueIndex:idx_device_tokens_owner_token;index:idx_device_tokens_owner_user"`
+	UserID     string          `gorm:"type:varchar(255);not null;index:idx_device_tokens_owner_user"`
+	Platform   domain.Platform `gorm:"type:varchar(16);not null"`
+	Token      string          `gorm:"type:varchar(512);not null;uniqueIndex:idx_device_tokens_owner_token;index"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (DeviceToken) TableName() string {
+	return "device_tokens"
+}
@@ -2,36 +2,153 @@ package entity
 
 import (
 	"github.com/google/uuid"
+	"notification-service-api/internal/notifications/domain"
 	"time"
 )
 
 type TelegramNotification struct {
-	NotificationID uuid.UUID `msgpack:"notification_id"`
-	CorrelationID  string    `msgpack:"request_id"`
-	To             string    `msgpack:"to"`
-	Payload        string    `msgpack:"payload"`
-	ParseMode      string    `msgpack:"parse_mode"`
-	CreatedAt      time.Time `msgpack:"created_at"`
+	NotificationID           uuid.UUID                `msgpack:"notification_id"`
+	CorrelationID            string                   `msgpack:"request_id"`
+	Bot                      string                   `msgpack:"bot"`
+	Action                   string                   `msgpack:"action"`
+	TargetID                 uuid.UUID                `msgpack:"target_id"`
+	To                       string                   `msgpack:"to"`
+	Payload                  string                   `msgpack:"payload"`
+	Parts                    []string                 `msgpack:"parts"`
+	ParseMode                string                   `msgpack:"parse_mode"`
+	InlineKeyboard           [][]TelegramInlineButton `msgpack:"inline_keyboard"`
+	DisableNotification      bool                     `msgpack:"disable_notification"`
+	LinkPreview              *TelegramLinkPreview     `msgpack:"link_preview"`
+	MessageThreadID          *int64                   `msgpack:"message_thread_id"`
+	ReplyToMessageID         *int64                   `msgpack:"reply_to_message_id"`
+	AllowSendingWithoutReply bool                     `msgpack:"allow_sending_without_reply"`
+	ProtectContent           bool                     `msgpack:"protect_content"`
+	Media                    []TelegramMedia          `msgpack:"media"`
+	CreatedAt                time.Time                `msgpack:"created_at"`
+}
+
+// TelegramMedia is a photo or document, either uploaded from Data or fetched by Telegram from URL.
+type TelegramMedia struct {
+	Type     string `msgpack:"type"`
+	URL      string `msgpack:"url"`
+	Data     []byte `msgpack:"data"`
+	Filename string `msgpack:"filename"`
+	Caption  string `msgpack:"caption"`
+}
+
+type TelegramInlineButton struct {
+	Text         string `msgpack:"text"`
+	URL          string `msgpack:"url"`
+	CallbackData string `msgpack:"callback_data"`
+}
+
+type TelegramLinkPreview struct {
+	IsDisabled       bool   `msgpack:"is_disabled"`
+	URL              string `msgpack:"url"`
+	PreferSmallMedia bool   `msgpack:"prefer_small_media"`
+	PreferLargeMedia bool   `msgpack:"prefer_large_media"`
+	ShowAboveText    bool   `msgpack:"show_above_text"`
 }
 
 type EmailAttachment struct {
 	Filename    string `msgpack:"filename"`
 	Data        []byte `msgpack:"data"`
 	ContentType string `msgpack:"content_type"`
+	ContentID   string `msgpack:"content_id"`
+	Inline      bool   `msgpack:"inline"`
+}
+
+type EmailCalendarEvent struct {
+	UID         string                `msgpack:"uid"`
+	Sequence    int                   `msgpack:"sequence"`
+	Summary     string                `msgpack:"summary"`
+	Description string                `msgpack:"description"`
+	Location    string                `msgpack:"location"`
+	Start       time.Time             `msgpack:"start"`
+	End         time.Time             `msgpack:"end"`
+	Timezone    string                `msgpack:"timezone"`
+	Organizer   EmailCalendarPerson   `msgpack:"organizer"`
+	Attendees   []EmailCalendarPerson `msgpack:"attendees"`
+}
+
+type EmailCalendarPerson struct {
+	Email    string `msgpack:"email"`
+	Name     string `msgpack:"name"`
+	Optional bool   `msgpack:"optional"`
 }
 
 type EmailNotification struct {
+	NotificationID uuid.UUID           `msgpack:"notification_id"`
+	CorrelationID  string              `msgpack:"request_id"`
+	To             string              `msgpack:"to"`
+	Subject        string              `msgpack:"subject"`
+	Body           string              `msgpack:"body"`
+	ContentType    string              `msgpack:"content_type"`
+	HTML           string              `msgpack:"html"`
+	Text           string              `msgpack:"text"`
+	CC             []string            `msgpack:"cc"`
+	BCC            []string            `msgpack:"bcc"`
+	From           *string             `msgpack:"from"`
+	ReplyTo        *string             `msgpack:"reply_to"`
+	Attachments    []EmailAttachment   `msgpack:"attachments"`
+	CalendarEvent  *EmailCalendarEvent `msgpack:"calendar_event"`
+	CreatedAt      time.Time           `msgpack:"created_at"`
+	SentAt         time.Time           `msgpack:"sent_at"`
+}
+
+type SMSNotification struct {
+	NotificationID uuid.UUID `msgpack:"notification_id"`
+	CorrelationID  string    `msgpack:"request_id"`
+	To             string    `msgpack:"to"`
+	Message        string    `msgpack:"message"`
+	Sender         string    `msgpack:"sender"`
+	CreatedAt      time.Time `msgpack:"created_at"`
+}
+
+type PushNotification struct {
 	NotificationID uuid.UUID         `msgpack:"notification_id"`
 	CorrelationID  string            `msgpack:"request_id"`
-	To             string            `msgpack:"to"`
-	Subject        string            `msgpack:"subject"`
+	Owner          string            `msgpack:"owner"`
+	UserID         string            `msgpack:"user_id"`
+	Token          string            `msgpack:"token"`
+	Title          string            `msgpack:"title"`
 	Body           string            `msgpack:"body"`
-	ContentType    string            `msgpack:"content_type"`
-	CC             []string          `msgpack:"cc"`
-	BCC            []string          `msgpack:"bcc"`
-	From           *string           `msgpack:"from"`
-	ReplyTo        *string           `msgpack:"reply_to"`
-	Attachments    []EmailAttachment `msgpack:"attachments"`
+	Data           map[string]string `msgpack:"data"`
+	Badge          *int              `msgpack:"badge"`
+	Sound          string            `msgpack:"sound"`
 	CreatedAt      time.Time         `msgpack:"created_at"`
-	SentAt         time.Time         `msgpack:"sent_at"`
+}
+
+type WebhookNotification struct {
+	NotificationID uuid.UUID         `msgpack:"notification_id"`
+	CorrelationID  string            `msgpack:"request_id"`
+	URL            string            `msgpack:"url"`
+	Event          string            `msgpack:"event"`
+	Payload        []byte            `msgpack:"payload"`
+	Headers        map[string]string `msgpack:"headers"`
+	Timeout        time.Duration     `msgpack:"timeout"`
+	CreatedAt      time.Time         `msgpack:"created_at"`
+}
+
+type ChatOpsField struct {
+	Title string `msgpack:"title"`
+	Value string `msgpack:"value"`
+	Short bool   `msgpack:"short"`
+}
+
+// ChatOpsNotification is a channel-neutral chat message, every chat-ops adapter renders it in its own format.
+type ChatOpsNotification struct {
+	NotificationID uuid.UUID      `msgpack:"notification_id"`
+	CorrelationID  string         `msgpack:"request_id"`
+	Channel        domain.Channel `msgpack:"channel"`
+	Target         string         `msgpack:"target"`
+	Title          string         `msgpack:"title"`
+	Text           string         `msgpack:"text"`
+	Level          string         `msgpack:"level"`
+	URL            string         `msgpack:"url"`
+	Fields         []ChatOpsField `msgpack:"fields"`
+	Footer         string         `msgpack:"footer"`
+	Username       string         `msgpack:"username"`
+	IconURL        string         `msgpack:"icon_url"`
+	CreatedAt      time.Time      `msgpack:"created_at"`
 }
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"notification-service-api/internal/notifications/domain"
+	"time"
+)
+
+// Notification is the persisted delivery state of a single enqueued notification.
+type Notification struct {
+	ID            uuid.UUID      `gorm:"type:uuid;primaryKey"`
+	Channel       domain.Channel `gorm:"type:varchar(32);index;not null"`
+	Status        domain.Status  `gorm:"type:varchar(16);index;not null"`
+	CorrelationID string         `gorm:"type:varchar(255)"`
+	Recipient     string         `gorm:"type:varchar(255)"`
+	CreatedBy     string         `gorm:"type:varchar(255);index"`
+	Attempts      int            `gorm:"not null;default:0"`
+	LastError     *string        `gorm:"type:text"`
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// TelegramBinding is the chat a user of a caller connected with a /start link of a bot, telegram.send can target the user by it.
+type TelegramBinding struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Owner     string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_telegram_bindings_owner_user_bot"`
+	UserID    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_telegram_bindings_owner_user_bot"`
+	Bot       string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_telegram_bindings_owner_user_bot;index:idx_telegram_bindings_bot_chat"`
+	ChatID    int64     `gorm:"not null;index:idx_telegram_bindings_bot_chat"`
+	Username  string    `gorm:"type:varchar(255)"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (TelegramBinding) TableName() string {
+	return "telegram_bindings"
+}
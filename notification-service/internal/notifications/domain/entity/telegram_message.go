@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// TelegramMessage is a message Telegram delivered for a notification, a split text or an album has several.
+type TelegramMessage struct {
+	ID             uint      `gorm:"primaryKey"`
+	NotificationID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_telegram_messages_notification_position"`
+	Position       int       `gorm:"not null;uniqueIndex:idx_telegram_messages_notification_position"`
+	Bot            string    `gorm:"type:varchar(64);not null"`
+	ChatID         int64     `gorm:"not null"`
+	MessageID      int64     `gorm:"not null"`
+	Type           string    `gorm:"type:varchar(16);not null"`
+	CreatedAt      time.Time
+}
+
+func (TelegramMessage) TableName() string {
+	return "telegram_messages"
+}
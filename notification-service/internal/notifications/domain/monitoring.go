@@ -1,16 +1,27 @@
 package domain
 
+import "errors"
+
 type Channel string
 
 const (
-	ChannelTelegram Channel = "telegram"
-	ChannelEmail    Channel = "email"
+	ChannelTelegram   Channel = "telegram"
+	ChannelEmail      Channel = "email"
+	ChannelSMS        Channel = "sms"
+	ChannelPush       Channel = "push"
+	ChannelWebhook    Channel = "webhook"
+	ChannelSlack      Channel = "slack"
+	ChannelDiscord    Channel = "discord"
+	ChannelMattermost Channel = "mattermost"
 )
 
 func (c Channel) String() string {
 	return string(c)
 }
 
+// ErrChannelDisabled is returned by the sends of a channel that is not configured.
+var ErrChannelDisabled = errors.New("channel is not configured")
+
 type NotificationType string
 
 const (
@@ -26,4 +37,6 @@ type NotificationMonitoring interface {
 	Send(channel Channel, notificationType NotificationType, delta int64)
 	SendSuccess(channel Channel, count int64)
 	SendError(channel Channel, count int64)
+	// With returns a monitoring that adds the tags to every point, its totals are kept apart from the untagged ones.
+	With(tags map[string]string) NotificationMonitoring
 }
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// Platform is the push service a device token belongs to.
+type Platform string
+
+const (
+	PlatformFCM  Platform = "fcm"
+	PlatformAPNs Platform = "apns"
+)
+
+func (p Platform) String() string {
+	return string(p)
+}
+
+var (
+	// ErrMalformedAPNsToken rejects an APNs token at registration, it becomes part of the request path.
+	ErrMalformedAPNsToken  = errors.New("apns token must be an even number of hexadecimal digits")
+	ErrDeviceTokenNotFound = errors.New("device token not found")
+	ErrNoDevices           = errors.New("no registered devices")
+	// ErrInvalidDeviceToken is returned by push providers for tokens that will never be delivered again.
+	ErrInvalidDeviceToken = errors.New("invalid device token")
+)
+
+// IsAPNsToken reports a device token as APNs issues it, hex encoded bytes, 32 of them today.
+func IsAPNsToken(token string) bool {
+	if len(token) < 64 || len(token) > 200 {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil
+}
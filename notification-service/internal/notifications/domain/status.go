@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued   Status = "queued"
+	StatusSending  Status = "sending"
+	StatusSent     Status = "sent"
+	StatusRetrying Status = "retrying"
+	StatusFailed   Status = "failed"
+	StatusDead     Status = "dead"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// StatusEvent is published on every delivery status change of a notification.
+type StatusEvent struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+	Status         Status    `json:"status"`
+	LastError      *string   `json:"last_error,omitempty"`
+	At             time.Time `json:"at"`
+}
+
+// IsFinal reports whether no further status change is expected.
+func (s Status) IsFinal() bool {
+	return s == StatusSent || s == StatusDead
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Bot API limits, checked before a message is enqueued so it cannot fail for them in the queue.
+const (
+	TelegramMediaPhoto    = "photo"
+	TelegramMediaDocument = "document"
+
+	TelegramMaxPhotoSize     = 10 << 20
+	TelegramMaxDocumentSize  = 50 << 20
+	TelegramMaxCaptionLength = 1024
+	TelegramMaxAlbumSize     = 10
+	// TelegramMaxCallbackData is counted in bytes, not characters
+	TelegramMaxCallbackData = 64
+	// TelegramMaxUploadSize bounds the files of one message together, they travel in a single queue message
+	// well below the RabbitMQ message size limit. Larger files go by url.
+	TelegramMaxUploadSize = 50 << 20
+)
+
+// TelegramFileScheme names a file on the host of a local Bot API server, which reads it itself,
+// so files larger than an upload are never carried through the queue.
+const TelegramFileScheme = "file"
+
+// Actions of a queued Telegram message, an empty action is a send.
+const (
+	TelegramActionSend   = ""
+	TelegramActionEdit   = "edit"
+	TelegramActionDelete = "delete"
+)
+
+// TelegramMessageText is the type of a stored text message, media messages keep their media type.
+const TelegramMessageText = "text"
+
+var (
+	ErrTelegramMessageNotFound = errors.New("telegram message not found")
+	ErrTelegramNotEditable     = errors.New("telegram notification was sent as several messages and cannot be edited")
+)
+
+// TelegramSentMessage is a message Telegram delivered, the chat id is numeric even when sent to an @username.
+type TelegramSentMessage struct {
+	ChatID    int64
+	MessageID int64
+	Type      string
+}
+
+// TelegramLimitError names the param that breaks a Telegram limit.
+type TelegramLimitError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *TelegramLimitError) Error() string {
+	return e.Message
+}
+
+// TelegramAPIError is a Bot API response that is not ok, with the hints of its parameters.
+type TelegramAPIError struct {
+	Code            int
+	Description     string
+	RetryAfter      time.Duration
+	MigrateToChatID int64
+}
+
+func (e *TelegramAPIError) Error() string {
+	return fmt.Sprintf("telegram api error %d: %s", e.Code, e.Description)
+}
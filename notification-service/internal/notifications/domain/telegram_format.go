@@ -0,0 +1,510 @@
+package domain
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	TelegramParseModeNone       = ""
+	TelegramParseModeMarkdown   = "Markdown"
+	TelegramParseModeMarkdownV2 = "MarkdownV2"
+	TelegramParseModeHTML       = "HTML"
+
+	TelegramMaxMessageLength = 4096
+	// TelegramMaxMessageParts bounds how many messages a long text may be split into
+	TelegramMaxMessageParts = 20
+)
+
+// NormalizeTelegramParseMode maps a parse_mode param case-insensitively, "none" sends plain text.
+func NormalizeTelegramParseMode(raw string) (string, bool) {
+	switch strings.ToLower(raw) {
+	case "none":
+		return TelegramParseModeNone, true
+	case "markdown":
+		return TelegramParseModeMarkdown, true
+	case "markdownv2":
+		return TelegramParseModeMarkdownV2, true
+	case "html":
+		return TelegramParseModeHTML, true
+	default:
+		return "", false
+	}
+}
+
+var (
+	markdownV2Escaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+		">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	markdownEscaper = strings.NewReplacer("_", `\_`, "*", `\*`, "`", "\\`", "[", `\[`)
+	htmlEscaper     = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+// EscapeTelegramText makes plain text safe to send with the parse mode.
+func EscapeTelegramText(text string, parseMode string) string {
+	switch parseMode {
+	case TelegramParseModeMarkdownV2:
+		return markdownV2Escaper.Replace(text)
+	case TelegramParseModeMarkdown:
+		return markdownEscaper.Replace(text)
+	case TelegramParseModeHTML:
+		return htmlEscaper.Replace(text)
+	default:
+		return text
+	}
+}
+
+// ValidateTelegramText reports the first construct Telegram would fail to parse.
+func ValidateTelegramText(text string, parseMode string) error {
+	_, err := tokenizeTelegram(text, parseMode)
+	return err
+}
+
+// SplitTelegramText validates the text and cuts it into messages of at most limit UTF-16 units.
+// Cuts prefer paragraph, line and word boundaries, entities open at a cut are closed at the end
+// of the part and opened again at the start of the next one.
+func SplitTelegramText(text string, parseMode string, limit int) ([]string, error) {
+	tokens, err := tokenizeTelegram(text, parseMode)
+	if err != nil {
+		return nil, err
+	}
+	if TelegramTextLength(text) <= limit {
+		return []string{text}, nil
+	}
+
+	return splitTokens(tokens, limit), nil
+}
+
+// TelegramPlainText drops the markup, it is the fallback when Telegram cannot parse a message.
+func TelegramPlainText(text string, parseMode string) string {
+	tokens, err := tokenizeTelegram(text, parseMode)
+	if err != nil {
+		return text
+	}
+
+	var b strings.Builder
+	for _, token := range tokens {
+		switch token.kind {
+		case tokenText:
+			b.WriteString(token.raw)
+		case tokenAtomic:
+			b.WriteString(token.plain)
+		}
+	}
+
+	return b.String()
+}
+
+type tokenKind int
+
+const (
+	tokenText tokenKind = iota
+	tokenOpen
+	tokenClose
+	// tokenAtomic is never cut: escapes, html entities and links
+	tokenAtomic
+)
+
+type token struct {
+	kind tokenKind
+	raw  string
+	// closer of an open token
+	closer string
+	// plain text of an atomic token
+	plain string
+}
+
+// TelegramTextLength counts UTF-16 units, the way Telegram measures messages and captions.
+func TelegramTextLength(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+func tokenizeTelegram(text string, parseMode string) ([]token, error) {
+	switch parseMode {
+	case TelegramParseModeMarkdownV2:
+		return tokenizeMarkdown(text, true)
+	case TelegramParseModeMarkdown:
+		return tokenizeMarkdown(text, false)
+	case TelegramParseModeHTML:
+		return tokenizeHTML(text)
+	default:
+		return []token{{kind: tokenText, raw: text}}, nil
+	}
+}
+
+type tokenizer struct {
+	text   string
+	tokens []token
+	stack  []token
+	plain  strings.Builder
+}
+
+func (t *tokenizer) flushText() {
+	if t.plain.Len() > 0 {
+		t.tokens = append(t.tokens, token{kind: tokenText, raw: t.plain.String()})
+		t.plain.Reset()
+	}
+}
+
+func (t *tokenizer) add(tok token) {
+	t.flushText()
+	t.tokens = append(t.tokens, tok)
+}
+
+func (t *tokenizer) position(i int) int {
+	return utf8.RuneCountInString(t.text[:i])
+}
+
+func (t *tokenizer) open(raw string, closer string) {
+	tok := token{kind: tokenOpen, raw: raw, closer: closer}
+	t.add(tok)
+	t.stack = append(t.stack, tok)
+}
+
+func (t *tokenizer) close(raw string) {
+	t.add(token{kind: tokenClose, raw: raw})
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *tokenizer) top() *token {
+	if len(t.stack) == 0 {
+		return nil
+	}
+	return &t.stack[len(t.stack)-1]
+}
+
+const markdownV2Reserved = "_*[]()~`>#+-=|{}.!"
+
+// tokenizeMarkdown handles MarkdownV2 and, without v2, the legacy Markdown subset (no underline,
+// strikethrough and spoiler, reserved characters need no escaping).
+func tokenizeMarkdown(text string, v2 bool) ([]token, error) {
+	t := &tokenizer{text: text}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		// inside code only ` and \ are special
+		if top := t.top(); top != nil && (top.closer == "`" || top.closer == "```") {
+			switch {
+			case rest[0] == '\\' && len(rest) > 1 && (rest[1] == '`' || rest[1] == '\\'):
+				t.add(token{kind: tokenAtomic, raw: rest[:2], plain: rest[1:2]})
+				i += 2
+			case strings.HasPrefix(rest, top.closer):
+				t.close(top.closer)
+				i += len(top.closer)
+			default:
+				t.plain.WriteByte(rest[0])
+				i++
+			}
+			continue
+		}
+
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && rest[1] < utf8.RuneSelf && (v2 || strings.IndexByte("_*`[", rest[1]) >= 0):
+			t.add(token{kind: tokenAtomic, raw: rest[:2], plain: rest[1:2]})
+			i += 2
+		case strings.HasPrefix(rest, "```"):
+			// the opener keeps the language line: ```go\n
+			opener := "```"
+			if nl := strings.IndexByte(rest, '\n'); nl > 0 && !strings.ContainsAny(rest[3:nl], " `") {
+				opener = rest[:nl+1]
+			}
+			t.open(opener, "```")
+			i += len(opener)
+		case rest[0] == '`':
+			t.open("`", "`")
+			i++
+		case v2 && strings.HasPrefix(rest, "||"):
+			if err := t.toggle("||", i); err != nil {
+				return nil, err
+			}
+			i += 2
+		case v2 && strings.HasPrefix(rest, "__"):
+			if err := t.toggle("__", i); err != nil {
+				return nil, err
+			}
+			i += 2
+		case rest[0] == '_' || rest[0] == '*' || (v2 && rest[0] == '~'):
+			if err := t.toggle(rest[:1], i); err != nil {
+				return nil, err
+			}
+			i++
+		case rest[0] == '[' || (v2 && strings.HasPrefix(rest, "![")):
+			n, plain, err := parseMarkdownLink(rest, v2)
+			if err != nil {
+				return nil, fmt.Errorf("%w at position %d", err, t.position(i))
+			}
+			t.add(token{kind: tokenAtomic, raw: rest[:n], plain: plain})
+			i += n
+		case v2 && rest[0] == '>' && (i == 0 || text[i-1] == '\n'):
+			// blockquote marker at the start of a line
+			t.plain.WriteByte('>')
+			i++
+		case v2 && strings.IndexByte(markdownV2Reserved, rest[0]) >= 0:
+			return nil, fmt.Errorf("character '%c' at position %d must be escaped with '\\'", rest[0], t.position(i))
+		default:
+			_, size := utf8.DecodeRuneInString(rest)
+			t.plain.WriteString(rest[:size])
+			i += size
+		}
+	}
+
+	if top := t.top(); top != nil {
+		return nil, fmt.Errorf("entity %q is not closed", strings.TrimSpace(top.raw))
+	}
+
+	t.flushText()
+	return t.tokens, nil
+}
+
+func (t *tokenizer) toggle(marker string, i int) error {
+	if top := t.top(); top != nil && top.closer == marker {
+		t.close(marker)
+		return nil
+	}
+
+	for _, open := range t.stack {
+		if open.closer == marker {
+			return fmt.Errorf("entity %q at position %d overlaps another entity", marker, t.position(i))
+		}
+	}
+
+	t.open(marker, marker)
+	return nil
+}
+
+// parseMarkdownLink returns the length of [text](url) or ![emoji](tg://emoji?id=...) at the start of s.
+func parseMarkdownLink(s string, v2 bool) (int, string, error) {
+	start := strings.IndexByte(s, '[')
+
+	end := indexUnescaped(s, start+1, ']', v2)
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return 0, "", fmt.Errorf("link is not closed")
+	}
+
+	urlEnd := indexUnescaped(s, end+2, ')', v2)
+	if urlEnd < 0 {
+		return 0, "", fmt.Errorf("link url is not closed")
+	}
+
+	label, url := unescapeMarkdown(s[start+1:end]), unescapeMarkdown(s[end+2:urlEnd])
+	if start > 0 || strings.HasPrefix(url, "tg://") {
+		return urlEnd + 1, label, nil
+	}
+	return urlEnd + 1, label + " (" + url + ")", nil
+}
+
+func indexUnescaped(s string, from int, c byte, v2 bool) int {
+	for i := from; i < len(s); i++ {
+		if v2 && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+var markdownUnescape = regexp.MustCompile(`\\([\x01-\x7e])`)
+
+func unescapeMarkdown(s string) string {
+	return markdownUnescape.ReplaceAllString(s, "$1")
+}
+
+var (
+	htmlTag    = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9-]*)(\s[^<>]*)?>`)
+	htmlEntity = regexp.MustCompile(`^&(#[0-9]+|#x[0-9a-fA-F]+|lt|gt|amp|quot);`)
+	htmlTags   = map[string]bool{
+		"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true, "s": true, "strike": true, "del": true,
+		"span": true, "tg-spoiler": true, "a": true, "tg-emoji": true, "code": true, "pre": true, "blockquote": true,
+	}
+)
+
+func tokenizeHTML(text string) ([]token, error) {
+	t := &tokenizer{text: text}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		switch rest[0] {
+		case '<':
+			m := htmlTag.FindStringSubmatch(rest)
+			if m == nil {
+				return nil, fmt.Errorf("character '<' at position %d must be escaped as &lt;", t.position(i))
+			}
+
+			name := strings.ToLower(m[2])
+			if !htmlTags[name] {
+				return nil, fmt.Errorf("unsupported tag <%s> at position %d", name, t.position(i))
+			}
+
+			if m[1] == "/" {
+				top := t.top()
+				if top == nil || top.closer != "</"+name+">" {
+					return nil, fmt.Errorf("unexpected closing tag </%s> at position %d", name, t.position(i))
+				}
+				t.close(m[0])
+			} else {
+				t.open(m[0], "</"+name+">")
+			}
+			i += len(m[0])
+		case '&':
+			m := htmlEntity.FindString(rest)
+			if m == "" {
+				return nil, fmt.Errorf("character '&' at position %d must be escaped as &amp;", t.position(i))
+			}
+			t.add(token{kind: tokenAtomic, raw: m, plain: html.UnescapeString(m)})
+			i += len(m)
+		case '>':
+			return nil, fmt.Errorf("character '>' at position %d must be escaped as &gt;", t.position(i))
+		default:
+			_, size := utf8.DecodeRuneInString(rest)
+			t.plain.WriteString(rest[:size])
+			i += size
+		}
+	}
+
+	if top := t.top(); top != nil {
+		return nil, fmt.Errorf("tag %s is not closed", top.raw)
+	}
+
+	t.flushText()
+	return t.tokens, nil
+}
+
+type splitter struct {
+	limit  int
+	parts  []string
+	cur    strings.Builder
+	curLen int
+	// bytes of cur that are only the reopened entities of the previous part
+	prefixLen int
+	stack     []token
+}
+
+func splitTokens(tokens []token, limit int) []string {
+	s := &splitter{limit: limit}
+
+	for _, tok := range tokens {
+		switch tok.kind {
+		case tokenText:
+			s.addText(tok.raw)
+		case tokenOpen:
+			s.fit(TelegramTextLength(tok.raw) + TelegramTextLength(tok.closer))
+			s.write(tok.raw)
+			s.stack = append(s.stack, tok)
+		case tokenClose:
+			// the closer was already reserved with its opener
+			s.write(tok.raw)
+			s.stack = s.stack[:len(s.stack)-1]
+		case tokenAtomic:
+			s.fit(TelegramTextLength(tok.raw))
+			s.write(tok.raw)
+		}
+	}
+
+	s.flush()
+	return s.parts
+}
+
+func (s *splitter) write(raw string) {
+	s.cur.WriteString(raw)
+	s.curLen += TelegramTextLength(raw)
+}
+
+func (s *splitter) closersLength() int {
+	n := 0
+	for _, open := range s.stack {
+		n += TelegramTextLength(open.closer)
+	}
+	return n
+}
+
+// fit starts a new part unless n more units fit into the current one.
+func (s *splitter) fit(n int) {
+	if s.curLen+n+s.closersLength() > s.limit && s.cur.Len() > s.prefixLen {
+		s.flush()
+		s.reopen()
+	}
+}
+
+func (s *splitter) addText(text string) {
+	for text != "" {
+		room := s.limit - s.curLen - s.closersLength()
+		if TelegramTextLength(text) <= room {
+			s.write(text)
+			return
+		}
+
+		cut := cutIndex(text, room)
+		if cut == 0 {
+			if s.cur.Len() > s.prefixLen {
+				s.flush()
+				s.reopen()
+				continue
+			}
+			// not even one character fits next to the reopened entities, overflow rather than loop
+			_, cut = utf8.DecodeRuneInString(text)
+		}
+
+		s.write(text[:cut])
+		text = text[cut:]
+		s.flush()
+		s.reopen()
+	}
+}
+
+func (s *splitter) flush() {
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		s.cur.WriteString(s.stack[i].closer)
+	}
+	if part := strings.TrimSpace(s.cur.String()); part != "" {
+		s.parts = append(s.parts, s.cur.String())
+	}
+	s.cur.Reset()
+	s.curLen = 0
+}
+
+func (s *splitter) reopen() {
+	for _, open := range s.stack {
+		s.write(open.raw)
+	}
+	s.prefixLen = s.cur.Len()
+}
+
+// cutIndex returns the byte length of the longest prefix of at most room units that ends at
+// a paragraph, line or word boundary, or a hard cut when the boundary would make the part too short.
+func cutIndex(text string, room int) int {
+	if room <= 0 {
+		return 0
+	}
+
+	hard, units := 0, 0
+	for i, r := range text {
+		units += utf16.RuneLen(r)
+		if units > room {
+			break
+		}
+		hard = i + utf8.RuneLen(r)
+	}
+
+	prefix := text[:hard]
+	for _, sep := range []string{"\n\n", "\n", " "} {
+		if i := strings.LastIndex(prefix, sep); i > 0 && i >= hard/2 {
+			return i + len(sep)
+		}
+	}
+
+	return hard
+}
@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeTelegramTextMarkdownV2(t *testing.T) {
+	for _, c := range `\` + markdownV2Reserved {
+		raw := "a" + string(c) + "b"
+		escaped := EscapeTelegramText(raw, TelegramParseModeMarkdownV2)
+
+		if want := `a\` + string(c) + "b"; escaped != want {
+			t.Errorf("EscapeTelegramText(%q) = %q, want %q", raw, escaped, want)
+		}
+		if err := ValidateTelegramText(escaped, TelegramParseModeMarkdownV2); err != nil {
+			t.Errorf("escaped %q is not valid MarkdownV2: %v", escaped, err)
+		}
+		if plain := TelegramPlainText(escaped, TelegramParseModeMarkdownV2); plain != raw {
+			t.Errorf("plain text of %q = %q, want %q", escaped, plain, raw)
+		}
+	}
+}
+
+func TestEscapeTelegramText(t *testing.T) {
+	tests := []struct {
+		name      string
+		parseMode string
+		raw       string
+		want      string
+	}{
+		{name: "markdown", parseMode: TelegramParseModeMarkdown, raw: "a_b*c`d[e]f.", want: "a\\_b\\*c\\`d\\[e]f."},
+		{name: "html", parseMode: TelegramParseModeHTML, raw: `<b>&"x"</b>`, want: `&lt;b&gt;&amp;"x"&lt;/b&gt;`},
+		{name: "none", parseMode: TelegramParseModeNone, raw: "*_<&", want: "*_<&"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escaped := EscapeTelegramText(tt.raw, tt.parseMode)
+			if escaped != tt.want {
+				t.Fatalf("EscapeTelegramText(%q) = %q, want %q", tt.raw, escaped, tt.want)
+			}
+			if err := ValidateTelegramText(escaped, tt.parseMode); err != nil {
+				t.Fatalf("escaped text is not valid: %v", err)
+			}
+			if plain := TelegramPlainText(escaped, tt.parseMode); plain != tt.raw {
+				t.Fatalf("plain text = %q, want %q", plain, tt.raw)
+			}
+		})
+	}
+}
+
+func TestValidateTelegramText(t *testing.T) {
+	tests := []struct {
+		name      string
+		parseMode string
+		text      string
+		wantErr   string
+	}{
+		{name: "unescaped reserved", parseMode: TelegramParseModeMarkdownV2, text: "1.5", wantErr: "character '.' at position 1"},
+		{name: "unclosed bold", parseMode: TelegramParseModeMarkdownV2, text: "*bold", wantErr: "not closed"},
+		{name: "overlapping entities", parseMode: TelegramParseModeMarkdownV2, text: "*a _b* c_", wantErr: "overlaps"},
+		{name: "unclosed link", parseMode: TelegramParseModeMarkdown, text: "[docs](https://example.com", wantErr: "link url is not closed"},
+		{name: "position counts characters", parseMode: TelegramParseModeMarkdownV2, text: "привет!", wantErr: "position 6"},
+		{name: "unknown tag", parseMode: TelegramParseModeHTML, text: "<div>x</div>", wantErr: "unsupported tag <div>"},
+		{name: "crossed tags", parseMode: TelegramParseModeHTML, text: "<b><i>x</b></i>", wantErr: "unexpected closing tag </b>"},
+		{name: "bare ampersand", parseMode: TelegramParseModeHTML, text: "a & b", wantErr: "&amp;"},
+		{name: "reserved inside code", parseMode: TelegramParseModeMarkdownV2, text: "`a.b_c`"},
+		{name: "legacy markdown dots", parseMode: TelegramParseModeMarkdown, text: "1.5 *bold*"},
+		{name: "html entities", parseMode: TelegramParseModeHTML, text: "<a href=\"https://example.com\">x</a> &lt;&#8212;&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTelegramText(tt.text, tt.parseMode)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitTelegramText(t *testing.T) {
+	words := func(word string, n int) string {
+		return strings.TrimSpace(strings.Repeat(word+" ", n))
+	}
+
+	tests := []struct {
+		name      string
+		parseMode string
+		text      string
+		limit     int
+		// every part has to start with it
+		prefix string
+	}{
+		{
+			name:      "nested markdownv2 entities",
+			parseMode: TelegramParseModeMarkdownV2,
+			text:      "*bold _italic " + words("word", 30) + "_ tail " + words("more", 10) + "*",
+			limit:     50,
+			prefix:    "*",
+		},
+		{
+			name:      "markdownv2 code block with language",
+			parseMode: TelegramParseModeMarkdownV2,
+			text:      "```go\n" + strings.Repeat("fmt.Println(1)\n", 20) + "```",
+			limit:     60,
+			prefix:    "```go\n",
+		},
+		{
+			name:      "markdown inline code",
+			parseMode: TelegramParseModeMarkdown,
+			text:      "`" + words("code_with_underscores", 12) + "`",
+			limit:     70,
+			prefix:    "`",
+		},
+		{
+			name:      "links are never cut",
+			parseMode: TelegramParseModeMarkdownV2,
+			text:      strings.Repeat("see [the docs](https://example.com/a_path) ", 12),
+			limit:     80,
+		},
+		{
+			name:      "nested html tags",
+			parseMode: TelegramParseModeHTML,
+			text:      "<b>bold <i>italic " + words("word", 30) + "</i> tail</b>",
+			limit:     60,
+			prefix:    "<b>",
+		},
+		{
+			name:      "html pre with language",
+			parseMode: TelegramParseModeHTML,
+			text:      `<pre><code class="language-go">` + strings.Repeat("x := a &lt; b\n", 20) + "</code></pre>",
+			limit:     90,
+			prefix:    `<pre><code class="language-go">`,
+		},
+		{
+			name:      "html links and entities",
+			parseMode: TelegramParseModeHTML,
+			text:      strings.Repeat(`<a href="https://example.com/?a=1&amp;b=2">link</a> &amp; `, 10),
+			limit:     70,
+		},
+		{
+			name:      "plain text at word boundaries",
+			parseMode: TelegramParseModeNone,
+			text:      words("lorem", 100),
+			limit:     64,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := SplitTelegramText(tt.text, tt.parseMode, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) < 2 {
+				t.Fatalf("text was not split: %q", parts)
+			}
+
+			var plain strings.Builder
+			for i, part := range parts {
+				if n := TelegramTextLength(part); n > tt.limit {
+					t.Errorf("part %d is %d units long, limit %d: %q", i, n, tt.limit, part)
+				}
+				if err := ValidateTelegramText(part, tt.parseMode); err != nil {
+					t.Errorf("part %d is not valid on its own: %v: %q", i, err, part)
+				}
+				if !strings.HasPrefix(part, tt.prefix) {
+					t.Errorf("part %d does not reopen %q: %q", i, tt.prefix, part)
+				}
+				plain.WriteString(TelegramPlainText(part, tt.parseMode))
+			}
+
+			if want := TelegramPlainText(tt.text, tt.parseMode); plain.String() != want {
+				t.Fatalf("parts lost or repeated text\n got: %q\nwant: %q", plain.String(), want)
+			}
+		})
+	}
+}
+
+func TestSplitTelegramTextSurrogatePairs(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantFirst string
+	}{
+		{
+			// the emoji takes two units and would end at 4097
+			name:      "emoji across the limit",
+			text:      strings.Repeat("a", TelegramMaxMessageLength-1) + "😀b",
+			wantFirst: strings.Repeat("a", TelegramMaxMessageLength-1),
+		},
+		{
+			name:      "emoji ending at the limit",
+			text:      strings.Repeat("a", TelegramMaxMessageLength-2) + "😀b",
+			wantFirst: strings.Repeat("a", TelegramMaxMessageLength-2) + "😀",
+		},
+		{
+			name:      "only emoji",
+			text:      strings.Repeat("😀", TelegramMaxMessageLength/2+1),
+			wantFirst: strings.Repeat("😀", TelegramMaxMessageLength/2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := SplitTelegramText(tt.text, TelegramParseModeNone, TelegramMaxMessageLength)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) != 2 || strings.Join(parts, "") != tt.text {
+				t.Fatalf("unexpected split into %d parts", len(parts))
+			}
+			if parts[0] != tt.wantFirst {
+				t.Fatalf("first part is %d units long, want %d", TelegramTextLength(parts[0]), TelegramTextLength(tt.wantFirst))
+			}
+			for i, part := range parts {
+				if !utf8.ValidString(part) {
+					t.Fatalf("part %d cuts a character in half", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitTelegramTextKeepsShortText(t *testing.T) {
+	text := strings.Repeat("😀", TelegramMaxMessageLength/2)
+
+	parts, err := SplitTelegramText(text, TelegramParseModeMarkdownV2, TelegramMaxMessageLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 1 || parts[0] != text {
+		t.Fatalf("text of exactly the limit was split into %d parts", len(parts))
+	}
+}
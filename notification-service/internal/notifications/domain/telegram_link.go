@@ -0,0 +1,15 @@
+package domain
+
+import "errors"
+
+// TelegramLink is a pending /start deep link of a bot, the chat that opens it is bound to the user.
+type TelegramLink struct {
+	Owner  string `json:"owner"`
+	UserID string `json:"user_id"`
+	Bot    string `json:"bot"`
+}
+
+var (
+	ErrTelegramLinkNotFound  = errors.New("telegram link not found or expired")
+	ErrTelegramUserNotLinked = errors.New("telegram chat is not linked to the user")
+)
@@ -0,0 +1,5 @@
+package domain
+
+import "errors"
+
+var ErrWebhookHostNotAllowed = errors.New("webhook host is not allowed")
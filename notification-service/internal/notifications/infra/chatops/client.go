@@ -0,0 +1,109 @@
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/pkg/utils"
+	"strings"
+	"time"
+)
+
+var levelColors = map[string]string{
+	domain.ChatOpsLevelInfo:    "#439FE0",
+	domain.ChatOpsLevelSuccess: "#2EB67D",
+	domain.ChatOpsLevelWarning: "#ECB22E",
+	domain.ChatOpsLevelError:   "#E01E5A",
+}
+
+func levelColor(level string) string {
+	return levelColors[level]
+}
+
+// ParseTargets reads "name=url,name=url", a bare url is the default target.
+func ParseTargets(raw string) (map[string]string, error) {
+	targets := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, url, ok := strings.Cut(item, "=")
+		if !ok || strings.Contains(name, "/") {
+			name, url = domain.DefaultChatOpsTarget, item
+		}
+		name, url = strings.TrimSpace(name), strings.TrimSpace(url)
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return nil, fmt.Errorf("target %s: invalid url", name)
+		}
+
+		targets[name] = url
+	}
+
+	return targets, nil
+}
+
+type client struct {
+	http *http.Client
+}
+
+func newClient(timeout time.Duration) client {
+	return client{http: &http.Client{Timeout: timeout}}
+}
+
+// postJSON retries 5xx and 429 answers, any other error answer is permanent.
+func (c client) postJSON(ctx context.Context, url string, payload any) error {
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return utils.Permanent(fmt.Errorf("marshal request: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return utils.Permanent(fmt.Errorf("create request: %w", withoutURL(err)))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("webhook error: status %d, %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+
+	return utils.Permanent(err)
+}
+
+// withoutURL drops the webhook url the http client puts into its errors, the url is the secret of the webhook.
+// Errors end up in logs and in the last_error clients read.
+func withoutURL(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,85 @@
+package chatops
+
+import (
+	"context"
+	"notification-service-api/internal/notifications/domain/entity"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Discord posts to Discord webhooks, the message is rendered as an embed.
+type Discord struct {
+	client client
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type discordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description"`
+	URL         string              `json:"url,omitempty"`
+	Color       int                 `json:"color,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Footer      *discordEmbedFooter `json:"footer,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+}
+
+type discordMessage struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Embeds    []discordEmbed `json:"embeds"`
+}
+
+func NewDiscord(timeout time.Duration) *Discord {
+	return &Discord{client: newClient(timeout)}
+}
+
+func (d *Discord) Send(ctx context.Context, webhookURL string, message *entity.ChatOpsNotification) error {
+	return d.client.postJSON(ctx, webhookURL, renderDiscord(message))
+}
+
+func renderDiscord(message *entity.ChatOpsNotification) discordMessage {
+	embed := discordEmbed{
+		Title:       truncate(message.Title, 256),
+		Description: truncate(message.Text, 4096),
+		URL:         message.URL,
+		Color:       discordColor(levelColor(message.Level)),
+		Timestamp:   message.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	for _, field := range message.Fields {
+		embed.Fields = append(embed.Fields, discordEmbedField{
+			Name:   truncate(field.Title, 256),
+			Value:  truncate(field.Value, 1024),
+			Inline: field.Short,
+		})
+	}
+
+	if message.Footer != "" {
+		embed.Footer = &discordEmbedFooter{Text: truncate(message.Footer, 2048)}
+	}
+
+	return discordMessage{
+		Username:  message.Username,
+		AvatarURL: message.IconURL,
+		Embeds:    []discordEmbed{embed},
+	}
+}
+
+// discordColor converts "#RRGGBB" to the integer Discord expects.
+func discordColor(hex string) int {
+	color, err := strconv.ParseInt(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return 0
+	}
+	return int(color)
+}
@@ -0,0 +1,72 @@
+package chatops
+
+import (
+	"context"
+	"notification-service-api/internal/notifications/domain/entity"
+	"time"
+)
+
+// Mattermost posts to Mattermost incoming webhooks, the message is rendered as a message attachment.
+type Mattermost struct {
+	client client
+}
+
+type mattermostField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type mattermostAttachment struct {
+	Fallback  string            `json:"fallback"`
+	Color     string            `json:"color,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	TitleLink string            `json:"title_link,omitempty"`
+	Text      string            `json:"text"`
+	Fields    []mattermostField `json:"fields,omitempty"`
+	Footer    string            `json:"footer,omitempty"`
+}
+
+type mattermostMessage struct {
+	Username    string                 `json:"username,omitempty"`
+	IconURL     string                 `json:"icon_url,omitempty"`
+	Attachments []mattermostAttachment `json:"attachments"`
+}
+
+func NewMattermost(timeout time.Duration) *Mattermost {
+	return &Mattermost{client: newClient(timeout)}
+}
+
+func (m *Mattermost) Send(ctx context.Context, webhookURL string, message *entity.ChatOpsNotification) error {
+	return m.client.postJSON(ctx, webhookURL, renderMattermost(message))
+}
+
+func renderMattermost(message *entity.ChatOpsNotification) mattermostMessage {
+	fallback := message.Text
+	if message.Title != "" {
+		fallback = message.Title + ": " + message.Text
+	}
+
+	attachment := mattermostAttachment{
+		Fallback:  fallback,
+		Color:     levelColor(message.Level),
+		Title:     message.Title,
+		TitleLink: message.URL,
+		Text:      message.Text,
+		Footer:    message.Footer,
+	}
+
+	for _, field := range message.Fields {
+		attachment.Fields = append(attachment.Fields, mattermostField{
+			Title: field.Title,
+			Value: field.Value,
+			Short: field.Short,
+		})
+	}
+
+	return mattermostMessage{
+		Username:    message.Username,
+		IconURL:     message.IconURL,
+		Attachments: []mattermostAttachment{attachment},
+	}
+}
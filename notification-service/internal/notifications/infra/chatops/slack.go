@@ -0,0 +1,102 @@
+package chatops
+
+import (
+	"context"
+	"notification-service-api/internal/notifications/domain/entity"
+	"time"
+)
+
+// Slack posts to Slack incoming webhooks, the message is rendered as Block Kit blocks.
+type Slack struct {
+	client client
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []any       `json:"elements,omitempty"`
+}
+
+type slackButton struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Username    string            `json:"username,omitempty"`
+	IconURL     string            `json:"icon_url,omitempty"`
+	Blocks      []slackBlock      `json:"blocks,omitempty"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+func NewSlack(timeout time.Duration) *Slack {
+	return &Slack{client: newClient(timeout)}
+}
+
+func (s *Slack) Send(ctx context.Context, webhookURL string, message *entity.ChatOpsNotification) error {
+	return s.client.postJSON(ctx, webhookURL, renderSlack(message))
+}
+
+func renderSlack(message *entity.ChatOpsNotification) slackMessage {
+	var blocks []slackBlock
+
+	if message.Title != "" {
+		blocks = append(blocks, slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: truncate(message.Title, 150)}})
+	}
+
+	blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate(message.Text, 3000)}})
+
+	if len(message.Fields) > 0 {
+		fields := make([]slackText, 0, len(message.Fields))
+		for _, field := range message.Fields {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: truncate("*"+field.Title+"*\n"+field.Value, 2000)})
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Fields: fields})
+	}
+
+	if message.URL != "" {
+		blocks = append(blocks, slackBlock{Type: "actions", Elements: []any{slackButton{
+			Type: "button",
+			Text: slackText{Type: "plain_text", Text: "Open"},
+			URL:  message.URL,
+		}}})
+	}
+
+	if message.Footer != "" {
+		blocks = append(blocks, slackBlock{Type: "context", Elements: []any{slackText{Type: "mrkdwn", Text: message.Footer}}})
+	}
+
+	// text is the fallback shown in notifications
+	fallback := message.Text
+	if message.Title != "" {
+		fallback = message.Title + ": " + message.Text
+	}
+
+	rendered := slackMessage{
+		Text:     truncate(fallback, 3000),
+		Username: message.Username,
+		IconURL:  message.IconURL,
+	}
+
+	// blocks have no color, a colored bar needs an attachment around them
+	if color := levelColor(message.Level); color != "" {
+		rendered.Attachments = []slackAttachment{{Color: color, Blocks: blocks}}
+	} else {
+		rendered.Blocks = blocks
+	}
+
+	return rendered
+}
@@ -0,0 +1,198 @@
+package email
+
+import (
+	"fmt"
+	"notification-service-api/internal/notifications/domain/entity"
+	"strings"
+	"time"
+	_ "time/tzdata"
+	"unicode/utf8"
+)
+
+const (
+	calendarProductID = "-//notification-service//EN"
+	// calendarLineOctets is the longest content line RFC 5545 allows, longer ones are folded
+	calendarLineOctets = 75
+
+	calendarDateUTC   = "20060102T150405Z"
+	calendarDateLocal = "20060102T150405"
+)
+
+// calendarInvite renders the event as an iCalendar REQUEST. Times in a named time zone are sent as local times
+// with the zone's transitions around the event, so clients without the IANA database still show them right.
+func calendarInvite(event *entity.EmailCalendarEvent, createdAt time.Time) (string, error) {
+	location := time.UTC
+	if event.Timezone != "" && event.Timezone != "UTC" {
+		loaded, err := time.LoadLocation(event.Timezone)
+		if err != nil {
+			return "", fmt.Errorf("calendar event timezone: %w", err)
+		}
+		location = loaded
+	}
+
+	c := &calendarWriter{}
+	c.line("BEGIN:VCALENDAR")
+	c.line("PRODID:" + calendarProductID)
+	c.line("VERSION:2.0")
+	c.line("CALSCALE:GREGORIAN")
+	c.line("METHOD:REQUEST")
+	if location != time.UTC {
+		c.timezone(location, event.Start, event.End)
+	}
+
+	c.line("BEGIN:VEVENT")
+	c.line("UID:" + calendarText(event.UID))
+	c.line(fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+	c.line("DTSTAMP:" + createdAt.UTC().Format(calendarDateUTC))
+	c.line("DTSTART" + calendarTime(event.Start, location))
+	c.line("DTEND" + calendarTime(event.End, location))
+	c.line("SUMMARY:" + calendarText(event.Summary))
+	if event.Description != "" {
+		c.line("DESCRIPTION:" + calendarText(event.Description))
+	}
+	if event.Location != "" {
+		c.line("LOCATION:" + calendarText(event.Location))
+	}
+	c.line("ORGANIZER" + calendarName(event.Organizer.Name) + ":mailto:" + event.Organizer.Email)
+	for _, attendee := range event.Attendees {
+		role := "REQ-PARTICIPANT"
+		if attendee.Optional {
+			role = "OPT-PARTICIPANT"
+		}
+		c.line("ATTENDEE" + calendarName(attendee.Name) + ";ROLE=" + role + ";PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:" + attendee.Email)
+	}
+	c.line("STATUS:CONFIRMED")
+	c.line("END:VEVENT")
+	c.line("END:VCALENDAR")
+
+	return c.b.String(), nil
+}
+
+type calendarWriter struct {
+	b strings.Builder
+}
+
+// line writes a CRLF terminated content line, folded into continuation lines that start with a space.
+// Folds never split a UTF-8 sequence.
+func (c *calendarWriter) line(s string) {
+	limit := calendarLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		c.b.WriteString(s[:cut])
+		c.b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = calendarLineOctets - 1
+	}
+	c.b.WriteString(s)
+	c.b.WriteString("\r\n")
+}
+
+// timezone writes a VTIMEZONE with every offset change of the location from the year before the event
+// to the year after it, or a single observance when the location keeps one offset.
+func (c *calendarWriter) timezone(location *time.Location, start, end time.Time) {
+	from := time.Date(start.In(location).Year()-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(end.In(location).Year()+2, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	c.line("BEGIN:VTIMEZONE")
+	c.line("TZID:" + location.String())
+
+	transitions := zoneTransitions(location, from, to)
+	if len(transitions) == 0 {
+		name, offset := start.In(location).Zone()
+		c.observance("STANDARD", time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC), offset, offset, name)
+	}
+	for _, at := range transitions {
+		_, before := at.Add(-time.Second).In(location).Zone()
+		name, after := at.In(location).Zone()
+
+		kind := "STANDARD"
+		if at.In(location).IsDST() {
+			kind = "DAYLIGHT"
+		}
+		// an observance starts at the local time of the offset it replaces
+		c.observance(kind, at.Add(time.Duration(before)*time.Second).UTC(), before, after, name)
+	}
+
+	c.line("END:VTIMEZONE")
+}
+
+func (c *calendarWriter) observance(kind string, start time.Time, from, to int, name string) {
+	c.line("BEGIN:" + kind)
+	c.line("DTSTART:" + start.Format(calendarDateLocal))
+	c.line("TZOFFSETFROM:" + calendarOffset(from))
+	c.line("TZOFFSETTO:" + calendarOffset(to))
+	c.line("TZNAME:" + calendarText(name))
+	c.line("END:" + kind)
+}
+
+// zoneTransitions returns the instants the location changes its offset between from and to. Offsets are compared
+// a day apart and a change is narrowed down to the second.
+func zoneTransitions(location *time.Location, from, to time.Time) []time.Time {
+	var transitions []time.Time
+
+	_, offset := from.In(location).Zone()
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		_, nextOffset := next.In(location).Zone()
+		if nextOffset == offset {
+			continue
+		}
+
+		low, high := day.Unix(), next.Unix()
+		for high-low > 1 {
+			middle := low + (high-low)/2
+			if _, o := time.Unix(middle, 0).In(location).Zone(); o == offset {
+				low = middle
+			} else {
+				high = middle
+			}
+		}
+
+		transitions = append(transitions, time.Unix(high, 0))
+		offset = nextOffset
+	}
+
+	return transitions
+}
+
+// calendarTime formats a DTSTART or DTEND value with the TZID parameter, UTC times end in Z instead.
+func calendarTime(t time.Time, location *time.Location) string {
+	if location == time.UTC {
+		return ":" + t.UTC().Format(calendarDateUTC)
+	}
+	return ";TZID=" + location.String() + ":" + t.In(location).Format(calendarDateLocal)
+}
+
+// calendarOffset formats seconds east of UTC as +HHMM, with seconds only when the offset has them.
+func calendarOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+	return offset
+}
+
+var calendarTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+func calendarText(s string) string {
+	return calendarTextEscaper.Replace(s)
+}
+
+// calendarName returns the CN parameter, quoted since names often hold commas. Quotes cannot be escaped in
+// parameter values, so they are dropped.
+func calendarName(name string) string {
+	name = strings.NewReplacer(`"`, "", "\r", "", "\n", " ").Replace(name)
+	if name == "" {
+		return ""
+	}
+	return `;CN="` + name + `"`
+}
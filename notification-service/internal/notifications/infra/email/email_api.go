@@ -17,20 +17,34 @@ func NewEmailAPI(smtpClient *utils.SMTPClient) *MailAPI {
 }
 
 func (e *MailAPI) SendEmailViaSMTP(ctx context.Context, message *entity.EmailNotification) error {
-	attachments := make([]utils.MailAttachment, len(message.Attachments))
+	attachments := make([]utils.MailAttachment, 0, len(message.Attachments))
 	for _, attachment := range message.Attachments {
 		attachments = append(attachments, utils.MailAttachment{
 			Filename:    attachment.Filename,
 			Data:        attachment.Data,
 			ContentType: attachment.ContentType,
+			ContentID:   attachment.ContentID,
+			Inline:      attachment.Inline,
 		})
 	}
 
+	html, text := mailBodies(message)
+
+	var calendar string
+	if message.CalendarEvent != nil {
+		invite, err := calendarInvite(message.CalendarEvent, message.CreatedAt)
+		if err != nil {
+			return err
+		}
+		calendar = invite
+	}
+
 	msg := &utils.MailMessage{
 		To:          message.To,
 		Subject:     message.Subject,
-		Body:        message.Body,
-		ContentType: message.ContentType,
+		Text:        text,
+		HTML:        html,
+		Calendar:    calendar,
 		ReplyTo:     message.ReplyTo,
 		CC:          message.CC,
 		BCC:         message.BCC,
@@ -40,3 +54,22 @@ func (e *MailAPI) SendEmailViaSMTP(ctx context.Context, message *entity.EmailNot
 
 	return e.smtpClient.Send(msg)
 }
+
+// mailBodies returns the HTML and text parts, the text one is generated when only HTML is given.
+// Emails queued before html and text existed carry body and content_type.
+func mailBodies(message *entity.EmailNotification) (html string, text string) {
+	html, text = message.HTML, message.Text
+	if message.Body != "" {
+		if message.ContentType == "text/html" {
+			html = message.Body
+		} else {
+			text = message.Body
+		}
+	}
+
+	if text == "" && html != "" {
+		text = htmlToText(html)
+	}
+
+	return html, text
+}
@@ -0,0 +1,280 @@
+package email
+
+import (
+	"fmt"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// skippedElements hold no text a reader would see.
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Title: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+}
+
+// paragraphElements are set apart by a blank line, lineElements start on a line of their own.
+var (
+	paragraphElements = map[atom.Atom]bool{
+		atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+		atom.Table: true, atom.Dl: true, atom.Address: true, atom.Figure: true, atom.Form: true,
+	}
+	lineElements = map[atom.Atom]bool{
+		atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true, atom.Nav: true,
+		atom.Main: true, atom.Aside: true, atom.Tr: true, atom.Dt: true, atom.Dd: true, atom.Figcaption: true,
+		atom.Caption: true, atom.Center: true,
+	}
+)
+
+// htmlToText renders an HTML body as the plain text alternative of an email. Blocks and list items go on lines
+// of their own, links keep their URL in parentheses, images their alt text and preformatted text its layout.
+func htmlToText(source string) string {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return source
+	}
+
+	w := &textWriter{}
+	w.walk(doc)
+	return w.String()
+}
+
+type textWriter struct {
+	b strings.Builder
+	// prefix starts every line, it indents list items and quotes blockquotes
+	prefix string
+	// newlines and space are written before the next text, so blocks never leave trailing blank lines
+	newlines int
+	space    bool
+	pre      bool
+	// marker is set right after a list marker, a block opening the item stays on its line
+	marker bool
+	lists  []*textList
+}
+
+type textList struct {
+	ordered bool
+	next    int
+}
+
+func (w *textWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+	case html.ElementNode:
+		w.element(n)
+	default:
+		w.children(n)
+	}
+}
+
+func (w *textWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+func (w *textWriter) element(n *html.Node) {
+	switch {
+	case skippedElements[n.DataAtom]:
+	case n.DataAtom == atom.Br:
+		w.lineBreak()
+	case n.DataAtom == atom.Hr:
+		w.block(2)
+		w.write("---")
+		w.block(2)
+	case n.DataAtom == atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			w.text(alt)
+		}
+	case n.DataAtom == atom.A:
+		w.link(n)
+	case n.DataAtom == atom.Ul || n.DataAtom == atom.Ol:
+		w.list(n)
+	case n.DataAtom == atom.Li:
+		w.item(n)
+	case n.DataAtom == atom.Pre:
+		w.block(2)
+		w.pre = true
+		w.children(n)
+		w.pre = false
+		w.block(2)
+	case n.DataAtom == atom.Blockquote:
+		w.block(2)
+		prefix := w.prefix
+		w.prefix += "> "
+		w.children(n)
+		w.prefix = prefix
+		w.block(2)
+	case n.DataAtom == atom.Td || n.DataAtom == atom.Th:
+		if hasPreviousCell(n) {
+			w.write(" |")
+			w.space = true
+		}
+		w.children(n)
+	case paragraphElements[n.DataAtom]:
+		w.block(2)
+		w.children(n)
+		w.block(2)
+	case lineElements[n.DataAtom]:
+		w.block(1)
+		w.children(n)
+		w.block(1)
+	default:
+		w.children(n)
+	}
+}
+
+// link writes the link text followed by its URL, unless the text already is the URL.
+func (w *textWriter) link(n *html.Node) {
+	start := w.b.Len()
+	w.children(n)
+
+	href := strings.TrimSpace(attr(n, "href"))
+	lower := strings.ToLower(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(lower, "javascript:") {
+		return
+	}
+
+	text := strings.TrimSpace(w.b.String()[start:])
+	switch text {
+	case "":
+		w.text(strings.TrimPrefix(href, "mailto:"))
+	case href, strings.TrimPrefix(href, "mailto:"):
+	default:
+		w.space = true
+		w.write("(" + href + ")")
+	}
+}
+
+func (w *textWriter) list(n *html.Node) {
+	gap := 2
+	if len(w.lists) > 0 {
+		gap = 1
+	}
+
+	start := 1
+	if value, err := strconv.Atoi(attr(n, "start")); err == nil {
+		start = value
+	}
+
+	w.block(gap)
+	w.lists = append(w.lists, &textList{ordered: n.DataAtom == atom.Ol, next: start})
+	w.children(n)
+	w.lists = w.lists[:len(w.lists)-1]
+	w.block(gap)
+}
+
+// item writes the marker of a list item, its further lines are indented to the text after the marker.
+func (w *textWriter) item(n *html.Node) {
+	marker := "- "
+	if len(w.lists) > 0 && w.lists[len(w.lists)-1].ordered {
+		list := w.lists[len(w.lists)-1]
+		marker = fmt.Sprintf("%d. ", list.next)
+		list.next++
+	}
+
+	w.block(1)
+	w.write(marker)
+	w.marker = true
+
+	prefix := w.prefix
+	w.prefix += strings.Repeat(" ", len(marker))
+	w.children(n)
+	w.prefix = prefix
+	w.block(1)
+}
+
+func (w *textWriter) text(s string) {
+	if w.pre {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				w.newlines++
+			}
+			if line != "" {
+				w.write(line)
+			}
+		}
+		return
+	}
+
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if s != "" {
+			w.space = true
+		}
+		return
+	}
+
+	if first, _ := utf8.DecodeRuneInString(s); unicode.IsSpace(first) {
+		w.space = true
+	}
+	for i, word := range words {
+		if i > 0 {
+			w.space = true
+		}
+		w.write(word)
+	}
+	if last, _ := utf8.DecodeLastRuneInString(s); unicode.IsSpace(last) {
+		w.space = true
+	}
+}
+
+// write puts out the pending line breaks or space and then s as it is.
+func (w *textWriter) write(s string) {
+	switch {
+	case w.b.Len() == 0:
+		w.b.WriteString(w.prefix)
+	case w.newlines > 0:
+		w.b.WriteString(strings.Repeat("\n", w.newlines))
+		w.b.WriteString(w.prefix)
+	case w.space && !w.marker:
+		w.b.WriteByte(' ')
+	}
+
+	w.newlines, w.space, w.marker = 0, false, false
+	w.b.WriteString(s)
+}
+
+// block asks for at least n line breaks before the next text.
+func (w *textWriter) block(n int) {
+	if w.marker {
+		return
+	}
+	w.newlines = max(w.newlines, n)
+	w.space = false
+}
+
+func (w *textWriter) lineBreak() {
+	w.newlines = min(w.newlines+1, 2)
+	w.space = false
+	w.marker = false
+}
+
+func (w *textWriter) String() string {
+	lines := strings.Split(w.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func hasPreviousCell(n *html.Node) bool {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode && (s.DataAtom == atom.Td || s.DataAtom == atom.Th) {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package email
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "paragraphs and line breaks",
+			html: "<p>Hello,</p><p>first line<br>second   line</p>",
+			want: "Hello,\n\nfirst line\nsecond line",
+		},
+		{
+			name: "skipped elements",
+			html: "<html><head><title>Title</title><style>p{}</style></head><body><script>alert(1)</script><p>Body</p></body></html>",
+			want: "Body",
+		},
+		{
+			name: "link with text",
+			html: `<p>Read the <a href="https://example.com/docs">docs</a> first.</p>`,
+			want: "Read the docs (https://example.com/docs) first.",
+		},
+		{
+			name: "link whose text is the href",
+			html: `<a href="https://example.com">https://example.com</a>`,
+			want: "https://example.com",
+		},
+		{
+			name: "link without text",
+			html: `<a href="https://example.com"><img src="logo.png"></a>`,
+			want: "https://example.com",
+		},
+		{
+			name: "mailto link",
+			html: `<p>Write to <a href="mailto:support@example.com">support@example.com</a> or <a href="mailto:sales@example.com">sales</a>.</p>`,
+			want: "Write to support@example.com or sales (mailto:sales@example.com).",
+		},
+		{
+			name: "anchor and javascript links",
+			html: `<a href="#top">Top</a> <a href="javascript:void(0)">Click</a>`,
+			want: "Top Click",
+		},
+		{
+			name: "image alt",
+			html: `<p>Logo: <img src="logo.png" alt="ACME"></p>`,
+			want: "Logo: ACME",
+		},
+		{
+			name: "unordered list",
+			html: "<p>Items:</p><ul><li>one</li><li>two</li></ul><p>After</p>",
+			want: "Items:\n\n- one\n- two\n\nAfter",
+		},
+		{
+			name: "ordered list with start",
+			html: `<ol start="3"><li>three</li><li>four</li></ol>`,
+			want: "3. three\n4. four",
+		},
+		{
+			name: "nested lists",
+			html: `<ul><li>fruit<ol><li>apple</li><li>pear</li></ol></li><li>vegetables<ul><li>leek</li></ul></li></ul>`,
+			want: "- fruit\n  1. apple\n  2. pear\n- vegetables\n  - leek",
+		},
+		{
+			name: "item opening with a paragraph",
+			html: `<ol><li><p>first</p><p>more</p></li></ol>`,
+			want: "1. first\n\n   more",
+		},
+		{
+			name: "preformatted text",
+			html: "<p>Run:</p><pre>make build\n  make   test</pre><p>Done</p>",
+			want: "Run:\n\nmake build\n  make   test\n\nDone",
+		},
+		{
+			name: "blockquote",
+			html: "<p>You wrote:</p><blockquote><p>first</p><p>second</p></blockquote><p>Thanks</p>",
+			want: "You wrote:\n\n> first\n\n> second\n\nThanks",
+		},
+		{
+			name: "nested blockquote",
+			html: "<blockquote>outer<blockquote>inner</blockquote></blockquote>",
+			want: "> outer\n\n> > inner",
+		},
+		{
+			name: "table",
+			html: "<table><tr><th>Name</th><th>Qty</th></tr><tr><td>Apple</td><td>2</td></tr></table><p>Total</p>",
+			want: "Name | Qty\nApple | 2\n\nTotal",
+		},
+		{
+			name: "horizontal rule",
+			html: "<p>above</p><hr><p>below</p>",
+			want: "above\n\n---\n\nbelow",
+		},
+		{
+			name: "entities and inline elements",
+			html: "<p>Fish &amp; <b>chips</b>&nbsp;today</p>",
+			want: "Fish & chips today",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToText(tt.html); got != tt.want {
+				t.Errorf("htmlToText(%q)\n got: %q\nwant: %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/goccy/go-json"
+	"notification-service-api/internal/notifications/domain"
+)
+
+const StatusEventsChannel = "notifications:status"
+
+// RedisStatusEvents fans status changes out over Redis pub/sub, so every API instance sees them
+// whichever instance consumed the message.
+type RedisStatusEvents struct {
+	client *redis.Client
+}
+
+func NewRedisStatusEvents(client *redis.Client) *RedisStatusEvents {
+	return &RedisStatusEvents{client: client}
+}
+
+func (e *RedisStatusEvents) Publish(ctx context.Context, event domain.StatusEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return e.client.Publish(ctx, StatusEventsChannel, payload).Err()
+}
+
+// Subscribe calls handle for every event until ctx is done, malformed messages are skipped.
+func (e *RedisStatusEvents) Subscribe(ctx context.Context, handle func(event domain.StatusEvent)) error {
+	sub := e.client.Subscribe(ctx, StatusEventsChannel)
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			var event domain.StatusEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				continue
+			}
+
+			handle(event)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"go.uber.org/zap"
 	"notification-service-api/internal/notifications/domain"
 	"notification-service-api/pkg/utils"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,28 +16,62 @@ type seriesKey struct {
 	Channel string
 	Type    string
 	Env     string
+	Tags    string
 }
 
 type InfluxMonitoring struct {
 	influxClient *utils.InfluxDB
 	logger       *zap.Logger
-	totals       sync.Map
+	totals       *sync.Map
 	env          string
+	tags         map[string]string
 }
 
 func NewInfluxMonitoring(influxClient *utils.InfluxDB, logger *zap.Logger, env string) *InfluxMonitoring {
 	return &InfluxMonitoring{
 		influxClient: influxClient,
 		logger:       logger,
+		totals:       &sync.Map{},
 		env:          env,
 	}
 }
 
+// With returns a monitoring sharing the client and the totals, its series are told apart by the tags.
+func (i *InfluxMonitoring) With(tags map[string]string) domain.NotificationMonitoring {
+	merged := make(map[string]string, len(i.tags)+len(tags))
+	for k, v := range i.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+
+	clone := *i
+	clone.tags = merged
+	return &clone
+}
+
+// tagsKey is a stable form of the extra tags for the series key.
+func (i *InfluxMonitoring) tagsKey() string {
+	keys := make([]string, 0, len(i.tags))
+	for k := range i.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "=" + i.tags[k] + ",")
+	}
+	return b.String()
+}
+
 func (i *InfluxMonitoring) Send(channel domain.Channel, notificationType domain.NotificationType, delta int64) {
 	key := seriesKey{
 		Channel: channel.String(),
 		Type:    notificationType.String(),
 		Env:     i.env,
+		Tags:    i.tagsKey(),
 	}
 
 	ctrAny, _ := i.totals.LoadOrStore(key, new(atomic.Int64))
@@ -48,6 +84,9 @@ func (i *InfluxMonitoring) Send(channel domain.Channel, notificationType domain.
 		"type":    key.Type,
 		"env":     key.Env,
 	}
+	for k, v := range i.tags {
+		tags[k] = v
+	}
 	fields := map[string]interface{}{
 		"total": val,
 	}
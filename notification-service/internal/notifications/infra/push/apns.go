@@ -0,0 +1,191 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	neturl "net/url"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apnsBaseURL = "https://api.push.apple.com"
+	// apnsTokenTTL stays below the hour after which APNs rejects a provider token
+	apnsTokenTTL = 50 * time.Minute
+)
+
+// APNsProvider sends through the APNs HTTP/2 API with token based (.p8 key) authentication.
+type APNsProvider struct {
+	baseURL string
+	keyID   string
+	teamID  string
+	topic   string
+	key     *ecdsa.PrivateKey
+	client  *http.Client
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+type APNsOptions struct {
+	// BaseURL can point at the sandbox (https://api.sandbox.push.apple.com) or a local stand-in server
+	BaseURL string
+	KeyID   string
+	TeamID  string
+	// Topic is the bundle id of the app
+	Topic   string
+	Key     *ecdsa.PrivateKey
+	Timeout time.Duration
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type apnsAps struct {
+	Alert apnsAlert `json:"alert"`
+	Badge *int      `json:"badge,omitempty"`
+	Sound string    `json:"sound,omitempty"`
+}
+
+type apnsErrorResponse struct {
+	Reason string `json:"reason"`
+}
+
+// LoadAPNsKey reads the PKCS#8 .p8 signing key downloaded from the Apple developer account.
+func LoadAPNsKey(path string) (*ecdsa.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse key: %w", err)
+	}
+
+	return key, nil
+}
+
+func NewAPNsProvider(options APNsOptions) (*APNsProvider, error) {
+	if options.KeyID == "" || options.TeamID == "" || options.Topic == "" || options.Key == nil {
+		return nil, fmt.Errorf("apns key, key id, team id and topic are required")
+	}
+
+	baseURL := strings.TrimRight(options.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = apnsBaseURL
+	}
+
+	return &APNsProvider{
+		baseURL: baseURL,
+		keyID:   options.KeyID,
+		teamID:  options.TeamID,
+		topic:   options.Topic,
+		key:     options.Key,
+		// the default transport negotiates HTTP/2 over TLS, which APNs requires
+		client: &http.Client{Timeout: options.Timeout},
+	}, nil
+}
+
+func (p *APNsProvider) Send(ctx context.Context, token string, message *entity.PushNotification) error {
+	providerToken, err := p.providerToken()
+	if err != nil {
+		return fmt.Errorf("apns provider token: %w", err)
+	}
+
+	payload := make(map[string]any, len(message.Data)+1)
+	for key, value := range message.Data {
+		payload[key] = value
+	}
+	payload["aps"] = apnsAps{
+		Alert: apnsAlert{Title: message.Title, Body: message.Body},
+		Badge: message.Badge,
+		Sound: message.Sound,
+	}
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/3/device/%s", p.baseURL, neturl.PathEscape(token))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("apns-id", message.NotificationID.String())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("apns request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var res apnsErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+
+	switch {
+	case resp.StatusCode == http.StatusGone,
+		res.Reason == "BadDeviceToken",
+		res.Reason == "Unregistered",
+		res.Reason == "DeviceTokenNotForTopic":
+		return fmt.Errorf("%w: apns %d %s", domain.ErrInvalidDeviceToken, resp.StatusCode, res.Reason)
+	case res.Reason == "ExpiredProviderToken":
+		p.resetProviderToken()
+	}
+
+	return fmt.Errorf("apns error: status %d, %s", resp.StatusCode, res.Reason)
+}
+
+// providerToken reuses the signed JWT, APNs throttles providers that sign a new one for every request.
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Since(p.issuedAt) < apnsTokenTTL {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.keyID
+
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+
+	p.token = signed
+	p.issuedAt = now
+	return p.token, nil
+}
+
+func (p *APNsProvider) resetProviderToken() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+}
@@ -0,0 +1,174 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"net/http"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"strings"
+	"time"
+)
+
+const fcmBaseURL = "https://fcm.googleapis.com"
+
+// FCMProvider sends through the Firebase Cloud Messaging HTTP v1 API.
+type FCMProvider struct {
+	baseURL     string
+	projectID   string
+	staticToken string
+	tokens      *fcmTokenSource
+	client      *http.Client
+}
+
+type FCMOptions struct {
+	// BaseURL and TokenURL can point at a local stand-in server
+	BaseURL  string
+	TokenURL string
+	// ProjectID defaults to the one of the credentials
+	ProjectID string
+	// AccessToken is used as is instead of credentials, for stand-in servers
+	AccessToken string
+	Credentials *FCMCredentials
+	Timeout     time.Duration
+}
+
+type fcmSendRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      *fcmAndroidConfig `json:"android,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroidConfig struct {
+	Notification fcmAndroidNotification `json:"notification"`
+}
+
+type fcmAndroidNotification struct {
+	Sound             string `json:"sound,omitempty"`
+	NotificationCount *int   `json:"notification_count,omitempty"`
+}
+
+type fcmErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func NewFCMProvider(options FCMOptions) (*FCMProvider, error) {
+	client := &http.Client{Timeout: options.Timeout}
+
+	provider := &FCMProvider{
+		baseURL:     strings.TrimRight(options.BaseURL, "/"),
+		projectID:   options.ProjectID,
+		staticToken: options.AccessToken,
+		client:      client,
+	}
+	if provider.baseURL == "" {
+		provider.baseURL = fcmBaseURL
+	}
+
+	if options.Credentials != nil {
+		if provider.projectID == "" {
+			provider.projectID = options.Credentials.ProjectID
+		}
+
+		tokenURL := options.TokenURL
+		if tokenURL == "" {
+			tokenURL = options.Credentials.TokenURI
+		}
+		if tokenURL == "" {
+			tokenURL = googleTokenURL
+		}
+		provider.tokens = &fcmTokenSource{credentials: options.Credentials, tokenURL: tokenURL, client: client}
+	}
+
+	if provider.projectID == "" {
+		return nil, fmt.Errorf("fcm project id is not set")
+	}
+	if provider.tokens == nil && provider.staticToken == "" {
+		return nil, fmt.Errorf("fcm credentials or access token are required")
+	}
+
+	return provider, nil
+}
+
+func (p *FCMProvider) Send(ctx context.Context, token string, message *entity.PushNotification) error {
+	accessToken := p.staticToken
+	if p.tokens != nil {
+		var err error
+		if accessToken, err = p.tokens.Token(ctx); err != nil {
+			return fmt.Errorf("fcm access token: %w", err)
+		}
+	}
+
+	fcmMsg := fcmMessage{
+		Token:        token,
+		Notification: fcmNotification{Title: message.Title, Body: message.Body},
+		Data:         message.Data,
+	}
+	if message.Sound != "" || message.Badge != nil {
+		fcmMsg.Android = &fcmAndroidConfig{Notification: fcmAndroidNotification{Sound: message.Sound, NotificationCount: message.Badge}}
+	}
+
+	reqBody, err := json.Marshal(fcmSendRequest{Message: fcmMsg})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.baseURL, p.projectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fcm request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var res fcmErrorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&res)
+
+	if isInvalidFCMToken(res) {
+		return fmt.Errorf("%w: fcm %s, %s", domain.ErrInvalidDeviceToken, res.Error.Status, res.Error.Message)
+	}
+
+	return fmt.Errorf("fcm error: status %d, %s %s", resp.StatusCode, res.Error.Status, res.Error.Message)
+}
+
+// isInvalidFCMToken reports UNREGISTERED tokens and INVALID_ARGUMENT errors caused by a malformed token,
+// other INVALID_ARGUMENT errors are about the payload and must not prune the device.
+func isInvalidFCMToken(res fcmErrorResponse) bool {
+	for _, detail := range res.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return true
+		}
+	}
+
+	return res.Error.Status == "INVALID_ARGUMENT" && strings.Contains(strings.ToLower(res.Error.Message), "registration token")
+}
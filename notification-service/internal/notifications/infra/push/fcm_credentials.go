@@ -0,0 +1,116 @@
+package push
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fcmScope           = "https://www.googleapis.com/auth/firebase.messaging"
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+	accessTokenRefresh = time.Minute
+)
+
+// FCMCredentials is the part of a Google service account key file needed to mint access tokens.
+type FCMCredentials struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	key *rsa.PrivateKey
+}
+
+func LoadFCMCredentials(path string) (*FCMCredentials, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %w", err)
+	}
+
+	var credentials FCMCredentials
+	if err := json.Unmarshal(raw, &credentials); err != nil {
+		return nil, fmt.Errorf("parse credentials: %w", err)
+	}
+
+	credentials.key, err = jwt.ParseRSAPrivateKeyFromPEM([]byte(credentials.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	return &credentials, nil
+}
+
+// fcmTokenSource exchanges a self-signed service account JWT for an OAuth2 access token and caches it until shortly before expiry.
+type fcmTokenSource struct {
+	credentials *FCMCredentials
+	tokenURL    string
+	client      *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Error       string `json:"error,omitempty"`
+}
+
+func (s *fcmTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expiresAt.Add(-accessTokenRefresh)) {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.credentials.ClientEmail,
+		"scope": fcmScope,
+		"aud":   s.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(s.credentials.key)
+	if err != nil {
+		return "", fmt.Errorf("sign assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var res accessTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || res.AccessToken == "" {
+		return "", fmt.Errorf("token request failed: status %d, %s", resp.StatusCode, res.Error)
+	}
+
+	s.token = res.AccessToken
+	s.expiresAt = now.Add(time.Duration(res.ExpiresIn) * time.Second)
+	return s.token, nil
+}
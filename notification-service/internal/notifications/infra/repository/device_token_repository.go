@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"time"
+)
+
+type DeviceTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewDeviceTokenRepository(db *gorm.DB) *DeviceTokenRepository {
+	return &DeviceTokenRepository{
+		db: db,
+	}
+}
+
+// Upsert registers the token or moves an already known one to the new user and platform.
+func (r *DeviceTokenRepository) Upsert(ctx context.Context, device *entity.DeviceToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(device).Error
+}
+
+func (r *DeviceTokenRepository) FindByToken(ctx context.Context, owner string, token string) (*entity.DeviceToken, error) {
+	var device entity.DeviceToken
+	if err := r.db.WithContext(ctx).First(&device, "owner = ? AND token = ?", owner, token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrDeviceTokenNotFound
+		}
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+func (r *DeviceTokenRepository) ListByUser(ctx context.Context, owner string, userID string) ([]entity.DeviceToken, error) {
+	var devices []entity.DeviceToken
+	if err := r.db.WithContext(ctx).Where("owner = ? AND user_id = ?", owner, userID).Order("created_at").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (r *DeviceTokenRepository) Delete(ctx context.Context, owner string, token string) error {
+	res := r.db.WithContext(ctx).Where("owner = ? AND token = ?", owner, token).Delete(&entity.DeviceToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrDeviceTokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteTokens removes the tokens for every owner, a token rejected by the provider is dead for all of them.
+func (r *DeviceTokenRepository) DeleteTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Where("token IN ?", tokens).Delete(&entity.DeviceToken{}).Error
+}
+
+func (r *DeviceTokenRepository) TouchLastUsed(ctx context.Context, tokens []string, at time.Time) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Model(&entity.DeviceToken{}).Where("token IN ?", tokens).Update("last_used_at", at).Error
+}
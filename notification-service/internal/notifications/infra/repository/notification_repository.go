@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"time"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, notification *entity.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *NotificationRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.Status, lastError *string) error {
+	updates := map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	}
+
+	switch status {
+	case domain.StatusSending:
+		updates["attempts"] = gorm.Expr("attempts + 1")
+	case domain.StatusSent:
+		updates["sent_at"] = time.Now()
+		updates["last_error"] = nil
+	}
+
+	if lastError != nil {
+		updates["last_error"] = *lastError
+	}
+
+	res := r.db.WithContext(ctx).Model(&entity.Notification{}).Where("id = ?", id).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+func (r *NotificationRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Notification, error) {
+	var notification entity.Notification
+	if err := r.db.WithContext(ctx).First(&notification, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotificationNotFound
+		}
+		return nil, err
+	}
+
+	return &notification, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"time"
+)
+
+type TelegramBindingRepository struct {
+	db *gorm.DB
+}
+
+func NewTelegramBindingRepository(db *gorm.DB) *TelegramBindingRepository {
+	return &TelegramBindingRepository{
+		db: db,
+	}
+}
+
+// Upsert binds the user to the chat of the bot, a user that links again moves to the new chat.
+func (r *TelegramBindingRepository) Upsert(ctx context.Context, binding *entity.TelegramBinding) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "user_id"}, {Name: "bot"}},
+		DoUpdates: clause.AssignmentColumns([]string{"chat_id", "username", "updated_at"}),
+	}).Create(binding).Error
+}
+
+func (r *TelegramBindingRepository) FindByUser(ctx context.Context, owner string, userID string, bot string) (*entity.TelegramBinding, error) {
+	var binding entity.TelegramBinding
+	if err := r.db.WithContext(ctx).First(&binding, "owner = ? AND user_id = ? AND bot = ?", owner, userID, bot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTelegramUserNotLinked
+		}
+		return nil, err
+	}
+
+	return &binding, nil
+}
+
+// DeleteByChat removes every binding of the chat with the bot and returns how many there were.
+func (r *TelegramBindingRepository) DeleteByChat(ctx context.Context, bot string, chatID int64) (int64, error) {
+	res := r.db.WithContext(ctx).Where("bot = ? AND chat_id = ?", bot, chatID).Delete(&entity.TelegramBinding{})
+	return res.RowsAffected, res.Error
+}
+
+// MoveChat points every binding of the chat with the bot to the new chat id.
+func (r *TelegramBindingRepository) MoveChat(ctx context.Context, bot string, chatID int64, newChatID int64) error {
+	return r.db.WithContext(ctx).Model(&entity.TelegramBinding{}).
+		Where("bot = ? AND chat_id = ?", bot, chatID).
+		Updates(map[string]any{"chat_id": newChatID, "updated_at": time.Now()}).Error
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"notification-service-api/internal/notifications/domain/entity"
+)
+
+type TelegramMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewTelegramMessageRepository(db *gorm.DB) *TelegramMessageRepository {
+	return &TelegramMessageRepository{
+		db: db,
+	}
+}
+
+// Save stores the delivered messages, a message redelivered after a lost ack replaces the one at its position.
+func (r *TelegramMessageRepository) Save(ctx context.Context, messages []entity.TelegramMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "notification_id"}, {Name: "position"}},
+		DoUpdates: clause.AssignmentColumns([]string{"chat_id", "message_id", "type"}),
+	}).Create(&messages).Error
+}
+
+func (r *TelegramMessageRepository) ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]entity.TelegramMessage, error) {
+	var messages []entity.TelegramMessage
+	if err := r.db.WithContext(ctx).Where("notification_id = ?", notificationID).Order("position").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *TelegramMessageRepository) DeleteByNotification(ctx context.Context, notificationID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("notification_id = ?", notificationID).Delete(&entity.TelegramMessage{}).Error
+}
@@ -0,0 +1,83 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io"
+	"net/http"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/pkg/utils"
+	"time"
+)
+
+// HTTPProvider posts messages as JSON to a gateway URL, which covers most SMS providers and in-house gateways.
+type HTTPProvider struct {
+	url    string
+	token  string
+	sender string
+	client *http.Client
+}
+
+type sendSMSRequest struct {
+	To      string `json:"to"`
+	From    string `json:"from,omitempty"`
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
+
+func NewHTTPProvider(url string, token string, sender string, timeout time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		url:    url,
+		token:  token,
+		sender: sender,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+func (p *HTTPProvider) SendSMS(ctx context.Context, message *entity.SMSNotification) error {
+	from := message.Sender
+	if from == "" {
+		from = p.sender
+	}
+
+	reqBody, err := json.Marshal(sendSMSRequest{
+		To:      message.To,
+		From:    from,
+		Message: message.Message,
+		ID:      message.NotificationID.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms provider request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("sms provider error: status %d, %s", resp.StatusCode, string(body))
+		// a rejected number or message fails the same way on every retry, throttling and server errors pass
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return utils.Permanent(err)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/goccy/go-json"
+	"notification-service-api/internal/notifications/domain"
+	"time"
+)
+
+// RedisLinkStore keeps pending /start links until they are used or expire.
+type RedisLinkStore struct {
+	client *redis.Client
+}
+
+func NewRedisLinkStore(client *redis.Client) *RedisLinkStore {
+	return &RedisLinkStore{client: client}
+}
+
+func (s *RedisLinkStore) Create(ctx context.Context, token string, link domain.TelegramLink, ttl time.Duration) error {
+	payload, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(ctx, linkKey(token), payload, ttl).Err()
+}
+
+// Take returns the link and removes it, a link binds one chat only.
+func (s *RedisLinkStore) Take(ctx context.Context, token string) (*domain.TelegramLink, error) {
+	payload, err := s.client.GetDel(ctx, linkKey(token)).Bytes()
+	if err == redis.Nil {
+		return nil, domain.ErrTelegramLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var link domain.TelegramLink
+	if err := json.Unmarshal(payload, &link); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func linkKey(token string) string {
+	return "telegram:link:" + token
+}
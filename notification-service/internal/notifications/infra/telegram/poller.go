@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	pollTimeout = 25 * time.Second
+	// pollerLockTTL outlives one poll, a replica that dies releases the lock within it
+	pollerLockTTL = 60 * time.Second
+	// pollerUpdateAttempts is how often an update is handled before it is skipped
+	pollerUpdateAttempts = 3
+)
+
+// renewLock extends the lock only while this replica still holds it.
+var renewLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Poller receives updates with getUpdates. Only the replica holding the Redis lock polls,
+// the offset is kept in Redis so another replica taking over continues where it stopped. Every bot has its own poller.
+type Poller struct {
+	api       *TGApi
+	redis     *redis.Client
+	logger    *zap.Logger
+	instance  string
+	lockKey   string
+	offsetKey string
+
+	retryDelay   time.Duration
+	failedUpdate int64
+	failures     int
+}
+
+func NewPoller(api *TGApi, client *redis.Client, logger *zap.Logger) *Poller {
+	return &Poller{
+		api:        api,
+		redis:      client,
+		logger:     logger,
+		instance:   uuid.NewString(),
+		lockKey:    "telegram:" + api.name + ":poller:lock",
+		offsetKey:  "telegram:" + api.name + ":poller:offset",
+		retryDelay: 5 * time.Second,
+	}
+}
+
+// Run polls until ctx is done. A failing update is handled again after a pause, and logged and skipped
+// after pollerUpdateAttempts, so it cannot stall the updates after it.
+func (p *Poller) Run(ctx context.Context, handle func(ctx context.Context, update []byte) error) {
+	if err := p.api.DeleteWebhook(ctx); err != nil {
+		p.logger.Error("failed to delete telegram webhook before polling", zap.Error(err))
+	}
+
+	for ctx.Err() == nil {
+		locked, err := p.lock(ctx)
+		if err != nil {
+			p.logger.Error("failed to take telegram poller lock", zap.Error(err))
+		}
+		if !locked {
+			p.sleep(ctx, pollTimeout)
+			continue
+		}
+
+		if err := p.poll(ctx, handle); err != nil {
+			p.logger.Error("failed to poll telegram updates", zap.Error(err))
+			p.sleep(ctx, p.retryDelay)
+		}
+	}
+}
+
+func (p *Poller) lock(ctx context.Context) (bool, error) {
+	renewed, err := renewLock.Run(ctx, p.redis, []string{p.lockKey}, p.instance, pollerLockTTL.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	if renewed == 1 {
+		return true, nil
+	}
+
+	return p.redis.SetNX(ctx, p.lockKey, p.instance, pollerLockTTL).Result()
+}
+
+func (p *Poller) poll(ctx context.Context, handle func(ctx context.Context, update []byte) error) error {
+	offset, err := p.redis.Get(ctx, p.offsetKey).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	updates, err := p.api.GetUpdates(ctx, offset, pollTimeout)
+	if err != nil {
+		return err
+	}
+
+	for _, raw := range updates {
+		var head struct {
+			UpdateID int64 `json:"update_id"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			p.logger.Error("failed to decode telegram update", zap.Error(err))
+			continue
+		}
+
+		if err := handle(ctx, raw); err != nil {
+			// the offset stays, so the next poll gets the update again
+			if p.retry(head.UpdateID) {
+				return fmt.Errorf("handle telegram update %d: %w", head.UpdateID, err)
+			}
+			p.logger.Error("failed to handle telegram update, skipping it", zap.Int64("update_id", head.UpdateID), zap.Error(err))
+		}
+		p.failures = 0
+
+		offset = head.UpdateID + 1
+		if err := p.redis.Set(ctx, p.offsetKey, offset, 0).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retry counts a failure of the update and reports whether it gets another attempt.
+func (p *Poller) retry(updateID int64) bool {
+	if updateID != p.failedUpdate {
+		p.failedUpdate = updateID
+		p.failures = 0
+	}
+	p.failures++
+
+	return p.failures < pollerUpdateAttempts
+}
+
+func (p *Poller) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,287 @@
+package telegram
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http/httptest"
+	"notification-service-api/internal/notifications/infra/telegram/telegramtest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers the few commands the poller sends, GET, SET with NX and the lock renewal script, from memory.
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	values   map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{listener: listener, values: make(map[string]string)}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+
+	return r
+}
+
+func (r *fakeRedis) client(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: r.listener.Addr().String()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func (r *fakeRedis) get(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[key]
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, r.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (r *fakeRedis) exec(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		value, ok := r.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		nx := false
+		for _, arg := range args[3:] {
+			nx = nx || strings.EqualFold(arg, "NX")
+		}
+		if _, ok := r.values[args[1]]; ok && nx {
+			return "$-1\r\n"
+		}
+		r.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "EVALSHA":
+		return "-NOSCRIPT No matching script\r\n"
+	case "EVAL":
+		// renewLock: EVAL script 1 key instance ttl
+		if r.values[args[3]] == args[4] {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	default:
+		return "+OK\r\n"
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args = append(args, string(arg[:size]))
+	}
+
+	return args, nil
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPollerHandlesUpdatesAndStoresOffset(t *testing.T) {
+	fake := telegramtest.NewServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store := newFakeRedis(t)
+
+	if _, err := fake.PushMessage(42, "alice", "/start one"); err != nil {
+		t.Fatal(err)
+	}
+	last, err := fake.PushMessage(43, "bob", "/start two")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var texts []string
+	handle := func(ctx context.Context, update []byte) error {
+		var decoded struct {
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(update, &decoded); err != nil {
+			return err
+		}
+		mu.Lock()
+		texts = append(texts, decoded.Message.Text)
+		mu.Unlock()
+		return nil
+	}
+
+	api := NewTGApiClient("default", "1:secret", server.URL, 5*time.Second, nil)
+	done := make(chan struct{})
+	go func() {
+		NewPoller(api, store.client(t), zap.NewNop()).Run(ctx, handle)
+		close(done)
+	}()
+
+	want := strconv.FormatInt(last+1, 10)
+	waitFor(t, func() bool { return store.get("telegram:default:poller:offset") == want })
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(texts) != 2 || texts[0] != "/start one" || texts[1] != "/start two" {
+		t.Fatalf("unexpected updates %v", texts)
+	}
+	if calls := fake.Calls("deleteWebhook"); len(calls) != 1 {
+		t.Fatalf("webhook not deleted before polling, calls %v", calls)
+	}
+}
+
+func TestPollerResumesFromStoredOffset(t *testing.T) {
+	fake := telegramtest.NewServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store := newFakeRedis(t)
+
+	first, err := fake.PushMessage(42, "alice", "/start handled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.PushMessage(42, "alice", "/start pending"); err != nil {
+		t.Fatal(err)
+	}
+	// another replica handled the first update before it stopped
+	store.values["telegram:default:poller:offset"] = strconv.FormatInt(first+1, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var handled []string
+	api := NewTGApiClient("default", "1:secret", server.URL, 5*time.Second, nil)
+	NewPoller(api, store.client(t), zap.NewNop()).Run(ctx, func(ctx context.Context, update []byte) error {
+		handled = append(handled, string(update))
+		cancel()
+		return nil
+	})
+
+	if len(handled) != 1 || !strings.Contains(handled[0], "/start pending") {
+		t.Fatalf("unexpected updates %v", handled)
+	}
+}
+
+func TestPollerRetriesFailedUpdate(t *testing.T) {
+	fake := telegramtest.NewServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store := newFakeRedis(t)
+
+	last, err := fake.PushMessage(42, "alice", "/start flaky")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	attempts := 0
+	handle := func(ctx context.Context, update []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("attempt %d failed", attempts)
+		}
+		return nil
+	}
+
+	api := NewTGApiClient("default", "1:secret", server.URL, 5*time.Second, nil)
+	poller := NewPoller(api, store.client(t), zap.NewNop())
+	poller.retryDelay = 10 * time.Millisecond
+	done := make(chan struct{})
+	go func() {
+		poller.Run(ctx, handle)
+		close(done)
+	}()
+
+	want := strconv.FormatInt(last+1, 10)
+	waitFor(t, func() bool { return store.get("telegram:default:poller:offset") == want })
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want the update handled until it succeeds", attempts)
+	}
+}
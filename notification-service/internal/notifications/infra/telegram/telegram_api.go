@@ -3,60 +3,146 @@ package telegram
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/goccy/go-json"
+	"io"
 	"net/http"
-	"os"
+	neturl "net/url"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"strings"
 	"time"
 )
 
 type TGApi struct {
-	token  string
-	client *http.Client
+	name    string
+	token   string
+	baseURL string
+	client  *http.Client
+	// pollClient has no timeout of its own, a long poll is bounded by its context
+	pollClient *http.Client
 }
 
 type sendMessageRequest struct {
-	ChatID    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode,omitempty"`
+	ChatID              string                `json:"chat_id"`
+	Text                string                `json:"text"`
+	ParseMode           string                `json:"parse_mode,omitempty"`
+	MessageThreadID     *int64                `json:"message_thread_id,omitempty"`
+	DisableNotification bool                  `json:"disable_notification,omitempty"`
+	ProtectContent      bool                  `json:"protect_content,omitempty"`
+	LinkPreviewOptions  *linkPreviewOptions   `json:"link_preview_options,omitempty"`
+	ReplyParameters     *replyParameters      `json:"reply_parameters,omitempty"`
+	ReplyMarkup         *inlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type linkPreviewOptions struct {
+	IsDisabled       bool   `json:"is_disabled,omitempty"`
+	URL              string `json:"url,omitempty"`
+	PreferSmallMedia bool   `json:"prefer_small_media,omitempty"`
+	PreferLargeMedia bool   `json:"prefer_large_media,omitempty"`
+	ShowAboveText    bool   `json:"show_above_text,omitempty"`
+}
+
+type replyParameters struct {
+	MessageID                int64 `json:"message_id"`
+	AllowSendingWithoutReply bool  `json:"allow_sending_without_reply,omitempty"`
+}
+
+type inlineKeyboardMarkup struct {
+	InlineKeyboard [][]inlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type inlineKeyboardButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
 }
 
 type sendMessageResponse struct {
-	Ok          bool            `json:"ok"`
-	Description string          `json:"description,omitempty"`
-	Result      json.RawMessage `json:"result,omitempty"`
+	Ok          bool                `json:"ok"`
+	ErrorCode   int                 `json:"error_code,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  *responseParameters `json:"parameters,omitempty"`
+	Result      json.RawMessage     `json:"result,omitempty"`
+}
+
+// message is the part of a sent Message the service keeps.
+type message struct {
+	MessageID int64 `json:"message_id"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+type responseParameters struct {
+	MigrateToChatID int64 `json:"migrate_to_chat_id,omitempty"`
+	RetryAfter      int   `json:"retry_after,omitempty"`
 }
 
-func NewTGApiClient() *TGApi {
+// NewTGApiClient returns the client of a bot, its token never leaves the request URL: errors name the bot instead.
+// baseURL is https://api.telegram.org or a local Bot API server, a nil proxy follows HTTPS_PROXY.
+func NewTGApiClient(name string, token string, baseURL string, timeout time.Duration, proxy *neturl.URL) *TGApi {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
 	return &TGApi{
-		token: os.Getenv("TELEGRAM_TOKEN"),
+		name:    name,
+		token:   token,
+		baseURL: strings.TrimRight(baseURL, "/"),
 		client: &http.Client{
-			Timeout: time.Second * 10,
+			Transport: transport,
+			// uploads of up to 50 MB, or 2000 MB to a local server, need more than a text message
+			Timeout: timeout,
 		},
+		pollClient: &http.Client{Transport: transport},
 	}
 }
 
-func (t *TGApi) SendMessage(ctx context.Context, to string, message string, parseMode string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.token)
+func (t *TGApi) SendMessage(ctx context.Context, notification *entity.TelegramNotification) (domain.TelegramSentMessage, error) {
+	reqBody, err := json.Marshal(newSendMessageRequest(notification))
+	if err != nil {
+		return domain.TelegramSentMessage{}, fmt.Errorf("marshal request: %w", err)
+	}
 
-	reqBody, err := json.Marshal(sendMessageRequest{
-		ChatID:    to,
-		Text:      message,
-		ParseMode: parseMode,
-	})
+	var sent message
+	if err := t.call(ctx, "sendMessage", bytes.NewBuffer(reqBody), "application/json", &sent); err != nil {
+		return domain.TelegramSentMessage{}, err
+	}
+
+	return domain.TelegramSentMessage{ChatID: sent.Chat.ID, MessageID: sent.MessageID, Type: domain.TelegramMessageText}, nil
+}
+
+// callJSON posts a Bot API method with a JSON body.
+func (t *TGApi) callJSON(ctx context.Context, method string, request any, result any) error {
+	reqBody, err := json.Marshal(request)
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBody))
+	return t.call(ctx, method, bytes.NewBuffer(reqBody), "application/json", result)
+}
+
+// call posts a Bot API method and fails on a response that is not ok, the result is decoded into result unless it is nil.
+func (t *TGApi) call(ctx context.Context, method string, body io.Reader, contentType string, result any) error {
+	url := fmt.Sprintf("%s/bot%s/%s", t.baseURL, t.token, method)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
-		return fmt.Errorf("create request: %w", err)
+		return fmt.Errorf("create request: %w", t.redact(err))
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	client := t.client
+	if method == "getUpdates" {
+		client = t.pollClient
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := t.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("telegram api request: %w", err)
+		return fmt.Errorf("telegram api request: %w", t.redact(err))
 	}
 	defer resp.Body.Close()
 
@@ -66,8 +152,78 @@ func (t *TGApi) SendMessage(ctx context.Context, to string, message string, pars
 	}
 
 	if !res.Ok {
-		return fmt.Errorf("telegram api error: %s, %v", res.Description, res)
+		apiErr := &domain.TelegramAPIError{Code: res.ErrorCode, Description: res.Description}
+		if res.Parameters != nil {
+			apiErr.RetryAfter = time.Duration(res.Parameters.RetryAfter) * time.Second
+			apiErr.MigrateToChatID = res.Parameters.MigrateToChatID
+		}
+		return apiErr
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(res.Result, result); err != nil {
+			return fmt.Errorf("decode result: %w", err)
+		}
 	}
 
 	return nil
 }
+
+// redact replaces the token in the URL the http client puts into its errors, keeping the error chain intact.
+func (t *TGApi) redact(err error) error {
+	if t.token == "" {
+		return err
+	}
+
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return &neturl.Error{Op: urlErr.Op, URL: strings.ReplaceAll(urlErr.URL, t.token, "<"+t.name+">"), Err: t.redact(urlErr.Err)}
+	}
+	if strings.Contains(err.Error(), t.token) {
+		return errors.New(strings.ReplaceAll(err.Error(), t.token, "<"+t.name+">"))
+	}
+
+	return err
+}
+
+func newSendMessageRequest(message *entity.TelegramNotification) sendMessageRequest {
+	req := sendMessageRequest{
+		ChatID:              message.To,
+		Text:                message.Payload,
+		ParseMode:           message.ParseMode,
+		MessageThreadID:     message.MessageThreadID,
+		DisableNotification: message.DisableNotification,
+		ProtectContent:      message.ProtectContent,
+	}
+
+	if preview := message.LinkPreview; preview != nil {
+		req.LinkPreviewOptions = &linkPreviewOptions{
+			IsDisabled:       preview.IsDisabled,
+			URL:              preview.URL,
+			PreferSmallMedia: preview.PreferSmallMedia,
+			PreferLargeMedia: preview.PreferLargeMedia,
+			ShowAboveText:    preview.ShowAboveText,
+		}
+	}
+
+	if message.ReplyToMessageID != nil {
+		req.ReplyParameters = &replyParameters{
+			MessageID:                *message.ReplyToMessageID,
+			AllowSendingWithoutReply: message.AllowSendingWithoutReply,
+		}
+	}
+
+	if len(message.InlineKeyboard) > 0 {
+		keyboard := make([][]inlineKeyboardButton, 0, len(message.InlineKeyboard))
+		for _, row := range message.InlineKeyboard {
+			buttons := make([]inlineKeyboardButton, 0, len(row))
+			for _, button := range row {
+				buttons = append(buttons, inlineKeyboardButton{Text: button.Text, URL: button.URL, CallbackData: button.CallbackData})
+			}
+			keyboard = append(keyboard, buttons)
+		}
+		req.ReplyMarkup = &inlineKeyboardMarkup{InlineKeyboard: keyboard}
+	}
+
+	return req
+}
@@ -0,0 +1,147 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"github.com/goccy/go-json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/internal/notifications/infra/telegram/telegramtest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAPI(t *testing.T) (*TGApi, *telegramtest.Server) {
+	t.Helper()
+
+	fake := telegramtest.NewServer("1:secret")
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return NewTGApiClient("default", "1:secret", server.URL, 5*time.Second, nil), fake
+}
+
+func TestSendMessage(t *testing.T) {
+	api, fake := newTestAPI(t)
+
+	sent, err := api.SendMessage(context.Background(), &entity.TelegramNotification{
+		To:             "42",
+		Payload:        "*hi*",
+		ParseMode:      domain.TelegramParseModeMarkdown,
+		InlineKeyboard: [][]entity.TelegramInlineButton{{{Text: "Open", URL: "https://example.com"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.ChatID != 42 || sent.MessageID == 0 || sent.Type != domain.TelegramMessageText {
+		t.Fatalf("unexpected sent message %+v", sent)
+	}
+
+	calls := fake.Calls("sendMessage")
+	if len(calls) != 1 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	params := calls[0].Params
+	if params["chat_id"] != "42" || params["text"] != "*hi*" || params["parse_mode"] != "Markdown" {
+		t.Fatalf("unexpected params %v", params)
+	}
+	var markup inlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(params["reply_markup"]), &markup); err != nil || markup.InlineKeyboard[0][0].URL != "https://example.com" {
+		t.Fatalf("unexpected reply_markup %q", params["reply_markup"])
+	}
+}
+
+func TestSendMessageErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response telegramtest.Response
+		want     domain.TelegramAPIError
+	}{
+		{
+			name:     "retry after",
+			response: telegramtest.TooManyRequests(7),
+			want:     domain.TelegramAPIError{Code: http.StatusTooManyRequests, RetryAfter: 7 * time.Second},
+		},
+		{
+			name:     "migrated",
+			response: telegramtest.Migrated(-1001234),
+			want:     domain.TelegramAPIError{Code: http.StatusBadRequest, MigrateToChatID: -1001234},
+		},
+		{
+			name:     "parse error",
+			response: telegramtest.BadRequest("can't parse entities: Can't find end of the entity starting at byte offset 0"),
+			want:     domain.TelegramAPIError{Code: http.StatusBadRequest},
+		},
+		{
+			name:     "blocked",
+			response: telegramtest.Forbidden("bot was blocked by the user"),
+			want:     domain.TelegramAPIError{Code: http.StatusForbidden},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, fake := newTestAPI(t)
+			fake.Respond("sendMessage", tt.response)
+
+			_, err := api.SendMessage(context.Background(), &entity.TelegramNotification{To: "42", Payload: "hi"})
+
+			var apiErr *domain.TelegramAPIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want a TelegramAPIError", err)
+			}
+			if apiErr.Code != tt.want.Code || apiErr.RetryAfter != tt.want.RetryAfter || apiErr.MigrateToChatID != tt.want.MigrateToChatID {
+				t.Fatalf("err = %+v, want %+v", apiErr, tt.want)
+			}
+			if apiErr.Description != tt.response.Description {
+				t.Fatalf("description = %q, want %q", apiErr.Description, tt.response.Description)
+			}
+		})
+	}
+}
+
+func TestSendMediaAlbum(t *testing.T) {
+	api, fake := newTestAPI(t)
+
+	sent, err := api.SendMedia(context.Background(), &entity.TelegramNotification{
+		To: "42",
+		Media: []entity.TelegramMedia{
+			{Type: domain.TelegramMediaPhoto, URL: "https://example.com/a.png", Caption: "first"},
+			{Type: domain.TelegramMediaDocument, Data: []byte("report"), Filename: "report.txt"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[0].MessageID == sent[1].MessageID {
+		t.Fatalf("unexpected sent messages %+v", sent)
+	}
+
+	calls := fake.Calls("sendMediaGroup")
+	if len(calls) != 1 {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+	if !strings.Contains(calls[0].Params["media"], `"caption":"first"`) {
+		t.Fatalf("unexpected media %s", calls[0].Params["media"])
+	}
+	if file, ok := calls[0].Files["file1"]; !ok || string(file.Data) != "report" {
+		t.Fatalf("unexpected files %v", calls[0].Files)
+	}
+}
+
+func TestErrorsDoNotContainToken(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	api := NewTGApiClient("default", "1:secret", server.URL, time.Second, nil)
+	_, err := api.SendMessage(context.Background(), &entity.TelegramNotification{To: "42", Payload: "hi"})
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), "1:secret") || !strings.Contains(err.Error(), "<default>") {
+		t.Fatalf("err = %v, want the bot name instead of the token", err)
+	}
+}
@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"context"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+)
+
+type editMessageTextRequest struct {
+	ChatID             int64                 `json:"chat_id"`
+	MessageID          int64                 `json:"message_id"`
+	Text               string                `json:"text"`
+	ParseMode          string                `json:"parse_mode,omitempty"`
+	LinkPreviewOptions *linkPreviewOptions   `json:"link_preview_options,omitempty"`
+	ReplyMarkup        *inlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type editMessageCaptionRequest struct {
+	ChatID      int64                 `json:"chat_id"`
+	MessageID   int64                 `json:"message_id"`
+	Caption     string                `json:"caption"`
+	ParseMode   string                `json:"parse_mode,omitempty"`
+	ReplyMarkup *inlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type deleteMessagesRequest struct {
+	ChatID     int64   `json:"chat_id"`
+	MessageIDs []int64 `json:"message_ids"`
+}
+
+// EditMessage replaces the text of a text message or the caption of a photo or document, the keyboard is replaced too.
+func (t *TGApi) EditMessage(ctx context.Context, target entity.TelegramMessage, notification *entity.TelegramNotification) error {
+	common := newSendMessageRequest(notification)
+
+	if target.Type != domain.TelegramMessageText {
+		return t.callJSON(ctx, "editMessageCaption", editMessageCaptionRequest{
+			ChatID:      target.ChatID,
+			MessageID:   target.MessageID,
+			Caption:     notification.Payload,
+			ParseMode:   notification.ParseMode,
+			ReplyMarkup: common.ReplyMarkup,
+		}, nil)
+	}
+
+	return t.callJSON(ctx, "editMessageText", editMessageTextRequest{
+		ChatID:             target.ChatID,
+		MessageID:          target.MessageID,
+		Text:               notification.Payload,
+		ParseMode:          notification.ParseMode,
+		LinkPreviewOptions: common.LinkPreviewOptions,
+		ReplyMarkup:        common.ReplyMarkup,
+	}, nil)
+}
+
+// DeleteMessages deletes up to 100 messages of a chat at once, messages that are already gone are skipped by Telegram.
+func (t *TGApi) DeleteMessages(ctx context.Context, chatID int64, messageIDs []int64) error {
+	return t.callJSON(ctx, "deleteMessages", deleteMessagesRequest{ChatID: chatID, MessageIDs: messageIDs}, nil)
+}
@@ -0,0 +1,148 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"mime/multipart"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/domain/entity"
+	"strconv"
+)
+
+type inputMedia struct {
+	Type      string `json:"type"`
+	Media     string `json:"media"`
+	Caption   string `json:"caption,omitempty"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+// SendMedia uploads as multipart/form-data, files given by URL are passed as strings and fetched by Telegram.
+func (t *TGApi) SendMedia(ctx context.Context, notification *entity.TelegramNotification) ([]domain.TelegramSentMessage, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	method, err := writeMediaForm(form, notification)
+	if err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("close form: %w", err)
+	}
+
+	// an album returns an array of messages, a single file one message
+	var sent []message
+	if len(notification.Media) == 1 {
+		sent = make([]message, 1)
+		err = t.call(ctx, method, &body, form.FormDataContentType(), &sent[0])
+	} else {
+		err = t.call(ctx, method, &body, form.FormDataContentType(), &sent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.TelegramSentMessage, 0, len(sent))
+	for i, m := range sent {
+		result = append(result, domain.TelegramSentMessage{ChatID: m.Chat.ID, MessageID: m.MessageID, Type: notification.Media[min(i, len(notification.Media)-1)].Type})
+	}
+
+	return result, nil
+}
+
+func writeMediaForm(form *multipart.Writer, message *entity.TelegramNotification) (string, error) {
+	fields := map[string]string{"chat_id": message.To}
+	if message.MessageThreadID != nil {
+		fields["message_thread_id"] = strconv.FormatInt(*message.MessageThreadID, 10)
+	}
+	if message.DisableNotification {
+		fields["disable_notification"] = "true"
+	}
+	if message.ProtectContent {
+		fields["protect_content"] = "true"
+	}
+
+	// nested objects are sent as JSON strings, the same way the Bot API documents them for multipart
+	common := newSendMessageRequest(message)
+	if common.ReplyParameters != nil {
+		raw, err := json.Marshal(common.ReplyParameters)
+		if err != nil {
+			return "", fmt.Errorf("marshal reply parameters: %w", err)
+		}
+		fields["reply_parameters"] = string(raw)
+	}
+
+	var method string
+	if len(message.Media) == 1 {
+		media := message.Media[0]
+		method = "sendDocument"
+		if media.Type == domain.TelegramMediaPhoto {
+			method = "sendPhoto"
+		}
+
+		if common.ReplyMarkup != nil {
+			raw, err := json.Marshal(common.ReplyMarkup)
+			if err != nil {
+				return "", fmt.Errorf("marshal reply markup: %w", err)
+			}
+			fields["reply_markup"] = string(raw)
+		}
+		if media.Caption != "" {
+			fields["caption"] = media.Caption
+			if message.ParseMode != "" {
+				fields["parse_mode"] = message.ParseMode
+			}
+		}
+		if media.URL != "" {
+			fields[media.Type] = media.URL
+		} else if err := writeFile(form, media.Type, media); err != nil {
+			return "", err
+		}
+	} else {
+		method = "sendMediaGroup"
+
+		group := make([]inputMedia, 0, len(message.Media))
+		for i, media := range message.Media {
+			item := inputMedia{Type: media.Type, Media: media.URL}
+			if media.Caption != "" {
+				item.Caption = media.Caption
+				item.ParseMode = message.ParseMode
+			}
+			if media.URL == "" {
+				name := "file" + strconv.Itoa(i)
+				item.Media = "attach://" + name
+				if err := writeFile(form, name, media); err != nil {
+					return "", err
+				}
+			}
+			group = append(group, item)
+		}
+
+		raw, err := json.Marshal(group)
+		if err != nil {
+			return "", fmt.Errorf("marshal media group: %w", err)
+		}
+		fields["media"] = string(raw)
+	}
+
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return "", fmt.Errorf("write field %s: %w", name, err)
+		}
+	}
+
+	return method, nil
+}
+
+func writeFile(form *multipart.Writer, field string, media entity.TelegramMedia) error {
+	part, err := form.CreateFormFile(field, media.Filename)
+	if err != nil {
+		return fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := part.Write(media.Data); err != nil {
+		return fmt.Errorf("write form file: %w", err)
+	}
+
+	return nil
+}
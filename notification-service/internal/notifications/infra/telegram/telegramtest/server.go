@@ -0,0 +1,465 @@
+// Package telegramtest is a fake Bot API server. It answers the methods the service calls, records every call
+// and returns scripted errors, so the Telegram channel can be tested without reaching Telegram.
+package telegramtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ControlPrefix is the path of the endpoints that drive the server over HTTP, for tests outside Go.
+const ControlPrefix = "/fake/"
+
+// maxPollWait bounds a getUpdates long poll, whatever timeout it asks for.
+const maxPollWait = 30 * time.Second
+
+// Call is a Bot API request the server received. Params holds JSON and form fields alike,
+// strings as they are and other JSON values as JSON, the way the Bot API accepts both.
+// Token keeps only the bot id of the token, calls are served over the control endpoints.
+type Call struct {
+	Token  string            `json:"token"`
+	Method string            `json:"method"`
+	Params map[string]string `json:"params"`
+	Files  map[string]File   `json:"files,omitempty"`
+	At     time.Time         `json:"at"`
+}
+
+type File struct {
+	Filename string `json:"filename"`
+	Size     int    `json:"size"`
+	Data     []byte `json:"-"`
+}
+
+// Response is a scripted answer. A zero Code answers ok with Result, or with the default result when it is nil.
+type Response struct {
+	Code            int    `json:"error_code,omitempty"`
+	Description     string `json:"description,omitempty"`
+	RetryAfter      int    `json:"retry_after,omitempty"`
+	MigrateToChatID int64  `json:"migrate_to_chat_id,omitempty"`
+	Result          any    `json:"result,omitempty"`
+}
+
+func BadRequest(description string) Response {
+	return Response{Code: http.StatusBadRequest, Description: "Bad Request: " + description}
+}
+
+func Forbidden(description string) Response {
+	return Response{Code: http.StatusForbidden, Description: "Forbidden: " + description}
+}
+
+func TooManyRequests(retryAfter int) Response {
+	return Response{Code: http.StatusTooManyRequests, Description: fmt.Sprintf("Too Many Requests: retry after %d", retryAfter), RetryAfter: retryAfter}
+}
+
+func Migrated(chatID int64) Response {
+	return Response{Code: http.StatusBadRequest, Description: "Bad Request: group chat was upgraded to a supergroup chat", MigrateToChatID: chatID}
+}
+
+func ServerError() Response {
+	return Response{Code: http.StatusInternalServerError, Description: "Internal Server Error"}
+}
+
+type Server struct {
+	mu        sync.Mutex
+	tokens    map[string]bool
+	calls     []Call
+	scripts   map[string][]Response
+	messageID int64
+	updates   []json.RawMessage
+	updateID  int64
+	// pushed is closed and replaced when an update arrives, waking the long polls
+	pushed chan struct{}
+	// OnCall, when set, sees every call after it is recorded
+	OnCall func(call Call)
+}
+
+// NewServer returns a server accepting the tokens, any token when none are given.
+func NewServer(tokens ...string) *Server {
+	s := &Server{
+		tokens:  make(map[string]bool, len(tokens)),
+		scripts: make(map[string][]Response),
+		pushed:  make(chan struct{}),
+	}
+	for _, token := range tokens {
+		s.tokens[token] = true
+	}
+
+	return s
+}
+
+// Respond queues answers for the next calls of the method, they are used in order before the defaults.
+func (s *Server) Respond(method string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts[method] = append(s.scripts[method], responses...)
+}
+
+// Calls returns the calls received so far, of the given methods only when any are given.
+func (s *Server) Calls(methods ...string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := make([]Call, 0, len(s.calls))
+	for _, call := range s.calls {
+		if len(methods) == 0 || contains(methods, call.Method) {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Reset forgets the calls, scripts and pending updates.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+	s.scripts = make(map[string][]Response)
+	s.updates = nil
+}
+
+// PushUpdate queues an update for getUpdates and returns its update_id, which it is given.
+func (s *Server) PushUpdate(update map[string]any) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateID++
+	update["update_id"] = s.updateID
+	raw, err := json.Marshal(update)
+	if err != nil {
+		return 0, err
+	}
+
+	s.updates = append(s.updates, raw)
+	close(s.pushed)
+	s.pushed = make(chan struct{})
+
+	return s.updateID, nil
+}
+
+// PushMessage queues a text message a user sent to the bot in a private chat, such as /start <token>.
+func (s *Server) PushMessage(chatID int64, username string, text string) (int64, error) {
+	chat := map[string]any{"id": chatID, "type": "private", "username": username}
+	return s.PushUpdate(map[string]any{
+		"message": map[string]any{
+			"message_id": s.nextMessageID(),
+			"date":       time.Now().Unix(),
+			"chat":       chat,
+			"from":       map[string]any{"id": chatID, "is_bot": false, "first_name": username, "username": username},
+			"text":       text,
+		},
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, ControlPrefix) {
+		s.serveControl(w, r)
+		return
+	}
+
+	// /bot<token>/<method>
+	path := strings.TrimPrefix(r.URL.Path, "/bot")
+	token, method, ok := strings.Cut(path, "/")
+	if !ok || path == r.URL.Path {
+		writeResponse(w, Response{Code: http.StatusNotFound, Description: "Not Found"}, nil)
+		return
+	}
+	if len(s.tokens) > 0 && !s.tokens[token] {
+		writeResponse(w, Response{Code: http.StatusUnauthorized, Description: "Unauthorized"}, nil)
+		return
+	}
+
+	call, err := readCall(r)
+	if err != nil {
+		writeResponse(w, BadRequest(err.Error()), nil)
+		return
+	}
+	call.Token = redactToken(token)
+	call.Method = method
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	response, scripted := s.nextScripted(method)
+	onCall := s.OnCall
+	s.mu.Unlock()
+
+	if onCall != nil {
+		onCall(call)
+	}
+
+	if scripted && (response.Code != 0 || response.Result != nil) {
+		writeResponse(w, response, response.Result)
+		return
+	}
+
+	result, found := s.result(r.Context(), call)
+	if !found {
+		writeResponse(w, Response{Code: http.StatusNotFound, Description: "Not Found: method not found"}, nil)
+		return
+	}
+
+	writeResponse(w, Response{}, result)
+}
+
+func (s *Server) nextScripted(method string) (Response, bool) {
+	queued := s.scripts[method]
+	if len(queued) == 0 {
+		return Response{}, false
+	}
+
+	s.scripts[method] = queued[1:]
+	return queued[0], true
+}
+
+// result is the default answer of a method, what Telegram returns for a successful call.
+func (s *Server) result(ctx context.Context, call Call) (any, bool) {
+	switch call.Method {
+	case "getMe":
+		return map[string]any{"id": 1, "is_bot": true, "first_name": "Fake", "username": "fake_bot"}, true
+	case "sendMessage":
+		msg := s.message(call)
+		msg["text"] = call.Params["text"]
+		return msg, true
+	case "sendPhoto":
+		msg := s.message(call)
+		msg["photo"] = []map[string]any{{"file_id": "photo" + strconv.FormatInt(msg["message_id"].(int64), 10), "width": 1, "height": 1}}
+		msg["caption"] = call.Params["caption"]
+		return msg, true
+	case "sendDocument":
+		msg := s.message(call)
+		msg["document"] = map[string]any{"file_id": "document" + strconv.FormatInt(msg["message_id"].(int64), 10), "file_name": call.Files["document"].Filename}
+		msg["caption"] = call.Params["caption"]
+		return msg, true
+	case "sendMediaGroup":
+		var media []json.RawMessage
+		_ = json.Unmarshal([]byte(call.Params["media"]), &media)
+		messages := make([]map[string]any, 0, len(media))
+		for range media {
+			messages = append(messages, s.message(call))
+		}
+		return messages, true
+	case "editMessageText", "editMessageCaption":
+		msg := s.message(call)
+		msg["message_id"], _ = strconv.ParseInt(call.Params["message_id"], 10, 64)
+		msg["text"] = call.Params["text"]
+		return msg, true
+	case "deleteMessage", "deleteMessages", "setWebhook", "deleteWebhook", "logOut", "close":
+		return true, true
+	case "getUpdates":
+		return s.pollUpdates(ctx, call), true
+	default:
+		return nil, false
+	}
+}
+
+// message is a sent Message in the chat of the call, usernames of channels get a made up id.
+func (s *Server) message(call Call) map[string]any {
+	chatID, err := strconv.ParseInt(call.Params["chat_id"], 10, 64)
+	if err != nil {
+		chatID = -1000000000000 - int64(len(call.Params["chat_id"]))
+	}
+
+	return map[string]any{
+		"message_id": s.nextMessageID(),
+		"date":       time.Now().Unix(),
+		"chat":       map[string]any{"id": chatID},
+	}
+}
+
+func (s *Server) nextMessageID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messageID++
+	return s.messageID
+}
+
+// pollUpdates returns the updates from offset on and drops the ones before it, like Telegram.
+// Without any it waits for one up to the timeout of the call.
+func (s *Server) pollUpdates(ctx context.Context, call Call) []json.RawMessage {
+	offset, _ := strconv.ParseInt(call.Params["offset"], 10, 64)
+	timeout, _ := strconv.Atoi(call.Params["timeout"])
+	deadline := time.After(min(time.Duration(timeout)*time.Second, maxPollWait))
+
+	for {
+		s.mu.Lock()
+		pending := make([]json.RawMessage, 0, len(s.updates))
+		kept := s.updates[:0]
+		for _, raw := range s.updates {
+			var head struct {
+				UpdateID int64 `json:"update_id"`
+			}
+			_ = json.Unmarshal(raw, &head)
+			if head.UpdateID >= offset {
+				pending = append(pending, raw)
+				kept = append(kept, raw)
+			}
+		}
+		s.updates = kept
+		pushed := s.pushed
+		s.mu.Unlock()
+
+		if len(pending) > 0 {
+			return pending
+		}
+
+		select {
+		case <-pushed:
+		case <-deadline:
+			return pending
+		case <-ctx.Done():
+			return pending
+		}
+	}
+}
+
+// serveControl drives the server over HTTP:
+//
+//	GET    /fake/calls[?method=sendMessage]  the recorded calls
+//	DELETE /fake/calls                       Reset
+//	POST   /fake/responses/<method>          queue a JSON array of Response
+//	POST   /fake/updates                     queue an update, a JSON object
+func (s *Server) serveControl(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, ControlPrefix)
+
+	switch {
+	case path == "calls" && r.Method == http.MethodGet:
+		var methods []string
+		if method := r.URL.Query().Get("method"); method != "" {
+			methods = append(methods, method)
+		}
+		writeJSON(w, http.StatusOK, s.Calls(methods...))
+	case path == "calls" && r.Method == http.MethodDelete:
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "responses/") && r.Method == http.MethodPost:
+		var responses []Response
+		if err := json.NewDecoder(r.Body).Decode(&responses); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.Respond(strings.TrimPrefix(path, "responses/"), responses...)
+		w.WriteHeader(http.StatusNoContent)
+	case path == "updates" && r.Method == http.MethodPost:
+		var update map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, err := s.PushUpdate(update)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int64{"update_id": id})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// readCall reads the params of a JSON, form or multipart request, and the files of a multipart one.
+func readCall(r *http.Request) (Call, error) {
+	call := Call{Params: make(map[string]string), At: time.Now()}
+
+	for name, values := range r.URL.Query() {
+		call.Params[name] = values[0]
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+			return call, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		for name, raw := range body {
+			var text string
+			if err := json.Unmarshal(raw, &text); err == nil {
+				call.Params[name] = text
+			} else {
+				call.Params[name] = string(raw)
+			}
+		}
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(64 << 20); err != nil {
+			return call, fmt.Errorf("invalid multipart body: %w", err)
+		}
+		for name, values := range r.MultipartForm.Value {
+			call.Params[name] = values[0]
+		}
+		call.Files = make(map[string]File, len(r.MultipartForm.File))
+		for name, headers := range r.MultipartForm.File {
+			f, err := headers[0].Open()
+			if err != nil {
+				return call, err
+			}
+			data, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return call, err
+			}
+			call.Files[name] = File{Filename: headers[0].Filename, Size: len(data), Data: data}
+		}
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return call, fmt.Errorf("invalid form body: %w", err)
+		}
+		for name, values := range r.PostForm {
+			call.Params[name] = values[0]
+		}
+	}
+
+	return call, nil
+}
+
+func writeResponse(w http.ResponseWriter, response Response, result any) {
+	if response.Code == 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "result": result})
+		return
+	}
+
+	body := map[string]any{"ok": false, "error_code": response.Code, "description": response.Description}
+	parameters := map[string]any{}
+	if response.RetryAfter > 0 {
+		parameters["retry_after"] = response.RetryAfter
+	}
+	if response.MigrateToChatID != 0 {
+		parameters["migrate_to_chat_id"] = response.MigrateToChatID
+	}
+	if len(parameters) > 0 {
+		body["parameters"] = parameters
+	}
+
+	writeJSON(w, response.Code, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// redactToken keeps the bot id of a <bot id>:<secret> token.
+func redactToken(token string) string {
+	id, _, _ := strings.Cut(token, ":")
+	return id + ":***"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package telegramtest
+
+import (
+	"bytes"
+	"github.com/goccy/go-json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, url string, body string) map[string]any {
+	t.Helper()
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestServerRejectsUnknownToken(t *testing.T) {
+	server := httptest.NewServer(NewServer("1:good"))
+	defer server.Close()
+
+	body := post(t, server.URL+"/bot1:bad/getMe", `{}`)
+	if body["ok"] != false || body["error_code"] != float64(http.StatusUnauthorized) {
+		t.Fatalf("unexpected response %v", body)
+	}
+
+	body = post(t, server.URL+"/bot1:good/getMe", `{}`)
+	if body["ok"] != true {
+		t.Fatalf("unexpected response %v", body)
+	}
+}
+
+func TestServerAnswersScriptedResponsesInOrder(t *testing.T) {
+	fake := NewServer()
+	fake.Respond("sendMessage", TooManyRequests(3), Migrated(-1002))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	url := server.URL + "/bot1:secret/sendMessage"
+
+	body := post(t, url, `{"chat_id":"42","text":"hi"}`)
+	if body["error_code"] != float64(http.StatusTooManyRequests) || body["parameters"].(map[string]any)["retry_after"] != float64(3) {
+		t.Fatalf("unexpected first response %v", body)
+	}
+
+	body = post(t, url, `{"chat_id":"42","text":"hi"}`)
+	if body["parameters"].(map[string]any)["migrate_to_chat_id"] != float64(-1002) {
+		t.Fatalf("unexpected second response %v", body)
+	}
+
+	body = post(t, url, `{"chat_id":"-1002","text":"hi"}`)
+	result, _ := body["result"].(map[string]any)
+	if body["ok"] != true || result["text"] != "hi" || result["chat"].(map[string]any)["id"] != float64(-1002) {
+		t.Fatalf("unexpected default response %v", body)
+	}
+
+	if calls := fake.Calls("sendMessage"); len(calls) != 3 || calls[2].Params["chat_id"] != "-1002" {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+}
+
+func TestServerRedactsTokenOfRecordedCalls(t *testing.T) {
+	fake := NewServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	post(t, server.URL+"/bot123456:secret-part/sendMessage", `{"chat_id":"42","text":"hi"}`)
+
+	if calls := fake.Calls(); len(calls) != 1 || calls[0].Token != "123456:***" {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+
+	resp, err := http.Get(server.URL + ControlPrefix + "calls?method=sendMessage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if bytes.Contains(raw, []byte("secret-part")) {
+		t.Fatalf("control endpoint exposes the token: %s", raw)
+	}
+}
+
+func TestServerPollsUpdatesFromOffset(t *testing.T) {
+	fake := NewServer()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	first, err := fake.PushMessage(42, "alice", "/start one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.PushMessage(42, "alice", "/start two"); err != nil {
+		t.Fatal(err)
+	}
+
+	body := post(t, server.URL+"/bot1:secret/getUpdates", `{"offset":0,"timeout":0}`)
+	if updates := body["result"].([]any); len(updates) != 2 {
+		t.Fatalf("unexpected updates %v", updates)
+	}
+
+	// confirming the first drops it, like Telegram
+	body = post(t, server.URL+"/bot1:secret/getUpdates", `{"offset":`+strconv.FormatInt(first+1, 10)+`,"timeout":0}`)
+	updates := body["result"].([]any)
+	if len(updates) != 1 || updates[0].(map[string]any)["message"].(map[string]any)["text"] != "/start two" {
+		t.Fatalf("unexpected updates %v", updates)
+	}
+}
@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"notification-service-api/internal/shared/ratelimit"
+	"strings"
+	"time"
+)
+
+// Throttle keeps the sends of all workers and replicas within the Bot API limits of one bot, the buckets live in Redis.
+type Throttle struct {
+	limiter *ratelimit.Limiter
+	prefix  string
+	global  ratelimit.Limit
+	chat    ratelimit.Limit
+	group   ratelimit.Limit
+}
+
+func NewThrottle(limiter *ratelimit.Limiter, bot string, global ratelimit.Limit, chat ratelimit.Limit, group ratelimit.Limit) *Throttle {
+	return &Throttle{
+		limiter: limiter,
+		prefix:  "telegram:" + bot + ":",
+		global:  global,
+		chat:    chat,
+		group:   group,
+	}
+}
+
+// Reserve takes a send slot for the chat, a positive duration means no slot is free and tells when to try again.
+// The chat bucket is checked first, so a throttled chat does not use up the global limit.
+func (t *Throttle) Reserve(ctx context.Context, chatID string) (time.Duration, error) {
+	chatLimit := t.chat
+	// groups and channels have negative ids or @usernames
+	if strings.HasPrefix(chatID, "-") || strings.HasPrefix(chatID, "@") {
+		chatLimit = t.group
+	}
+
+	res, err := t.limiter.Allow(ctx, t.chatKey(chatID), chatLimit)
+	if err != nil || !res.Allowed {
+		return res.RetryAfter, err
+	}
+
+	res, err = t.limiter.Allow(ctx, t.prefix+"global", t.global)
+	if err != nil || !res.Allowed {
+		return res.RetryAfter, err
+	}
+
+	return 0, nil
+}
+
+// Block holds back every send to the chat for d, after Telegram answered with retry_after,
+// so the other workers and replicas wait as well instead of running into the same flood control.
+func (t *Throttle) Block(ctx context.Context, chatID string, d time.Duration) error {
+	return t.limiter.Block(ctx, t.chatKey(chatID), d)
+}
+
+func (t *Throttle) chatKey(chatID string) string {
+	return t.prefix + "chat:" + chatID
+}
@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"context"
+	"github.com/goccy/go-json"
+	"time"
+)
+
+// telegramAllowedUpdates are the update types the bot reacts to, Telegram does not send the others.
+var telegramAllowedUpdates = []string{"message"}
+
+type getUpdatesRequest struct {
+	Offset         int64    `json:"offset,omitempty"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+type setWebhookRequest struct {
+	URL            string   `json:"url"`
+	SecretToken    string   `json:"secret_token,omitempty"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+// getUpdatesGrace is how long a long poll may take past its timeout before it is given up.
+const getUpdatesGrace = 10 * time.Second
+
+// GetUpdates long-polls for updates from offset on, they are returned undecoded for the update handler.
+func (t *TGApi) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout+getUpdatesGrace)
+	defer cancel()
+
+	var updates []json.RawMessage
+	err := t.callJSON(ctx, "getUpdates", getUpdatesRequest{
+		Offset:         offset,
+		Timeout:        int(timeout / time.Second),
+		AllowedUpdates: telegramAllowedUpdates,
+	}, &updates)
+
+	return updates, err
+}
+
+// SetWebhook makes Telegram push updates to url, with the secret in the X-Telegram-Bot-Api-Secret-Token header.
+func (t *TGApi) SetWebhook(ctx context.Context, url string, secret string) error {
+	return t.callJSON(ctx, "setWebhook", setWebhookRequest{URL: url, SecretToken: secret, AllowedUpdates: telegramAllowedUpdates}, nil)
+}
+
+// DeleteWebhook switches the bot back to getUpdates, which fails while a webhook is set.
+func (t *TGApi) DeleteWebhook(ctx context.Context) error {
+	return t.callJSON(ctx, "deleteWebhook", struct{}{}, nil)
+}
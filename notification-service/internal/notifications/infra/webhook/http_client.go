@@ -0,0 +1,165 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"notification-service-api/internal/notifications/domain/entity"
+	"notification-service-api/pkg/utils"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// ErrAddressNotAllowed is returned when a target resolves to an address of the service's own network.
+var ErrAddressNotAllowed = errors.New("webhook target address is not allowed")
+
+// reservedPrefixes are not reachable on the internet, beside the ranges netip.Addr reports itself.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Client POSTs webhook payloads signed with HMAC-SHA256 over "timestamp.body".
+type Client struct {
+	secret         []byte
+	defaultTimeout time.Duration
+	client         *http.Client
+}
+
+// NewClient refuses to connect to loopback, private and link-local addresses unless allowPrivateNetworks,
+// so a target cannot reach the metadata endpoint or internal services. The check runs on the address being
+// dialed, a host name resolving to another address on a retry is checked again.
+func NewClient(secret string, defaultTimeout time.Duration, allowPrivateNetworks bool) *Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivateNetworks {
+		dialer.Control = rejectPrivateAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialed instead of the target and hide its address
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Client{
+		secret:         []byte(secret),
+		defaultTimeout: defaultTimeout,
+		client: &http.Client{
+			Transport: transport,
+			// a redirect is answered as is, following it could lead the signed payload anywhere
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func rejectPrivateAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if !isPublicAddress(ip.Unmap()) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, ip)
+	}
+	return nil
+}
+
+func isPublicAddress(ip netip.Addr) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sign returns the X-Webhook-Signature value, receivers recompute it to verify the payload.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send treats network errors, 5xx and 429 as retryable, any other non 2xx answer fails permanently.
+func (c *Client) Send(ctx context.Context, message *entity.WebhookNotification) error {
+	timeout := c.defaultTimeout
+	if message.Timeout > 0 {
+		timeout = message.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, message.URL, bytes.NewReader(message.Payload))
+	if err != nil {
+		return utils.Permanent(fmt.Errorf("create request: %w", err))
+	}
+
+	for key, value := range message.Headers {
+		req.Header.Set(key, value)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, message.NotificationID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(c.secret, timestamp, message.Payload))
+	if message.Event != "" {
+		req.Header.Set(HeaderEvent, message.Event)
+	} else {
+		req.Header.Del(HeaderEvent)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrAddressNotAllowed) {
+			return utils.Permanent(fmt.Errorf("webhook request: %w", err))
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("webhook timed out after %s", timeout)
+		}
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("webhook error: status %d, %s", resp.StatusCode, string(body))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+
+	return utils.Permanent(err)
+}
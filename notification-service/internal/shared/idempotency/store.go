@@ -0,0 +1,91 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"notification-service-api/internal/shared/idempotency/entity"
+	"time"
+)
+
+var (
+	ErrKeyMismatch = errors.New("idempotency key was already used with a different request")
+	ErrInProgress  = errors.New("request with this idempotency key is still in progress")
+)
+
+type Store struct {
+	db  *gorm.DB
+	ttl time.Duration
+	// inProgressTTL frees a key whose call never completed, a crashed or timed out one, long before ttl
+	inProgressTTL time.Duration
+}
+
+// NewStore keeps completed responses for ttl and claims of calls still in progress for inProgressTTL,
+// which should outlast the longest call.
+func NewStore(db *gorm.DB, ttl time.Duration, inProgressTTL time.Duration) *Store {
+	return &Store{
+		db:            db,
+		ttl:           ttl,
+		inProgressTTL: inProgressTTL,
+	}
+}
+
+// HashRequest returns the fingerprint stored with a key to detect reuse with a different body.
+func HashRequest(method string, params []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{'\n'})
+	h.Write(params)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin claims the key for the request. When the same request was already completed,
+// the stored response is returned with replay set to true.
+func (s *Store) Begin(ctx context.Context, key string, requestHash string) (response string, replay bool, err error) {
+	record := entity.Idempotency{Key: key, Request: requestHash}
+
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if res.Error != nil {
+		return "", false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return "", false, nil
+	}
+
+	var existing entity.Idempotency
+	if err := s.db.WithContext(ctx).Where("key = ?", key).First(&existing).Error; err != nil {
+		return "", false, err
+	}
+
+	expiry := s.ttl
+	if existing.Response == "" {
+		expiry = s.inProgressTTL
+	}
+	if expiry > 0 && time.Since(existing.CreatedAt) > expiry {
+		if err := s.Release(ctx, key); err != nil {
+			return "", false, err
+		}
+		return s.Begin(ctx, key, requestHash)
+	}
+
+	if existing.Request != requestHash {
+		return "", false, ErrKeyMismatch
+	}
+	if existing.Response == "" {
+		return "", false, ErrInProgress
+	}
+
+	return existing.Response, true, nil
+}
+
+func (s *Store) Complete(ctx context.Context, key string, response string) error {
+	return s.db.WithContext(ctx).Model(&entity.Idempotency{}).Where("key = ?", key).Update("response", response).Error
+}
+
+// Release forgets the key so that a failed request can be retried with it.
+func (s *Store) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Unscoped().Where("key = ?", key).Delete(&entity.Idempotency{}).Error
+}
@@ -0,0 +1,250 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeRow struct {
+	id        int64
+	createdAt time.Time
+	request   string
+	response  string
+}
+
+// fakeDB answers the four statements the store sends, claim, lookup, complete and release, from memory.
+type fakeDB struct {
+	mu     sync.Mutex
+	nextID int64
+	rows   map[string]*fakeRow
+}
+
+func newTestStore(t *testing.T, ttl time.Duration, inProgressTTL time.Duration) (*Store, *fakeDB) {
+	t.Helper()
+
+	db := &fakeDB{rows: make(map[string]*fakeRow)}
+	sqlDB := sql.OpenDB(db)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewStore(gormDB, ttl, inProgressTTL), db
+}
+
+// age moves the claim of key into the past.
+func (db *fakeDB) age(key string, d time.Duration) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.rows[key].createdAt = db.rows[key].createdAt.Add(-d)
+}
+
+func (db *fakeDB) query(query string, args []driver.NamedValue) (driver.Rows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, `INSERT INTO "idempotencies"`) && strings.Contains(query, "ON CONFLICT DO NOTHING"):
+		key := args[3].Value.(string)
+		if _, ok := db.rows[key]; ok {
+			return &fakeRows{columns: []string{"id"}}, nil
+		}
+		db.nextID++
+		db.rows[key] = &fakeRow{id: db.nextID, createdAt: args[0].Value.(time.Time), request: args[4].Value.(string), response: args[5].Value.(string)}
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{db.nextID}}}, nil
+	case strings.HasPrefix(query, `SELECT * FROM "idempotencies" WHERE key = $1`):
+		rows := &fakeRows{columns: []string{"id", "created_at", "updated_at", "deleted_at", "key", "request", "response"}}
+		key := args[0].Value.(string)
+		if row, ok := db.rows[key]; ok {
+			rows.values = [][]driver.Value{{row.id, row.createdAt, row.createdAt, nil, key, row.request, row.response}}
+		}
+		return rows, nil
+	}
+
+	return nil, fmt.Errorf("unexpected query %s", query)
+}
+
+func (db *fakeDB) exec(query string, args []driver.NamedValue) (driver.Result, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, `UPDATE "idempotencies" SET "response"=$1,"updated_at"=$2 WHERE key = $3`):
+		row, ok := db.rows[args[2].Value.(string)]
+		if !ok {
+			return driver.RowsAffected(0), nil
+		}
+		row.response = args[0].Value.(string)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, `DELETE FROM "idempotencies" WHERE key = $1`):
+		key := args[0].Value.(string)
+		if _, ok := db.rows[key]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		delete(db.rows, key)
+		return driver.RowsAffected(1), nil
+	}
+
+	return nil, fmt.Errorf("unexpected statement %s", query)
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: db}, nil
+}
+
+func (db *fakeDB) Driver() driver.Driver {
+	return db
+}
+
+func (db *fakeDB) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error             { return nil }
+func (c *fakeConn) Rollback() error           { return nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(query, args)
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.db.exec(query, args)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestStoreReplaysCompletedRequest(t *testing.T) {
+	store, _ := newTestStore(t, time.Hour, time.Minute)
+	ctx := context.Background()
+	hash := HashRequest("email.send", []byte(`{"to":"a@example.com"}`))
+
+	if _, replay, err := store.Begin(ctx, "key", hash); err != nil || replay {
+		t.Fatalf("first begin: replay %v, err %v", replay, err)
+	}
+	if err := store.Complete(ctx, "key", `{"result":1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	response, replay, err := store.Begin(ctx, "key", hash)
+	if err != nil || !replay || response != `{"result":1}` {
+		t.Fatalf("retry: response %q, replay %v, err %v", response, replay, err)
+	}
+}
+
+func TestStoreRejectsKeyReusedWithAnotherRequest(t *testing.T) {
+	store, _ := newTestStore(t, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	if _, _, err := store.Begin(ctx, "key", HashRequest("email.send", []byte(`{"to":"a@example.com"}`))); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Complete(ctx, "key", `{"result":1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := store.Begin(ctx, "key", HashRequest("email.send", []byte(`{"to":"b@example.com"}`)))
+	if !errors.Is(err, ErrKeyMismatch) {
+		t.Fatalf("err = %v, want %v", err, ErrKeyMismatch)
+	}
+}
+
+func TestStoreInProgress(t *testing.T) {
+	store, db := newTestStore(t, time.Hour, time.Minute)
+	ctx := context.Background()
+	hash := HashRequest("sms.send", []byte(`{}`))
+
+	if _, _, err := store.Begin(ctx, "key", hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Begin(ctx, "key", hash); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("err = %v, want %v while the first call runs", err, ErrInProgress)
+	}
+
+	// the first call timed out and never completed
+	db.age("key", 2*time.Minute)
+
+	if _, replay, err := store.Begin(ctx, "key", hash); err != nil || replay {
+		t.Fatalf("after the in-progress ttl: replay %v, err %v, want the key claimed again", replay, err)
+	}
+	if _, _, err := store.Begin(ctx, "key", hash); !errors.Is(err, ErrInProgress) {
+		t.Fatalf("err = %v, want the new claim in progress", err)
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	store, db := newTestStore(t, time.Hour, time.Minute)
+	ctx := context.Background()
+	hash := HashRequest("sms.send", []byte(`{}`))
+
+	if _, _, err := store.Begin(ctx, "key", hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Complete(ctx, "key", `{"result":1}`); err != nil {
+		t.Fatal(err)
+	}
+
+	// a completed response outlives the in-progress ttl
+	db.age("key", 2*time.Minute)
+	if _, replay, err := store.Begin(ctx, "key", hash); err != nil || !replay {
+		t.Fatalf("within ttl: replay %v, err %v", replay, err)
+	}
+
+	db.age("key", time.Hour)
+	if _, replay, err := store.Begin(ctx, "key", hash); err != nil || replay {
+		t.Fatalf("after ttl: replay %v, err %v, want the key claimed again", replay, err)
+	}
+}
+
+func TestStoreReleaseFreesKey(t *testing.T) {
+	store, _ := newTestStore(t, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	if _, _, err := store.Begin(ctx, "key", HashRequest("sms.send", []byte(`{"a":1}`))); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Release(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed call can be retried, even with corrected params
+	if _, replay, err := store.Begin(ctx, "key", HashRequest("sms.send", []byte(`{"a":2}`))); err != nil || replay {
+		t.Fatalf("after release: replay %v, err %v", replay, err)
+	}
+}
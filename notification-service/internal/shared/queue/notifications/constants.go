@@ -6,17 +6,34 @@ const (
 	ExchangeDLX   = "notifications.dlx"
 	ExchangeRetry = "notifications.retry"
 
-	RoutingEmailSend    = "email.send"
-	RoutingSMSSend      = "sms.send"
-	RoutingTelegramSend = "telegram.send"
-
-	QueueEmail    = "notifications.email"
-	QueueSMS      = "notifications.sms"
-	QueueTelegram = "notifications.telegram"
-
-	DeadQueueEmail    = "notifications.email.dlq"
-	DeadQueueSMS      = "notifications.sms.dlq"
-	DeadQueueTelegram = "notifications.telegram.dlq"
+	RoutingEmailSend      = "email.send"
+	RoutingSMSSend        = "sms.send"
+	RoutingTelegramSend   = "telegram.send"
+	RoutingTelegramEdit   = "telegram.edit"
+	RoutingTelegramDelete = "telegram.delete"
+	RoutingPushSend       = "push.send"
+	RoutingWebhookSend    = "webhook.send"
+	RoutingSlackSend      = "slack.send"
+	RoutingDiscordSend    = "discord.send"
+	RoutingMattermostSend = "mattermost.send"
+
+	QueueEmail      = "notifications.email"
+	QueueSMS        = "notifications.sms"
+	QueueTelegram   = "notifications.telegram"
+	QueuePush       = "notifications.push"
+	QueueWebhook    = "notifications.webhook"
+	QueueSlack      = "notifications.slack"
+	QueueDiscord    = "notifications.discord"
+	QueueMattermost = "notifications.mattermost"
+
+	DeadQueueEmail      = "notifications.email.dlq"
+	DeadQueueSMS        = "notifications.sms.dlq"
+	DeadQueueTelegram   = "notifications.telegram.dlq"
+	DeadQueuePush       = "notifications.push.dlq"
+	DeadQueueWebhook    = "notifications.webhook.dlq"
+	DeadQueueSlack      = "notifications.slack.dlq"
+	DeadQueueDiscord    = "notifications.discord.dlq"
+	DeadQueueMattermost = "notifications.mattermost.dlq"
 
 	RoutingEmailSendRetry = RoutingEmailSend + ".retry"
 	RoutingEmailSendDLQ   = RoutingEmailSend + ".dlq"
@@ -26,4 +43,21 @@ const (
 
 	RoutingTelegramSendRetry = RoutingTelegramSend + ".retry"
 	RoutingTelegramSendDLQ   = RoutingTelegramSend + ".dlq"
+
+	RoutingPushSendRetry = RoutingPushSend + ".retry"
+	RoutingPushSendDLQ   = RoutingPushSend + ".dlq"
+
+	RoutingWebhookSendRetry = RoutingWebhookSend + ".retry"
+	RoutingWebhookSendDLQ   = RoutingWebhookSend + ".dlq"
+
+	RoutingSlackSendRetry = RoutingSlackSend + ".retry"
+	RoutingSlackSendDLQ   = RoutingSlackSend + ".dlq"
+
+	RoutingDiscordSendRetry = RoutingDiscordSend + ".retry"
+	RoutingDiscordSendDLQ   = RoutingDiscordSend + ".dlq"
+
+	RoutingMattermostSendRetry = RoutingMattermostSend + ".retry"
+	RoutingMattermostSendDLQ   = RoutingMattermostSend + ".dlq"
+
+	HeaderNotificationID = "x-notification-id"
 )
@@ -38,6 +38,11 @@ func InitTopology(ch *amqp.Channel) error {
 		{notifications.QueueEmail, notifications.RoutingEmailSend, notifications.DeadQueueEmail},
 		{notifications.QueueSMS, notifications.RoutingSMSSend, notifications.DeadQueueSMS},
 		{notifications.QueueTelegram, notifications.RoutingTelegramSend, notifications.DeadQueueTelegram},
+		{notifications.QueuePush, notifications.RoutingPushSend, notifications.DeadQueuePush},
+		{notifications.QueueWebhook, notifications.RoutingWebhookSend, notifications.DeadQueueWebhook},
+		{notifications.QueueSlack, notifications.RoutingSlackSend, notifications.DeadQueueSlack},
+		{notifications.QueueDiscord, notifications.RoutingDiscordSend, notifications.DeadQueueDiscord},
+		{notifications.QueueMattermost, notifications.RoutingMattermostSend, notifications.DeadQueueMattermost},
 	}
 
 	for _, b := range bindings {
@@ -47,6 +52,13 @@ func InitTopology(ch *amqp.Channel) error {
 		log.Printf("Queue declared: %s (rk=%s)", b.queue, b.key)
 	}
 
+	// edits and deletes go through the telegram queue, with its retries, dead letters and rate limits
+	for _, key := range []string{notifications.RoutingTelegramEdit, notifications.RoutingTelegramDelete} {
+		if err := ch.QueueBind(notifications.QueueTelegram, key, notifications.ExchangeNotifications, false, nil); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
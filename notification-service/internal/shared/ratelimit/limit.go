@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"fmt"
+	"notification-service-api/internal/shared/rpc"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Count calls per Period, a caller may spend the whole Count at once.
+type Limit struct {
+	Count  int
+	Period time.Duration
+}
+
+func (l Limit) String() string {
+	switch l.Period {
+	case time.Second:
+		return strconv.Itoa(l.Count) + "/s"
+	case time.Minute:
+		return strconv.Itoa(l.Count) + "/m"
+	case time.Hour:
+		return strconv.Itoa(l.Count) + "/h"
+	default:
+		return strconv.Itoa(l.Count) + "/" + l.Period.String()
+	}
+}
+
+// ParseLimit reads limits like "10/s", "600/m" or "1000/h".
+func ParseLimit(raw string) (Limit, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(raw), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected <count>/<s|m|h>", raw)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit count %q", count)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit unit %q, expected s, m or h", unit)
+	}
+
+	return Limit{Count: n, Period: period}, nil
+}
+
+// Rules maps method patterns ("*", "email.*", "email.send") to limits.
+type Rules map[string]Limit
+
+// ParseRules reads the "pattern=limit,pattern=limit" format of RATE_LIMIT_DEFAULT.
+func ParseRules(raw string) (Rules, error) {
+	rules := Rules{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		pattern, limit, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit rule %q, expected pattern=limit", item)
+		}
+
+		l, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		rules[strings.TrimSpace(pattern)] = l
+	}
+
+	return rules, nil
+}
+
+// RulesFromMap parses limits stored per API key.
+func RulesFromMap(raw map[string]string) (Rules, error) {
+	rules := make(Rules, len(raw))
+	for pattern, limit := range raw {
+		l, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		rules[pattern] = l
+	}
+
+	return rules, nil
+}
+
+// For returns the limit of the most specific pattern matching the method:
+// an exact name wins over a namespace, a namespace over "*".
+func (r Rules) For(method string) (string, Limit, bool) {
+	bestPattern, best, bestScore := "", Limit{}, -1
+	for pattern, limit := range r {
+		if !rpc.MatchMethod(pattern, method) {
+			continue
+		}
+
+		score := len(pattern)
+		if pattern == method {
+			score = 1 << 16
+		}
+		if score > bestScore {
+			bestPattern, best, bestScore = pattern, limit, score
+		}
+	}
+
+	return bestPattern, best, bestScore >= 0
+}
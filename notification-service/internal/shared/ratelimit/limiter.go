@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+const keyPrefix = "ratelimit:"
+
+// tokenBucket refills Count tokens per Period and takes one per call, the Redis clock is used
+// so every instance sees the same time. A blocked bucket gives no tokens until the block ends.
+// Returns {allowed, remaining, retry_after_ms}.
+var tokenBucket = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local period_ms = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local rate = capacity / period_ms
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts', 'blocked')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local blocked = tonumber(state[3]) or 0
+if now < blocked then
+  return {0, math.floor(tokens), blocked - now}
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period_ms * 2)
+
+return {allowed, math.floor(tokens), retry}
+`)
+
+// blockBucket keeps the bucket from giving tokens for ARGV[1] milliseconds, a longer block already in place stays.
+var blockBucket = redis.NewScript(`
+local t = redis.call('TIME')
+local now = t[1] * 1000 + math.floor(t[2] / 1000)
+local block_ms = tonumber(ARGV[1])
+
+local blocked = tonumber(redis.call('HGET', KEYS[1], 'blocked')) or 0
+if now + block_ms > blocked then
+  redis.call('HSET', KEYS[1], 'blocked', now + block_ms)
+end
+if redis.call('PTTL', KEYS[1]) < block_ms then
+  redis.call('PEXPIRE', KEYS[1], block_ms)
+end
+
+return 1
+`)
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	redis *redis.Client
+}
+
+func NewLimiter(redisClient *redis.Client) *Limiter {
+	return &Limiter{redis: redisClient}
+}
+
+// Allow takes a token from the bucket of key.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	res, err := tokenBucket.Run(ctx, l.redis, []string{keyPrefix + key}, limit.Count, limit.Period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+// Block takes no more tokens from the bucket of key for d, when the other side asked to slow down.
+func (l *Limiter) Block(ctx context.Context, key string, d time.Duration) error {
+	return blockBucket.Run(ctx, l.redis, []string{keyPrefix + key}, d.Milliseconds()).Err()
+}
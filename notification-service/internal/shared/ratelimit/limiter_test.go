@@ -0,0 +1,220 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"github.com/go-redis/redis/v8"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers every script call with the next canned reply and keeps the arguments it was called with.
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	replies  []string
+	calls    [][]string
+}
+
+func newFakeRedis(t *testing.T, replies ...string) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{listener: listener, replies: replies}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+
+	return r
+}
+
+func (r *fakeRedis) client(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: r.listener.Addr().String()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, r.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (r *fakeRedis) exec(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "EVALSHA", "EVAL":
+		r.calls = append(r.calls, args)
+		if len(r.replies) == 0 {
+			return "-ERR no reply left\r\n"
+		}
+		reply := r.replies[0]
+		r.replies = r.replies[1:]
+		return reply
+	default:
+		return "+OK\r\n"
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args = append(args, string(arg[:size]))
+	}
+
+	return args, nil
+}
+
+func TestLimiterAllowReadsTheBucketReply(t *testing.T) {
+	fake := newFakeRedis(t, "*3\r\n:1\r\n:9\r\n:0\r\n", "*3\r\n:0\r\n:0\r\n:250\r\n")
+	limiter := NewLimiter(fake.client(t))
+	limit := Limit{Count: 10, Period: time.Minute}
+
+	tests := []struct {
+		want Result
+	}{
+		{want: Result{Allowed: true, Remaining: 9}},
+		{want: Result{Allowed: false, Remaining: 0, RetryAfter: 250 * time.Millisecond}},
+	}
+
+	for i, tt := range tests {
+		got, err := limiter.Allow(context.Background(), "apikey:1:email.send", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Fatalf("call %d: result = %+v, want %+v", i, got, tt.want)
+		}
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.calls) != len(tests) {
+		t.Fatalf("script was called %d times, want %d", len(fake.calls), len(tests))
+	}
+	// EVALSHA sha numkeys key capacity period_ms
+	for _, call := range fake.calls {
+		if got := call[2:]; strings.Join(got, " ") != "1 ratelimit:apikey:1:email.send 10 60000" {
+			t.Fatalf("script called with %q", got)
+		}
+	}
+}
+
+// TestLimiterRefill runs the bucket scripts on a real Redis, e.g. REDIS_TEST_ADDR=localhost:6379 go test ./...
+func TestLimiterRefill(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	limiter := NewLimiter(client)
+	key := "test:" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	t.Cleanup(func() { client.Del(ctx, keyPrefix+key) })
+
+	// one token every 100ms
+	limit := Limit{Count: 4, Period: 400 * time.Millisecond}
+	allow := func() Result {
+		t.Helper()
+		res, err := limiter.Allow(ctx, key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	for i := 0; i < limit.Count; i++ {
+		if res := allow(); !res.Allowed || res.Remaining != limit.Count-1-i {
+			t.Fatalf("call %d of a full bucket: %+v", i, res)
+		}
+	}
+
+	res := allow()
+	if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 100*time.Millisecond {
+		t.Fatalf("empty bucket: %+v, want a retry within one token", res)
+	}
+
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+	if res := allow(); !res.Allowed {
+		t.Fatalf("bucket did not refill a token after retry_after: %+v", res)
+	}
+	if res := allow(); res.Allowed {
+		t.Fatalf("bucket refilled more than one token: %+v", res)
+	}
+
+	// a bucket left alone refills up to its capacity and not beyond
+	time.Sleep(2 * limit.Period)
+	for i := 0; i < limit.Count; i++ {
+		if res := allow(); !res.Allowed {
+			t.Fatalf("call %d after a full refill: %+v", i, res)
+		}
+	}
+	if res := allow(); res.Allowed {
+		t.Fatalf("bucket refilled above its capacity: %+v", res)
+	}
+
+	// a block holds back the refilled tokens until it ends
+	time.Sleep(limit.Period)
+	if err := limiter.Block(ctx, key, 300*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	res = allow()
+	if res.Allowed || res.RetryAfter <= 200*time.Millisecond || res.RetryAfter > 300*time.Millisecond {
+		t.Fatalf("blocked bucket: %+v, want a retry at the end of the block", res)
+	}
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+	if res := allow(); !res.Allowed || res.Remaining != limit.Count-1 {
+		t.Fatalf("bucket after the block: %+v", res)
+	}
+}
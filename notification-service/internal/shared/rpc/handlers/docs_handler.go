@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"html/template"
+	"net/http"
+	"notification-service-api/internal/shared/rpc"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxDocsDepth limits how deep nested objects are expanded in the docs tables.
+const maxDocsDepth = 4
+
+type DocsHandler struct {
+	registry *rpc.Registry
+	tmpl     *template.Template
+}
+
+type docsPage struct {
+	Info    rpc.OpenRPCInfo
+	Methods []docsMethod
+}
+
+type docsMethod struct {
+	Name    string
+	Summary string
+	Params  []docsField
+	Result  []docsField
+}
+
+type docsField struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+func NewDocsHandler(registry *rpc.Registry, templatePath string) (*DocsHandler, error) {
+	tmpl, err := template.New(filepath.Base(templatePath)).Funcs(template.FuncMap{
+		"inc": func(i int) int { return i + 1 },
+	}).ParseFiles(templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DocsHandler{
+		registry: registry,
+		tmpl:     tmpl,
+	}, nil
+}
+
+// Page renders the HTML docs from the OpenRPC document, so they never drift from the DTOs.
+func (h *DocsHandler) Page(c *gin.Context) {
+	doc := h.registry.Discover()
+
+	page := docsPage{Info: doc.Info}
+	for _, m := range doc.Methods {
+		method := docsMethod{Name: m.Name, Summary: m.Summary}
+		for _, p := range m.Params {
+			method.Params = append(method.Params, flattenField(doc, p.Name, p.Schema, p.Required, p.Description, 0)...)
+		}
+		if m.Result != nil {
+			result := doc.Resolve(m.Result.Schema)
+			if result.TypeName() == "object" && len(result.PropertyOrder) > 0 {
+				// results are documented one level deep, nested objects are described by their type only
+				method.Result = flattenObject(doc, "", result, maxDocsDepth)
+			} else {
+				method.Result = []docsField{{Name: "result", Type: typeLabel(doc, m.Result.Schema)}}
+			}
+		}
+		page.Methods = append(page.Methods, method)
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := h.tmpl.Execute(c.Writer, page); err != nil {
+		rpc.FromGin(c).Error("render docs: " + err.Error())
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+// OpenRPC serves the raw OpenRPC document, same as the rpc.discover method.
+func (h *DocsHandler) OpenRPC(c *gin.Context) {
+	c.JSON(http.StatusOK, h.registry.Discover())
+}
+
+func flattenField(doc *rpc.OpenRPCDocument, name string, schema *rpc.Schema, required bool, description string, depth int) []docsField {
+	resolved := doc.Resolve(schema)
+	if description == "" {
+		description = schema.Description
+	}
+	if description == "" {
+		description = resolved.Description
+	}
+
+	fields := []docsField{{
+		Name:        name,
+		Type:        typeLabel(doc, schema),
+		Required:    required,
+		Description: describeConstraints(resolved, description),
+	}}
+
+	if depth >= maxDocsDepth {
+		return fields
+	}
+
+	switch resolved.TypeName() {
+	case "object":
+		fields = append(fields, flattenObject(doc, name+".", resolved, depth+1)...)
+	case "array":
+		items := doc.Resolve(resolved.Items)
+		if items != nil && items.TypeName() == "object" {
+			fields = append(fields, flattenObject(doc, name+"[].", items, depth+1)...)
+		}
+	}
+
+	return fields
+}
+
+func flattenObject(doc *rpc.OpenRPCDocument, prefix string, obj *rpc.Schema, depth int) []docsField {
+	required := make(map[string]bool, len(obj.Required))
+	for _, r := range obj.Required {
+		required[r] = true
+	}
+
+	var fields []docsField
+	for _, prop := range obj.PropertyOrder {
+		fields = append(fields, flattenField(doc, prefix+prop, obj.Properties[prop], required[prop], "", depth)...)
+	}
+
+	return fields
+}
+
+func typeLabel(doc *rpc.OpenRPCDocument, schema *rpc.Schema) string {
+	if schema == nil {
+		return "any"
+	}
+
+	resolved := doc.Resolve(schema)
+	label := resolved.TypeName()
+	switch label {
+	case "":
+		label = "any"
+	case "array":
+		label = "[]" + typeLabel(doc, resolved.Items)
+	case "object":
+		if resolved.AdditionalProperties != nil {
+			label = "map[string]" + typeLabel(doc, resolved.AdditionalProperties)
+		}
+	}
+
+	if resolved.Format != "" {
+		label += " (" + resolved.Format + ")"
+	}
+	if resolved.Nullable() {
+		label += "|null"
+	}
+
+	return label
+}
+
+func describeConstraints(s *rpc.Schema, description string) string {
+	var parts []string
+	if description != "" {
+		parts = append(parts, description)
+	}
+
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			if str, ok := v.(string); ok {
+				values = append(values, str)
+			}
+		}
+		parts = append(parts, "One of: "+strings.Join(values, ", "))
+	}
+	if s.MaxLength != nil {
+		parts = append(parts, "Max length: "+strconv.Itoa(*s.MaxLength))
+	}
+	if s.MaxItems != nil {
+		parts = append(parts, "Max items: "+strconv.Itoa(*s.MaxItems))
+	}
+
+	return strings.Join(parts, ". ")
+}
@@ -2,35 +2,86 @@ package handlers
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"github.com/goccy/go-json"
 	"go.uber.org/zap"
 	"net/http"
+	"notification-service-api/internal/shared/idempotency"
 	"notification-service-api/internal/shared/rpc"
 	"notification-service-api/internal/shared/rpc/respond"
+	"strings"
+	"sync"
 )
 
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	maxIdempotencyKeyLength = 200
+	maxStoredKeyLength      = 255
+)
+
+type BatchOptions struct {
+	// Parallelism is the number of batch items executed at the same time.
+	Parallelism int
+	// MaxSize is the largest accepted batch, 0 means unlimited.
+	MaxSize int
+}
+
 type RPCHandler struct {
-	registry *rpc.Registry
+	registry    *rpc.Registry
+	idempotency *idempotency.Store
+	batch       BatchOptions
 }
 
-func NewRPCHandler(registry *rpc.Registry) *RPCHandler {
+func NewRPCHandler(registry *rpc.Registry, idempotencyStore *idempotency.Store, batch BatchOptions) *RPCHandler {
+	if batch.Parallelism <= 0 {
+		batch.Parallelism = 1
+	}
+
 	return &RPCHandler{
-		registry: registry,
+		registry:    registry,
+		idempotency: idempotencyStore,
+		batch:       batch,
 	}
 }
 
 func (h *RPCHandler) MainRPCHandler(c *rpc.HttpCtx) {
 	raw, err := c.GetRawData()
 
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respond.Fail(c, nil, respond.InvalidRequest, "request_too_large", "request body is too large", map[string]int64{
+			"limit_bytes": tooLarge.Limit,
+		})
+		return
+	}
 	if err != nil {
 		respond.Fail(c, nil, respond.ParseError, "parse_error", "failed to read body", err.Error())
 		return
 	}
 
+	headerKey := strings.TrimSpace(c.GetHeader(HeaderIdempotencyKey))
+	if len(headerKey) > maxIdempotencyKeyLength {
+		respond.Fail(c, nil, respond.InvalidRequest, "invalid_idempotency_key", "idempotency key is too long", nil)
+		return
+	}
+
+	out := h.Dispatch(c, raw, headerKey)
+	if out == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+// Dispatch runs a raw JSON-RPC payload, a single request or a batch, and returns what has to be sent back.
+// It returns nil when there is nothing to answer, i.e. the payload contained only notifications.
+func (h *RPCHandler) Dispatch(c *rpc.HttpCtx, raw []byte, headerKey string) any {
 	s := bytes.TrimSpace(raw)
 	if len(s) == 0 {
-		respond.Fail(c, nil, respond.InvalidRequest, "invalid_request", "empty body", nil)
-		return
+		return respond.BuildFail(nil, respond.InvalidRequest, "invalid_request", "empty body", nil)
 	}
 
 	isBatch := false
@@ -39,13 +90,18 @@ func (h *RPCHandler) MainRPCHandler(c *rpc.HttpCtx) {
 	switch s[0] {
 	case '[':
 		if err := json.Unmarshal(s, &requests); err != nil {
-			respond.Fail(c, nil, respond.InvalidRequest, "invalid_request", "failed to read body", err.Error())
-			return
+			return respond.BuildFail(nil, respond.InvalidRequest, "invalid_request", "failed to read body", err.Error())
 		}
 
 		if len(requests) == 0 {
-			respond.Fail(c, nil, respond.InvalidRequest, "invalid_request", "empty body", nil)
-			return
+			return respond.BuildFail(nil, respond.InvalidRequest, "invalid_request", "empty body", nil)
+		}
+
+		if h.batch.MaxSize > 0 && len(requests) > h.batch.MaxSize {
+			return respond.BuildFail(nil, respond.InvalidRequest, "batch_too_large", "batch is too large", map[string]int{
+				"max_size": h.batch.MaxSize,
+				"size":     len(requests),
+			})
 		}
 
 		isBatch = true
@@ -54,61 +110,242 @@ func (h *RPCHandler) MainRPCHandler(c *rpc.HttpCtx) {
 	case '{':
 		var r respond.Request
 		if err := json.Unmarshal(s, &r); err != nil {
-			respond.Fail(c, nil, respond.InvalidRequest, "invalid_request", "failed to read body", err.Error())
-			return
+			return respond.BuildFail(nil, respond.InvalidRequest, "invalid_request", "failed to read body", err.Error())
 		}
 
 		requests = []respond.Request{r}
 		break
 	default:
-		respond.Fail(c, nil, respond.InvalidRequest, "invalid_request", "invalid request", nil)
-		return
+		return respond.BuildFail(nil, respond.InvalidRequest, "invalid_request", "invalid request", nil)
 	}
 
-	resps := make([]respond.Response[any], 0, len(requests))
-	for _, req := range requests {
-		if req.JSONRPC != respond.Version || req.Method == "" {
-			if req.ID != nil {
-				resps = append(resps, respond.BuildFail(req.ID, respond.InvalidRequest, "invalid_request", "invalid request", nil))
-			}
+	results := h.runAll(c, requests, headerKey, isBatch)
 
-			continue
+	resps := make([]respond.Response[any], 0, len(results))
+	for _, res := range results {
+		if res.ok {
+			resps = append(resps, res.resp)
 		}
+	}
 
-		result, rpcErr := h.registry.Call(req.Method, c, req.Params)
+	switch {
+	case len(resps) == 0:
+		return nil
+	case isBatch:
+		return resps
+	default:
+		return resps[0]
+	}
+}
 
-		if req.ID == nil {
-			if rpcErr != nil {
-				c.Logger().Warn("rpc notification error",
-					zap.String("method", req.Method),
-					zap.Any("error", rpcErr),
-				)
+type itemResult struct {
+	resp respond.Response[any]
+	ok   bool
+}
+
+// runAll executes the requests on a bounded worker pool, results keep the order of the requests.
+func (h *RPCHandler) runAll(c *rpc.HttpCtx, requests []respond.Request, headerKey string, isBatch bool) []itemResult {
+	results := make([]itemResult, len(requests))
+
+	run := func(i int) {
+		req := requests[i]
+		itemCtx := c.WithLogger(c.Logger().With(
+			zap.Int("batch_index", i),
+			zap.Any("rpc_id", req.ID),
+			zap.String("rpc_method", req.Method),
+		))
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				itemCtx.Logger().Error("rpc handler panic", zap.Any("panic", rec), zap.Stack("stack"))
+				results[i] = itemResult{
+					resp: respond.BuildFail(req.ID, respond.InternalError, "internal_error", "internal error", nil),
+					ok:   req.ID != nil,
+				}
 			}
-			continue
+		}()
+
+		resp, ok := h.handle(itemCtx, req, idempotencyKey(principalScope(c), headerKey, req, i, isBatch))
+		results[i] = itemResult{resp: resp, ok: ok}
+	}
+
+	workers := min(h.batch.Parallelism, len(requests))
+	if workers <= 1 {
+		for i := range requests {
+			run(i)
 		}
+		return results
+	}
 
-		if rpcErr != nil {
-			var appCode string
-			var details any
-			if rpcErr.Data != nil {
-				appCode = rpcErr.Data.Code
-				details = rpcErr.Data.Details
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run(i)
 			}
-			resps = append(resps, respond.BuildFail(req.ID, rpcErr.Code, appCode, rpcErr.Message, details))
-			continue
-		}
+		}()
+	}
+
+	for i := range requests {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// handle runs a single request and reports whether a response has to be sent back (i.e. it is not a notification).
+func (h *RPCHandler) handle(c *rpc.HttpCtx, req respond.Request, key string) (respond.Response[any], bool) {
+	if req.JSONRPC != respond.Version || req.Method == "" {
+		return respond.BuildFail(req.ID, respond.InvalidRequest, "invalid_request", "invalid request", nil), req.ID != nil
+	}
 
-		resps = append(resps, respond.BuildOK(req.ID, result))
+	if key != "" && h.idempotency != nil && h.registry.IsIdempotent(req.Method) {
+		return h.handleIdempotent(c, req, key)
 	}
 
+	return h.call(c, req)
+}
+
+func (h *RPCHandler) handleIdempotent(c *rpc.HttpCtx, req respond.Request, key string) (respond.Response[any], bool) {
+	hash := idempotency.HashRequest(req.Method, compactParams(req.Params))
+
+	stored, replay, err := h.idempotency.Begin(c.Context, key, hash)
 	switch {
-	case len(resps) == 0:
-		c.Status(http.StatusNoContent)
-	case len(requests) == 1 && isBatch:
-		c.JSON(http.StatusOK, resps)
-	case len(requests) == 1:
-		c.JSON(http.StatusOK, resps[0])
+	case errors.Is(err, idempotency.ErrKeyMismatch):
+		return respond.BuildFail(req.ID, respond.IdempotencyMismatch, "idempotency_key_mismatch", err.Error(), nil), req.ID != nil
+	case errors.Is(err, idempotency.ErrInProgress):
+		return respond.BuildFail(req.ID, respond.IdempotencyInProgress, "idempotency_in_progress", err.Error(), nil), req.ID != nil
+	case err != nil:
+		c.Logger().Error("idempotency begin", zap.String("method", req.Method), zap.Error(err))
+		return respond.BuildFail(req.ID, respond.InternalError, "idempotency", "failed to check idempotency key", nil), req.ID != nil
+	}
+
+	if replay {
+		var resp respond.Response[json.RawMessage]
+		if err := json.Unmarshal([]byte(stored), &resp); err != nil {
+			c.Logger().Error("idempotency replay", zap.String("method", req.Method), zap.Error(err))
+			return respond.BuildFail(req.ID, respond.InternalError, "idempotency", "failed to replay response", nil), req.ID != nil
+		}
+
+		c.Logger().Info("rpc replayed by idempotency key", zap.String("method", req.Method))
+
+		out := respond.Response[any]{JSONRPC: respond.Version, Error: resp.Error, ID: req.ID}
+		if resp.Result != nil {
+			var result any = *resp.Result
+			out.Result = &result
+		}
+		return out, req.ID != nil
+	}
+
+	resp, ok := h.call(c, req)
+
+	// only successful calls are remembered, a failed one can be retried with the same key. A timed out call
+	// may still complete, its key stays in progress until the marker expires after twice the method timeout.
+	if resp.Error != nil && resp.Error.Code == respond.TimeoutError {
+		return resp, ok
+	}
+	if resp.Error != nil {
+		if err := h.idempotency.Release(c.Context, key); err != nil {
+			c.Logger().Error("idempotency release", zap.String("method", req.Method), zap.Error(err))
+		}
+		return resp, ok
+	}
+
+	blob, err := json.Marshal(resp)
+	if err == nil {
+		err = h.idempotency.Complete(c.Context, key, string(blob))
+	}
+	if err != nil {
+		c.Logger().Error("idempotency complete", zap.String("method", req.Method), zap.Error(err))
+	}
+
+	return resp, ok
+}
+
+func (h *RPCHandler) call(c *rpc.HttpCtx, req respond.Request) (respond.Response[any], bool) {
+	result, rpcErr := h.registry.Call(req.Method, c, req.Params)
+
+	if rpcErr != nil {
+		if req.ID == nil {
+			c.Logger().Warn("rpc notification error",
+				zap.String("method", req.Method),
+				zap.Any("error", rpcErr),
+			)
+		}
+
+		var appCode string
+		var details any
+		if rpcErr.Data != nil {
+			appCode = rpcErr.Data.Code
+			details = rpcErr.Data.Details
+		}
+		return respond.BuildFail(req.ID, rpcErr.Code, appCode, rpcErr.Message, details), req.ID != nil
+	}
+
+	return respond.BuildOK(req.ID, result), req.ID != nil
+}
+
+// idempotencyKey prefers the idempotency_key param over the header.
+// Keys are scoped by caller, so two consumers picking the same key never see each other's responses,
+// and within a batch the header key is scoped per item so every item is tracked on its own.
+func idempotencyKey(scope string, headerKey string, req respond.Request, index int, isBatch bool) string {
+	var key string
+	switch paramKey := paramsIdempotencyKey(req.Params); {
+	case paramKey != "":
+		key = req.Method + ":" + paramKey
+	case headerKey == "":
+		return ""
+	case !isBatch:
+		key = req.Method + ":" + headerKey
+	case req.ID != nil:
+		key = fmt.Sprintf("%s:%s#%v", req.Method, headerKey, req.ID)
 	default:
-		c.JSON(http.StatusOK, resps)
+		key = fmt.Sprintf("%s:%s#%d", req.Method, headerKey, index)
+	}
+
+	if scope != "" {
+		key = scope + "|" + key
+	}
+
+	if len(key) > maxStoredKeyLength {
+		return idempotency.HashRequest(req.Method, []byte(key))
+	}
+
+	return key
+}
+
+func principalScope(c *rpc.HttpCtx) string {
+	if p := c.Principal(); p != nil {
+		return p.Key()
+	}
+	return ""
+}
+
+func paramsIdempotencyKey(params json.RawMessage) string {
+	p := bytes.TrimSpace(params)
+	if len(p) == 0 || p[0] != '{' {
+		return ""
+	}
+
+	var v struct {
+		IdempotencyKey string `json:"idempotency_key"`
+	}
+	if err := json.Unmarshal(p, &v); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(v.IdempotencyKey)
+}
+
+func compactParams(params json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, params); err != nil {
+		return params
 	}
+	return buf.Bytes()
 }
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"net/http"
+	"net/http/httptest"
+	"notification-service-api/internal/shared/rpc"
+	"notification-service-api/internal/shared/rpc/respond"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type echoParams struct {
+	Value   int `json:"value"`
+	SleepMS int `json:"sleep_ms"`
+}
+
+func newTestContext() *rpc.HttpCtx {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/rpc", nil)
+	return &rpc.HttpCtx{Context: c}
+}
+
+func TestDispatchBatchKeepsOrder(t *testing.T) {
+	const parallelism = 4
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	registry := rpc.NewRegistry()
+	registry.Register("test.echo", rpc.Typed(func(c *rpc.HttpCtx, p echoParams) (int, *respond.RPCError) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(time.Duration(p.SleepMS) * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return p.Value, nil
+	}))
+	handler := NewRPCHandler(registry, nil, BatchOptions{Parallelism: parallelism})
+
+	// later items finish first, every third one is a notification without an answer
+	var items []string
+	for i := 0; i < 12; i++ {
+		id := fmt.Sprintf(`,"id":%d`, i)
+		if i%3 == 2 {
+			id = ""
+		}
+		items = append(items, fmt.Sprintf(`{"jsonrpc":"2.0","method":"test.echo","params":{"value":%d,"sleep_ms":%d}%s}`, i, (12-i)*5, id))
+	}
+	items = append(items, `{"jsonrpc":"2.0","method":"test.missing","id":"missing"}`)
+
+	out := handler.Dispatch(newTestContext(), []byte("["+strings.Join(items, ",")+"]"), "")
+
+	resps, ok := out.([]respond.Response[any])
+	if !ok {
+		t.Fatalf("unexpected answer %#v", out)
+	}
+	var got []string
+	for _, resp := range resps {
+		switch {
+		case resp.Error != nil:
+			got = append(got, fmt.Sprintf("%v:%d", resp.ID, resp.Error.Code))
+		default:
+			result, _ := json.Marshal(resp.Result)
+			got = append(got, fmt.Sprintf("%v=%s", resp.ID, result))
+		}
+	}
+
+	want := "0=0 1=1 3=3 4=4 6=6 7=7 9=9 10=10 missing:-32601"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("answers %v, want %s", got, want)
+	}
+	if maxRunning < 2 || maxRunning > parallelism {
+		t.Fatalf("%d items ran at once, want 2 to %d", maxRunning, parallelism)
+	}
+}
+
+func TestDispatchRejectsOversizedBatch(t *testing.T) {
+	handler := NewRPCHandler(rpc.NewRegistry(), nil, BatchOptions{Parallelism: 2, MaxSize: 2})
+
+	item := `{"jsonrpc":"2.0","method":"rpc.discover","id":1}`
+	out := handler.Dispatch(newTestContext(), []byte("["+strings.Repeat(item+",", 2)+item+"]"), "")
+
+	resp, ok := out.(respond.Response[any])
+	if !ok || resp.Error == nil || resp.Error.Data.Code != "batch_too_large" {
+		t.Fatalf("unexpected answer %#v", out)
+	}
+}
+
+func TestDispatchOnlyNotifications(t *testing.T) {
+	handler := NewRPCHandler(rpc.NewRegistry(), nil, BatchOptions{Parallelism: 2})
+
+	out := handler.Dispatch(newTestContext(), []byte(`[{"jsonrpc":"2.0","method":"rpc.discover"}]`), "")
+	if out != nil {
+		t.Fatalf("notifications were answered: %#v", out)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"notification-service-api/internal/shared/rpc"
+	"notification-service-api/internal/shared/rpc/respond"
+	"sync"
+	"time"
+)
+
+const (
+	wsWriteWait      = 10 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsPingInterval   = wsPongWait * 9 / 10
+	wsMaxMessageSize = 1 << 20
+	wsSendBuffer     = 64
+	// wsMaxInFlight bounds the messages of one connection handled at the same time, reading stops above it
+	wsMaxInFlight = 16
+)
+
+var errSessionClosed = errors.New("websocket session closed")
+
+// WSHandler serves JSON-RPC over WebSocket: every text message is a request or a batch,
+// answers and server-pushed notifications are sent back on the same connection.
+type WSHandler struct {
+	rpc      *RPCHandler
+	upgrader websocket.Upgrader
+}
+
+// NewWSHandler accepts connections from the given origins, "*" allows any.
+// Requests without an Origin header (non-browser clients) are always accepted.
+func NewWSHandler(rpcHandler *RPCHandler, allowedOrigins []string) *WSHandler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+
+	return &WSHandler{
+		rpc: rpcHandler,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" || allowed["*"] || allowed[origin] {
+					return true
+				}
+
+				u, err := url.Parse(origin)
+				return err == nil && u.Host == r.Host
+			},
+		},
+	}
+}
+
+func (h *WSHandler) Serve(c *rpc.HttpCtx) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has already answered with an HTTP error
+		c.Logger().Warn("websocket upgrade failed", zap.Error(err))
+		return
+	}
+
+	session := newWSSession(conn)
+	logger := c.Logger().With(zap.String("ws_session", session.id))
+	sc := c.WithLogger(logger).WithSession(session)
+
+	logger.Info("websocket connected")
+
+	go session.writeLoop(logger)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, wsMaxInFlight)
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Warn("websocket read failed", zap.Error(err))
+			}
+			break
+		}
+
+		inFlight <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-inFlight
+				wg.Done()
+			}()
+
+			out := h.rpc.Dispatch(sc, msg, "")
+			if out == nil {
+				return
+			}
+			if err := session.send(out); err != nil && !errors.Is(err, errSessionClosed) {
+				logger.Warn("websocket response not sent", zap.Error(err))
+			}
+		}()
+	}
+
+	session.close()
+	// the gin context is recycled once Serve returns, in-flight calls still use it
+	wg.Wait()
+
+	logger.Info("websocket disconnected")
+}
+
+type wsSession struct {
+	id        string
+	conn      *websocket.Conn
+	out       chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newWSSession(conn *websocket.Conn) *wsSession {
+	return &wsSession{
+		id:   uuid.NewString(),
+		conn: conn,
+		out:  make(chan []byte, wsSendBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+func (s *wsSession) ID() string {
+	return s.id
+}
+
+func (s *wsSession) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *wsSession) Close() {
+	s.close()
+}
+
+func (s *wsSession) Notify(method string, params any) error {
+	return s.send(respond.Notification[any]{
+		JSONRPC: respond.Version,
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// send queues a message for the writer, a peer that does not read for wsWriteWait is dropped.
+func (s *wsSession) send(v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(wsWriteWait)
+	defer timer.Stop()
+
+	select {
+	case s.out <- payload:
+		return nil
+	case <-s.done:
+		return errSessionClosed
+	case <-timer.C:
+		s.close()
+		return errors.New("websocket peer is too slow")
+	}
+}
+
+func (s *wsSession) writeLoop(logger *zap.Logger) {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	// closing the connection also unblocks the reader
+	defer s.conn.Close()
+
+	for {
+		select {
+		case payload := <-s.out:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				logger.Warn("websocket write failed", zap.Error(err))
+				s.close()
+				return
+			}
+		case <-ticker.C:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				s.close()
+				return
+			}
+		case <-s.done:
+			_ = s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+			return
+		}
+	}
+}
+
+func (s *wsSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
@@ -1,17 +1,73 @@
 package rpc
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"time"
 )
 
 // HttpCtx http context (implements gin.Context and respond.Ctx)
 type HttpCtx struct {
 	*gin.Context
+	logger  *zap.Logger
+	ctx     context.Context
+	session Session
 }
 
 func Wrap(h func(*HttpCtx)) gin.HandlerFunc {
-	return func(c *gin.Context) { h(&HttpCtx{c}) }
+	return func(c *gin.Context) { h(&HttpCtx{Context: c}) }
+}
+
+// WithLogger returns a copy of the context bound to its own logger, e.g. for a single batch item.
+func (h *HttpCtx) WithLogger(logger *zap.Logger) *HttpCtx {
+	cp := *h
+	cp.logger = logger
+	return &cp
+}
+
+// WithContext returns a copy whose deadline, cancellation and values come from ctx, e.g. a per-method timeout.
+// ctx should be derived from h so the gin values stay reachable.
+func (h *HttpCtx) WithContext(ctx context.Context) *HttpCtx {
+	cp := *h
+	cp.ctx = ctx
+	return &cp
+}
+
+// Detached returns a copy backed by a copy of the gin context, safe to use after the request is answered
+// and gin reuses its context.
+func (h *HttpCtx) Detached() *HttpCtx {
+	cp := *h
+	cp.Context = h.Context.Copy()
+	return &cp
+}
+
+func (h *HttpCtx) Deadline() (time.Time, bool) {
+	if h.ctx != nil {
+		return h.ctx.Deadline()
+	}
+	return h.Context.Deadline()
+}
+
+func (h *HttpCtx) Done() <-chan struct{} {
+	if h.ctx != nil {
+		return h.ctx.Done()
+	}
+	return h.Context.Done()
+}
+
+func (h *HttpCtx) Err() error {
+	if h.ctx != nil {
+		return h.ctx.Err()
+	}
+	return h.Context.Err()
+}
+
+func (h *HttpCtx) Value(key any) any {
+	if h.ctx != nil {
+		return h.ctx.Value(key)
+	}
+	return h.Context.Value(key)
 }
 
 func (h *HttpCtx) RequestID() string {
@@ -31,5 +87,20 @@ func (h *HttpCtx) Endpoint() string {
 }
 
 func (h *HttpCtx) Logger() *zap.Logger {
+	if h.logger != nil {
+		return h.logger
+	}
+
 	return FromLogger(h.Context)
 }
+
+// Principal returns the caller set by the auth middleware, nil when the request is not authenticated.
+func (h *HttpCtx) Principal() *Principal {
+	if v, ok := h.Get(CtxKeyPrincipal); ok {
+		if p, ok := v.(*Principal); ok {
+			return p
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"notification-service-api/internal/shared/rpc/respond"
+	"strings"
+)
+
+// CallInfo describes the procedure an interceptor is running around.
+type CallInfo struct {
+	Method string
+}
+
+// Interceptor wraps a method call, it must call next to continue the chain.
+type Interceptor func(c *HttpCtx, info CallInfo, params any, next Handler) (any, *respond.RPCError)
+
+type scopedInterceptor struct {
+	pattern     string
+	interceptor Interceptor
+}
+
+// Use registers interceptors for methods matching the pattern: "*" for every method,
+// "email.*" for a namespace or "email.send" for a single method.
+// Global interceptors run first, then namespace ones, then method ones, each level in registration order.
+func (r *Registry) Use(pattern string, interceptors ...Interceptor) {
+	r.mu.Lock()
+	for _, ic := range interceptors {
+		r.interceptors = append(r.interceptors, scopedInterceptor{pattern: pattern, interceptor: ic})
+	}
+	r.chains = nil
+	r.mu.Unlock()
+}
+
+// MatchMethod reports whether a method name matches a pattern as accepted by Registry.Use.
+func MatchMethod(pattern string, method string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(method, strings.TrimSuffix(pattern, "*"))
+	default:
+		return pattern == method
+	}
+}
+
+func patternLevel(pattern string) int {
+	switch {
+	case pattern == "*":
+		return 0
+	case strings.HasSuffix(pattern, ".*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// chain must be called with r.mu held for writing.
+func (r *Registry) chain(name string, m *Method) Handler {
+	if h, ok := r.chains[name]; ok {
+		return h
+	}
+
+	var matched []Interceptor
+	for level := 0; level <= 2; level++ {
+		for _, si := range r.interceptors {
+			if patternLevel(si.pattern) == level && MatchMethod(si.pattern, name) {
+				matched = append(matched, si.interceptor)
+			}
+		}
+	}
+
+	h := m.handler
+	info := CallInfo{Method: name}
+	for i := len(matched) - 1; i >= 0; i-- {
+		ic, next := matched[i], h
+		h = func(c *HttpCtx, params any) (any, *respond.RPCError) {
+			return ic(c, info, params, next)
+		}
+	}
+
+	if r.chains == nil {
+		r.chains = make(map[string]Handler)
+	}
+	r.chains[name] = h
+
+	return h
+}
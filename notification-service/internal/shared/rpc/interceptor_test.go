@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"notification-service-api/internal/shared/rpc/respond"
+	"strings"
+	"testing"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(c *HttpCtx, info CallInfo, params any, next Handler) (any, *respond.RPCError) {
+			calls = append(calls, name+">"+info.Method)
+			result, err := next(c, params)
+			calls = append(calls, "<"+name)
+			return result, err
+		}
+	}
+
+	registry := NewRegistry()
+	registry.Register("email.send", Typed(func(c *HttpCtx, _ struct{}) (string, *respond.RPCError) {
+		calls = append(calls, "handler")
+		return "ok", nil
+	}))
+	// registered out of order, levels still run global, namespace, method
+	registry.Use("email.send", record("method"))
+	registry.Use("email.*", record("namespace"))
+	registry.Use("*", record("global1"), record("global2"))
+	registry.Use("sms.*", record("other"))
+
+	result, err := registry.Call("email.send", &HttpCtx{}, nil)
+	if err != nil || result != "ok" {
+		t.Fatalf("result %v, err %v", result, err)
+	}
+
+	want := "global1>email.send global2>email.send namespace>email.send method>email.send handler <method <namespace <global2 <global1"
+	if got := strings.Join(calls, " "); got != want {
+		t.Fatalf("calls\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestInterceptorShortCircuits(t *testing.T) {
+	called := false
+
+	registry := NewRegistry()
+	registry.Register("apikey.create", Typed(func(c *HttpCtx, _ struct{}) (string, *respond.RPCError) {
+		called = true
+		return "ok", nil
+	}))
+	registry.Use("apikey.*", func(c *HttpCtx, info CallInfo, params any, next Handler) (any, *respond.RPCError) {
+		return nil, respond.NewRPCError(respond.AuthError, "forbidden", "forbidden", nil)
+	})
+
+	_, err := registry.Call("apikey.create", &HttpCtx{}, nil)
+	if err == nil || err.Code != respond.AuthError || called {
+		t.Fatalf("err %v, handler called %v, want the interceptor to stop the call", err, called)
+	}
+}
+
+func TestInterceptorAddedAfterCall(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("sms.send", Typed(func(c *HttpCtx, _ struct{}) (string, *respond.RPCError) {
+		return "ok", nil
+	}))
+	if _, err := registry.Call("sms.send", &HttpCtx{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cached chain is rebuilt
+	registry.Use("*", func(c *HttpCtx, info CallInfo, params any, next Handler) (any, *respond.RPCError) {
+		return "intercepted", nil
+	})
+	if result, _ := registry.Call("sms.send", &HttpCtx{}, nil); result != "intercepted" {
+		t.Fatalf("result %v, want the new interceptor to run", result)
+	}
+}
+
+func TestMatchMethod(t *testing.T) {
+	tests := []struct {
+		pattern, method string
+		want            bool
+	}{
+		{"*", "email.send", true},
+		{"email.*", "email.send", true},
+		{"email.*", "emails.send", false},
+		{"email.send", "email.send", true},
+		{"email.send", "email.sender", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchMethod(tt.pattern, tt.method); got != tt.want {
+			t.Errorf("MatchMethod(%q, %q) = %v, want %v", tt.pattern, tt.method, got, tt.want)
+		}
+	}
+}
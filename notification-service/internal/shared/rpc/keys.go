@@ -14,6 +14,7 @@ const (
 	CtxKeyClientIP  = "client_ip"
 	CtxKeyUserAgent = "user_agent"
 	CtxKeyHeaders   = "client_headers"
+	CtxKeyPrincipal = "principal"
 )
 
 var baseLogger *zap.Logger
@@ -60,6 +60,9 @@ func AccessLogMiddleware() gin.HandlerFunc {
 			zap.Duration("latency", latency),
 			zap.Int64("latency_ms", latencyMs),
 		}
+		if query := c.Request.URL.Query(); len(query) > 0 {
+			fields = append(fields, zap.String("query", redactQuery(query).Encode()))
+		}
 
 		switch {
 		case status >= 500:
@@ -81,9 +84,14 @@ func snapshotRequestBody(r *http.Request) string {
 
 	switch mt {
 	case "application/json", "text/plain", "":
-		b, _ := io.ReadAll(r.Body)
+		b, err := io.ReadAll(r.Body)
 		_ = r.Body.Close()
-		r.Body = io.NopCloser(bytes.NewBuffer(b))
+		if err != nil {
+			// the handler sees the error too, e.g. a body over the size limit, after the bytes that were read
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(b), errReader{err: err}))
+		} else {
+			r.Body = io.NopCloser(bytes.NewBuffer(b))
+		}
 
 		// TODO: mask attachment base64 with good performance
 		return maskAttachmentBase64(b)
@@ -94,6 +102,14 @@ func snapshotRequestBody(r *http.Request) string {
 	}
 }
 
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
 func truncateForLog(b []byte) string {
 	if len(b) == 0 {
 		return ""
@@ -118,11 +134,31 @@ func redactSecrets(s string) string {
 	return r.Replace(s)
 }
 
+// GinLogFormatter is the gin request log line with the query masked, WebSocket clients pass their credentials there.
+func GinLogFormatter(params gin.LogFormatterParams) string {
+	if path, query, ok := strings.Cut(params.Path, "?"); ok {
+		params.Path = path
+		if values, err := url.ParseQuery(query); err == nil {
+			params.Path += "?" + redactQuery(values).Encode()
+		}
+	}
+
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+		params.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		params.StatusCode,
+		params.Latency,
+		params.ClientIP,
+		params.Method,
+		params.Path,
+		params.ErrorMessage,
+	)
+}
+
 func redactQuery(v url.Values) url.Values {
 	out := url.Values{}
 	for k, vals := range v {
 		kl := strings.ToLower(k)
-		if kl == "password" || kl == "passwd" || kl == "token" || kl == "authorization" {
+		if kl == "password" || kl == "passwd" || kl == "token" || kl == "authorization" || kl == "api_key" || kl == "access_token" {
 			out[k] = []string{"***"}
 			continue
 		}
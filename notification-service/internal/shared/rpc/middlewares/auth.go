@@ -2,33 +2,125 @@ package middlewares
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 	"net/http"
+	"notification-service-api/internal/auth/app"
+	"notification-service-api/internal/shared/rpc"
 	"notification-service-api/internal/shared/rpc/respond"
+	"notification-service-api/internal/shared/signature"
 	"notification-service-api/pkg/utils"
+	"strings"
 )
 
-func AuthMiddleware(configuration *utils.Config, logger *zap.Logger) gin.HandlerFunc {
+// AuthMiddleware resolves the caller to a principal: from an HMAC signature (X-Signature headers),
+// an `Authorization: Bearer` JWT or X-API-KEY, in that order.
+// MASTER_TOKEN stays a bootstrap admin allowed every method, other keys are looked up in the database;
+// both keys and tokens are limited to their methods by ScopesInterceptor, admin methods only by an explicit scope.
+func AuthMiddleware(configuration *utils.Config, apiKeys *app.APIKeyService, jwtVerifier *utils.JWTVerifier, signatures *signature.Verifier, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !configuration.IsSecure {
+			// without auth every caller is trusted, the admin methods included
+			setPrincipal(c, &rpc.Principal{ID: rpc.PrincipalKindAnonymous, Kind: rpc.PrincipalKindAnonymous, Scopes: []string{"*", rpc.AdminNamespace + "*"}})
+			c.Next()
+			return
+		}
+
+		if signature.IsSigned(c.Request) {
+			principal, err := verifySignature(c, signatures)
+			if err != nil {
+				rpc.FromGin(c).Info("signature rejected", zap.Error(err))
+				c.AbortWithStatusJSON(http.StatusOK, returnUnauthorized())
+				return
+			}
+
+			setPrincipal(c, principal)
+			c.Next()
+			return
+		}
+
+		if bearer := bearerToken(c); bearer != "" {
+			claims, err := jwtVerifier.Verify(bearer)
+			if err != nil {
+				rpc.FromGin(c).Info("bearer token rejected", zap.Error(err))
+				c.AbortWithStatusJSON(http.StatusOK, returnUnauthorized())
+				return
+			}
+
+			setPrincipal(c, &rpc.Principal{
+				ID:     claims.Subject,
+				Kind:   rpc.PrincipalKindJWT,
+				Scopes: claims.Scopes,
+				Tenant: claims.Tenant,
+				Claims: claims.Raw,
+			})
 			c.Next()
 			return
 		}
 
 		token := c.GetHeader("X-API-KEY")
+		// browsers cannot set headers on a WebSocket handshake
+		if token == "" && websocket.IsWebSocketUpgrade(c.Request) {
+			token = c.Query("api_key")
+		}
 		if token == "" {
 			c.AbortWithStatusJSON(http.StatusOK, returnUnauthorized())
 			return
 		}
 
-		if configuration.MasterToken == token {
+		if configuration.MasterToken != "" && configuration.MasterToken == token {
+			setPrincipal(c, &rpc.Principal{ID: rpc.PrincipalKindMaster, Kind: rpc.PrincipalKindMaster, Scopes: []string{"*", rpc.AdminNamespace + "*"}})
 			c.Next()
 			return
 		}
 
-		c.AbortWithStatusJSON(http.StatusOK, returnUnauthorized())
-		return
+		key, err := apiKeys.Authenticate(c, token)
+		if err != nil {
+			rpc.FromGin(c).Info("api key rejected", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusOK, returnUnauthorized())
+			return
+		}
+
+		setPrincipal(c, &rpc.Principal{
+			ID:         key.ID.String(),
+			Name:       key.Name,
+			Kind:       rpc.PrincipalKindAPIKey,
+			Scopes:     key.Methods,
+			RateLimits: key.RateLimits,
+		})
+		c.Next()
+	}
+}
+
+// setPrincipal stores the caller and adds its identity to the request logger.
+func setPrincipal(c *gin.Context, principal *rpc.Principal) {
+	c.Set(rpc.CtxKeyPrincipal, principal)
+
+	fields := []zap.Field{
+		zap.String("principal_id", principal.ID),
+		zap.String("principal_kind", principal.Kind),
+	}
+	if principal.Name != "" {
+		fields = append(fields, zap.String("principal_name", principal.Name))
+	}
+	if principal.Tenant != "" {
+		fields = append(fields, zap.String("tenant", principal.Tenant))
+	}
+
+	c.Set(rpc.CtxKeyLogger, rpc.FromGin(c).With(fields...))
+}
+
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
 	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		return c.Query("access_token")
+	}
+
+	return ""
 }
 
 func returnUnauthorized() respond.Response[any] {
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"notification-service-api/internal/shared/rpc/respond"
+)
+
+// BodyLimitMiddleware refuses request bodies over maxBytes. A body without Content-Length is cut at the limit,
+// readers then get *http.MaxBytesError. It runs first, before anything buffers the body.
+func BodyLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusOK, returnTooLarge(maxBytes))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+func returnTooLarge(maxBytes int64) respond.Response[any] {
+	return respond.BuildFail(nil, respond.InvalidRequest, "request_too_large", "request body is too large", map[string]int64{
+		"limit_bytes": maxBytes,
+	})
+}
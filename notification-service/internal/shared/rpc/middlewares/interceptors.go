@@ -0,0 +1,286 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+	"math"
+	"notification-service-api/internal/shared/ratelimit"
+	"notification-service-api/internal/shared/rpc"
+	"notification-service-api/internal/shared/rpc/respond"
+	"notification-service-api/pkg/monotime"
+	"notification-service-api/pkg/utils"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var methodInFlight atomic.Int64
+
+// RecoveryInterceptor turns a panic inside the method into an internal error instead of failing the whole request.
+func RecoveryInterceptor() rpc.Interceptor {
+	return func(c *rpc.HttpCtx, info rpc.CallInfo, params any, next rpc.Handler) (result any, rpcErr *respond.RPCError) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.Logger().Error("rpc method panic",
+					zap.String("rpc_method", info.Method),
+					zap.Any("panic", rec),
+					zap.Stack("stack"),
+				)
+				result, rpcErr = nil, respond.NewRPCError(respond.InternalError, "internal_error", "internal error", nil)
+			}
+		}()
+
+		return next(c, params)
+	}
+}
+
+// timeoutGrace is how long a cancelled method may take to return before the timeout is answered without it.
+const timeoutGrace = 2 * time.Second
+
+// TimeoutInterceptor cancels the method context after the timeout and answers with a timeout error.
+// The cancelled method is waited for a little, so work it completed anyway is reported as done rather than
+// retried by the client. A method still running after that keeps a copy of the gin context, gin reuses the original.
+func TimeoutInterceptor(timeout time.Duration) rpc.Interceptor {
+	return func(c *rpc.HttpCtx, info rpc.CallInfo, params any, next rpc.Handler) (any, *respond.RPCError) {
+		if timeout <= 0 {
+			return next(c, params)
+		}
+
+		ctx, cancel := context.WithTimeout(c, timeout)
+		defer cancel()
+
+		type outcome struct {
+			result any
+			err    *respond.RPCError
+		}
+
+		detached := c.Detached().WithContext(ctx)
+		done := make(chan outcome, 1)
+		go func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					c.Logger().Error("rpc method panic",
+						zap.String("rpc_method", info.Method),
+						zap.Any("panic", rec),
+						zap.Stack("stack"),
+					)
+					done <- outcome{err: respond.NewRPCError(respond.InternalError, "internal_error", "internal error", nil)}
+				}
+			}()
+
+			result, err := next(detached, params)
+			done <- outcome{result: result, err: err}
+		}()
+
+		select {
+		case out := <-done:
+			return out.result, out.err
+		case <-ctx.Done():
+			select {
+			case out := <-done:
+				if out.err == nil {
+					return out.result, nil
+				}
+			case <-time.After(timeoutGrace):
+				c.Logger().Error("rpc method ignores cancellation", zap.String("rpc_method", info.Method))
+			}
+
+			c.Logger().Warn("rpc method timed out", zap.String("rpc_method", info.Method), zap.Duration("timeout", timeout))
+			return nil, respond.NewRPCError(respond.TimeoutError, "timeout", "method timed out", map[string]int64{
+				"timeout_ms": timeout.Milliseconds(),
+			})
+		}
+	}
+}
+
+// ScopesInterceptor allows the call only when a principal scope matches the method name,
+// and, when given, every one of the required scopes.
+func ScopesInterceptor(required ...string) rpc.Interceptor {
+	return func(c *rpc.HttpCtx, info rpc.CallInfo, params any, next rpc.Handler) (any, *respond.RPCError) {
+		principal := c.Principal()
+		if !principal.Allows(info.Method) {
+			return nil, forbidden(info.Method)
+		}
+
+		for _, scope := range required {
+			if !principal.Allows(scope) {
+				return nil, forbidden(info.Method)
+			}
+		}
+
+		return next(c, params)
+	}
+}
+
+func forbidden(method string) *respond.RPCError {
+	return respond.NewRPCError(respond.AuthError, "Forbidden", "Method is not allowed for this token", map[string]string{
+		"method": method,
+	})
+}
+
+// StatisticsInterceptor is StatisticsMiddleware at method granularity, every call is tagged with its method and outcome.
+func StatisticsInterceptor(influx *utils.InfluxDB, logger *zap.Logger) rpc.Interceptor {
+	return func(c *rpc.HttpCtx, info rpc.CallInfo, params any, next rpc.Handler) (any, *respond.RPCError) {
+		start := time.Now()
+		_ = methodInFlight.Add(1)
+
+		result, rpcErr := next(c, params)
+
+		lat := time.Since(start)
+		cur := methodInFlight.Add(-1)
+
+		status := "ok"
+		errorCode := 0
+		if rpcErr != nil {
+			status = "error"
+			errorCode = int(rpcErr.Code)
+		}
+
+		tags := map[string]string{
+			"service": "notification",
+			"env":     os.Getenv("SERVICE_ENV"),
+			"method":  info.Method,
+			"status":  status,
+		}
+
+		fields := map[string]interface{}{
+			"counter":     1,
+			"duration_ms": float64(lat.Milliseconds()),
+			"inflight":    cur,
+			"error_code":  errorCode,
+		}
+
+		if err := influx.Send("notification_rpc_method", tags, fields, monotime.NowNanoUnique()); err != nil {
+			logger.Error("Send to Influx error", zap.Error(err))
+		}
+
+		return result, rpcErr
+	}
+}
+
+// AccessLogInterceptor is AccessLogMiddleware at method granularity: one line per call, batch items included.
+func AccessLogInterceptor() rpc.Interceptor {
+	return func(c *rpc.HttpCtx, info rpc.CallInfo, params any, next rpc.Handler) (any, *respond.RPCError) {
+		start := time.Now()
+
+		result, rpcErr := next(c, params)
+
+		latency := time.Since(start)
+		msg := "RPC " + info.Method +
+			" in " + latency.Truncate(time.Millisecond).String() +
+			" | in: " + snapshotParams(params)
+
+		fields := []zap.Field{
+			zap.String("rpc_method", info.Method),
+			zap.Duration("latency", latency),
+			zap.Int64("latency_ms", latency.Milliseconds()),
+		}
+
+		log := c.Logger()
+		switch {
+		case rpcErr == nil:
+			log.Info(msg, fields...)
+		case rpcErr.Code == respond.InternalError || rpcErr.Code == respond.TimeoutError:
+			log.Error(msg+" | error: "+strconv.Itoa(int(rpcErr.Code))+" "+rpcErr.Message, fields...)
+		default:
+			log.Warn(msg+" | error: "+strconv.Itoa(int(rpcErr.Code))+" "+rpcErr.Message, fields...)
+		}
+
+		return result, rpcErr
+	}
+}
+
+// AuditInterceptor writes who called what and with which outcome, meant for methods with side effects.
+func AuditInterceptor(logger *zap.Logger) rpc.Interceptor {
+	audit := logger.Named("audit")
+
+	return func(c *rpc.HttpCtx, info rpc.CallInfo, params any, next rpc.Handler) (any, *respond.RPCError) {
+		result, rpcErr := next(c, params)
+
+		principalID, principalKind := "", ""
+		if p := c.Principal(); p != nil {
+			principalID, principalKind = p.ID, p.Kind
+		}
+
+		outcome := "ok"
+		if rpcErr != nil {
+			outcome = fmt.Sprintf("error %d", rpcErr.Code)
+		}
+
+		audit.Info(fmt.Sprintf("%s called %s: %s", principalID, info.Method, outcome),
+			zap.String(rpc.CtxKeyRequestID, c.RequestID()),
+			zap.String("rpc_method", info.Method),
+			zap.String("principal_id", principalID),
+			zap.String("principal_kind", principalKind),
+			zap.String("outcome", outcome),
+		)
+
+		return result, rpcErr
+	}
+}
+
+func snapshotParams(params any) string {
+	switch v := params.(type) {
+	case nil:
+		return ""
+	case json.RawMessage:
+		return maskAttachmentBase64(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return maskAttachmentBase64(b)
+	}
+}
+
+// RateLimitInterceptor takes a token from the bucket of the caller and method, every batch item counts.
+// Limits of the principal (API key) win over the defaults, a Redis failure lets the call through.
+func RateLimitInterceptor(limiter *ratelimit.Limiter, defaults ratelimit.Rules, logger *zap.Logger) rpc.Interceptor {
+	return func(c *rpc.HttpCtx, info rpc.CallInfo, params any, next rpc.Handler) (any, *respond.RPCError) {
+		principal := c.Principal()
+		if principal == nil {
+			return next(c, params)
+		}
+
+		pattern, limit, ok := defaults.For(info.Method)
+		if len(principal.RateLimits) > 0 {
+			own, err := ratelimit.RulesFromMap(principal.RateLimits)
+			if err != nil {
+				logger.Warn("invalid rate limits of "+principal.Key(), zap.Error(err))
+			} else if ownPattern, ownLimit, found := own.For(info.Method); found {
+				pattern, limit, ok = ownPattern, ownLimit, true
+			}
+		}
+		if !ok {
+			return next(c, params)
+		}
+
+		// methods sharing a pattern share its bucket, e.g. "email.*" limits all email methods together
+		bucket := principal.Key() + ":" + pattern
+		if !strings.HasSuffix(pattern, "*") {
+			bucket = principal.Key() + ":" + info.Method
+		}
+
+		res, err := limiter.Allow(c, bucket, limit)
+		if err != nil {
+			logger.Error("rate limiter unavailable", zap.Error(err))
+			return next(c, params)
+		}
+
+		if !res.Allowed {
+			retryAfter := int64(math.Ceil(res.RetryAfter.Seconds()))
+			return nil, respond.NewRPCError(respond.RateLimited, "rate_limited", "rate limit exceeded", map[string]any{
+				"limit":          limit.String(),
+				"retry_after":    retryAfter,
+				"retry_after_ms": res.RetryAfter.Milliseconds(),
+			})
+		}
+
+		return next(c, params)
+	}
+}
@@ -0,0 +1,389 @@
+package rpc
+
+import (
+	"encoding"
+	"github.com/goccy/go-json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const OpenRPCVersion = "1.2.6"
+
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []OpenRPCMethod   `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+type OpenRPCInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name           string                     `json:"name"`
+	Summary        string                     `json:"summary,omitempty"`
+	ParamStructure string                     `json:"paramStructure"`
+	Params         []OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor  `json:"result,omitempty"`
+}
+
+type OpenRPCContentDescriptor struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type OpenRPCComponents struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is the subset of JSON Schema (draft-07) produced from Go types and their `validate` tags.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+
+	// PropertyOrder keeps the struct field order, JSON objects lose it.
+	PropertyOrder []string `json:"-"`
+}
+
+// TypeName returns the primary JSON type of the schema, ignoring "null".
+func (s *Schema) TypeName() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []string:
+		for _, v := range t {
+			if v != "null" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Schema) Nullable() bool {
+	if t, ok := s.Type.([]string); ok {
+		for _, v := range t {
+			if v == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type schemaGenerator struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		defs:  make(map[string]*Schema),
+		names: make(map[reflect.Type]string),
+	}
+}
+
+func (g *schemaGenerator) method(name string, m *Method) OpenRPCMethod {
+	out := OpenRPCMethod{
+		Name:           name,
+		Summary:        m.summary,
+		ParamStructure: "by-name",
+		Params:         []OpenRPCContentDescriptor{},
+	}
+
+	if m.params != nil {
+		pt := m.params
+		for pt.Kind() == reflect.Ptr {
+			pt = pt.Elem()
+		}
+
+		if pt.Kind() == reflect.Struct && pt != timeType {
+			obj := g.object(pt)
+			required := make(map[string]bool, len(obj.Required))
+			for _, r := range obj.Required {
+				required[r] = true
+			}
+			for _, prop := range obj.PropertyOrder {
+				schema := obj.Properties[prop]
+				out.Params = append(out.Params, OpenRPCContentDescriptor{
+					Name:        prop,
+					Description: schema.Description,
+					Required:    required[prop],
+					Schema:      schema,
+				})
+			}
+		} else {
+			out.ParamStructure = "either"
+			out.Params = append(out.Params, OpenRPCContentDescriptor{
+				Name:     "params",
+				Required: true,
+				Schema:   g.schemaFor(pt),
+			})
+		}
+	}
+
+	if m.result != nil {
+		out.Result = &OpenRPCContentDescriptor{
+			Name:   "result",
+			Schema: g.schemaFor(m.result),
+		}
+	}
+
+	return out
+}
+
+// Resolve follows a components $ref, schemas without one are returned as is.
+func (d *OpenRPCDocument) Resolve(s *Schema) *Schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	if def, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]; ok {
+		return def
+	}
+	return s
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := g.schemaFor(t.Elem())
+		if s.Ref == "" {
+			s.Type = nullable(s.Type)
+		}
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		if t.Kind() == reflect.Array && t.Len() == 16 {
+			return &Schema{Type: "string", Format: "uuid"}
+		}
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.define(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+func (g *schemaGenerator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+
+	g.names[t] = name
+	g.defs[name] = &Schema{} // placeholder, breaks recursion for self-referencing types
+	g.defs[name] = g.object(t)
+
+	return name
+}
+
+func (g *schemaGenerator) object(t reflect.Type) *Schema {
+	obj := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, obj)
+	return obj
+}
+
+func (g *schemaGenerator) fields(t reflect.Type, obj *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, obj)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := g.schemaFor(f.Type)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			if schema.Ref != "" {
+				schema = &Schema{Ref: schema.Ref, Description: doc}
+			} else {
+				schema.Description = doc
+			}
+		}
+
+		if applyValidateTag(schema, f.Tag.Get("validate")) {
+			obj.Required = append(obj.Required, name)
+		}
+
+		obj.Properties[name] = schema
+		obj.PropertyOrder = append(obj.PropertyOrder, name)
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// applyValidateTag maps go-playground/validator rules onto the schema and reports whether the field is required.
+// Rules after "dive" describe the items of a slice or map.
+func applyValidateTag(s *Schema, tag string) bool {
+	if tag == "" || tag == "-" {
+		return false
+	}
+
+	own, itemRules, hasDive := strings.Cut(tag, ",dive")
+	if hasDive && s.Items != nil && s.Items.Ref == "" {
+		applyValidateTag(s.Items, strings.TrimPrefix(itemRules, ","))
+	}
+
+	required := false
+	for _, rule := range strings.Split(own, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri", "http_url":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "datetime":
+			s.Format = "date-time"
+		case "e164":
+			s.Pattern = `^\+[1-9]\d{1,14}$`
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "min", "gte":
+			setBound(s, param, true)
+		case "max", "lte":
+			setBound(s, param, false)
+		case "len":
+			setBound(s, param, true)
+			setBound(s, param, false)
+		case "gt":
+			if n, err := strconv.ParseFloat(param, 64); err == nil {
+				s.ExclusiveMinimum = &n
+			}
+		case "lt":
+			if n, err := strconv.ParseFloat(param, 64); err == nil {
+				s.ExclusiveMaximum = &n
+			}
+		}
+	}
+
+	return required
+}
+
+func setBound(s *Schema, param string, lower bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	i := int(n)
+
+	switch s.TypeName() {
+	case "string":
+		if lower {
+			s.MinLength = &i
+		} else {
+			s.MaxLength = &i
+		}
+	case "array":
+		if lower {
+			s.MinItems = &i
+		} else {
+			s.MaxItems = &i
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
+
+func nullable(t any) any {
+	switch v := t.(type) {
+	case string:
+		return []string{v, "null"}
+	case []string:
+		return v
+	default:
+		return t
+	}
+}
@@ -0,0 +1,63 @@
+package rpc
+
+import "strings"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string
+	Name   string
+	Kind   string
+	Scopes []string
+	Tenant string
+	// RateLimits overrides the default limits, method pattern -> limit like "10/m".
+	RateLimits map[string]string
+	// Claims holds every claim of a bearer token, nil for other kinds.
+	Claims map[string]any
+}
+
+const (
+	PrincipalKindAnonymous = "anonymous"
+	PrincipalKindMaster    = "master"
+	PrincipalKindAPIKey    = "api_key"
+	PrincipalKindJWT       = "jwt"
+	PrincipalKindHMAC      = "hmac"
+)
+
+// Key identifies the principal across kinds and tenants, e.g. to own resources.
+func (p *Principal) Key() string {
+	if p.Tenant != "" {
+		return p.Kind + ":" + p.Tenant + "/" + p.ID
+	}
+	return p.Kind + ":" + p.ID
+}
+
+// AdminNamespace holds the methods that manage credentials. A "*" scope does not reach them, the scope has
+// to name them ("apikey.*" or a single method), so an integration key cannot mint or revoke keys.
+const AdminNamespace = "apikey."
+
+// IsAdminMethod reports whether the method is in AdminNamespace.
+func IsAdminMethod(method string) bool {
+	return strings.HasPrefix(method, AdminNamespace)
+}
+
+// Allows reports whether one of the principal scopes matches the method (see MatchMethod).
+// The master principal is allowed every method, admin ones included.
+func (p *Principal) Allows(method string) bool {
+	if p == nil {
+		return false
+	}
+	if p.Kind == PrincipalKindMaster {
+		return true
+	}
+
+	for _, scope := range p.Scopes {
+		if scope == "*" && IsAdminMethod(method) {
+			continue
+		}
+		if MatchMethod(scope, method) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -4,42 +4,148 @@ import (
 	"fmt"
 	"github.com/goccy/go-json"
 	"notification-service-api/internal/shared/rpc/respond"
+	"reflect"
+	"sort"
 	"sync"
 )
 
+const MethodDiscover = "rpc.discover"
+
 type Handler func(c *HttpCtx, params any) (any, *respond.RPCError)
 
+// Method is a registered procedure: the handler plus the param and result types it was built from.
+type Method struct {
+	handler    Handler
+	params     reflect.Type
+	result     reflect.Type
+	summary    string
+	idempotent bool
+}
+
+// WithSummary sets the human-readable description used in the OpenRPC document.
+func (m *Method) WithSummary(summary string) *Method {
+	m.summary = summary
+	return m
+}
+
+// Idempotent lets the method honor idempotency keys. Only sends take them, a stored response is kept in clear,
+// so results carrying secrets must not be stored and reads have nothing to deduplicate.
+func (m *Method) Idempotent() *Method {
+	m.idempotent = true
+	return m
+}
+
 type Registry struct {
-	mu sync.RWMutex
-	m  map[string]Handler
+	mu           sync.RWMutex
+	m            map[string]*Method
+	info         OpenRPCInfo
+	doc          *OpenRPCDocument
+	interceptors []scopedInterceptor
+	chains       map[string]Handler
 }
 
 func NewRegistry() *Registry {
-	return &Registry{
-		m: make(map[string]Handler),
+	r := &Registry{
+		m: make(map[string]*Method),
+		info: OpenRPCInfo{
+			Title:   "JSON-RPC API",
+			Version: "1.0.0",
+		},
 	}
+
+	// the result is declared as any: describing the meta schema itself would only clutter the components
+	r.Register(MethodDiscover, Typed(func(c *HttpCtx, _ struct{}) (any, *respond.RPCError) {
+		return r.Discover(), nil
+	}).WithSummary("Returns the OpenRPC document describing this service."))
+
+	return r
+}
+
+func (r *Registry) SetInfo(info OpenRPCInfo) {
+	r.mu.Lock()
+	r.info = info
+	r.doc = nil
+	r.mu.Unlock()
 }
 
-func (r *Registry) Register(name string, h Handler) {
+func (r *Registry) Register(name string, m *Method) {
 	r.mu.Lock()
-	r.m[name] = h
+	r.m[name] = m
+	r.doc = nil
+	r.chains = nil
 	r.mu.Unlock() // Defer is overhead for this simple operation
 }
 
 func (r *Registry) Call(name string, c *HttpCtx, params any) (any, *respond.RPCError) {
 	r.mu.RLock()
-	h := r.m[name]
+	m := r.m[name]
+	h, ok := r.chains[name]
 	r.mu.RUnlock()
 
-	if h == nil {
+	if m == nil {
 		return nil, &respond.RPCError{Code: respond.MethodNotFound, Message: "method not found"}
 	}
 
+	if !ok {
+		r.mu.Lock()
+		h = r.chain(name, m)
+		r.mu.Unlock()
+	}
+
 	return h(c, params)
 }
 
-func Typed[T any](fn func(c *HttpCtx, p T) (any, *respond.RPCError)) Handler {
-	return func(c *HttpCtx, params any) (any, *respond.RPCError) {
+// IsIdempotent reports whether the method honors idempotency keys.
+func (r *Registry) IsIdempotent(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m := r.m[name]
+	return m != nil && m.idempotent
+}
+
+// Discover returns the OpenRPC document of all registered methods, it is rebuilt only after a registration.
+func (r *Registry) Discover() *OpenRPCDocument {
+	r.mu.RLock()
+	doc := r.doc
+	r.mu.RUnlock()
+	if doc != nil {
+		return doc
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.doc != nil {
+		return r.doc
+	}
+
+	names := make([]string, 0, len(r.m))
+	for name := range r.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gen := newSchemaGenerator()
+	methods := make([]OpenRPCMethod, 0, len(names))
+	for _, name := range names {
+		methods = append(methods, gen.method(name, r.m[name]))
+	}
+
+	r.doc = &OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    r.info,
+		Methods: methods,
+		Components: OpenRPCComponents{
+			Schemas: gen.defs,
+		},
+	}
+
+	return r.doc
+}
+
+func Typed[T any, R any](fn func(c *HttpCtx, p T) (R, *respond.RPCError)) *Method {
+	handler := func(c *HttpCtx, params any) (any, *respond.RPCError) {
 		var p T
 
 		switch v := params.(type) {
@@ -69,6 +175,21 @@ func Typed[T any](fn func(c *HttpCtx, p T) (any, *respond.RPCError)) Handler {
 			}
 		}
 
-		return fn(c, p)
+		if rpcErr := validateParams(p); rpcErr != nil {
+			return nil, rpcErr
+		}
+
+		result, rpcErr := fn(c, p)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+
+		return result, nil
+	}
+
+	return &Method{
+		handler: handler,
+		params:  reflect.TypeOf((*T)(nil)).Elem(),
+		result:  reflect.TypeOf((*R)(nil)).Elem(),
 	}
 }
@@ -15,6 +15,12 @@ const (
 	InvalidParams  RPCErrorCode = -32602
 	InternalError  RPCErrorCode = -32603
 	AuthError      RPCErrorCode = -32003
+	NotFoundError  RPCErrorCode = -32004
+	TimeoutError   RPCErrorCode = -32008
+	RateLimited    RPCErrorCode = -32029
+
+	IdempotencyMismatch   RPCErrorCode = -32009
+	IdempotencyInProgress RPCErrorCode = -32010
 )
 
 func (c RPCErrorCode) String() string {
@@ -29,6 +35,16 @@ func (c RPCErrorCode) String() string {
 		return "Invalid params"
 	case InternalError:
 		return "Internal error"
+	case NotFoundError:
+		return "Not found"
+	case TimeoutError:
+		return "Timeout"
+	case RateLimited:
+		return "Rate limited"
+	case IdempotencyMismatch:
+		return "Idempotency key mismatch"
+	case IdempotencyInProgress:
+		return "Idempotency key in progress"
 	default:
 		return "Unknown"
 	}
@@ -41,6 +57,13 @@ type Request struct {
 	ID      ID              `json:"id,omitempty"`
 }
 
+// Notification is a server to client message without id, no answer is expected.
+type Notification[T any] struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  T      `json:"params"`
+}
+
 type Response[T any] struct {
 	JSONRPC string    `json:"jsonrpc"`
 	Result  *T        `json:"result,omitempty"`
@@ -54,6 +77,15 @@ type RPCError struct {
 	Data    *ErrorDTO    `json:"data,omitempty"`
 }
 
+// FieldError describes a single invalid param, it is carried in ErrorDTO.Details of invalid params errors.
+type FieldError struct {
+	Field    string `json:"field"`
+	JSONPath string `json:"json_path"`
+	Rule     string `json:"rule"`
+	Param    string `json:"param,omitempty"`
+	Message  string `json:"message"`
+}
+
 type ErrorDTO struct {
 	Code    string      `json:"code"`
 	Message string      `json:"message"`
@@ -0,0 +1,24 @@
+package rpc
+
+// Session is a long-lived connection able to receive server-pushed JSON-RPC notifications.
+type Session interface {
+	ID() string
+	// Notify sends a JSON-RPC notification (a request without id) to the peer.
+	Notify(method string, params any) error
+	// Done is closed once the connection is gone.
+	Done() <-chan struct{}
+	// Close drops the connection, for a peer that cannot keep up.
+	Close()
+}
+
+// WithSession returns a copy of the context bound to the connection the request came from.
+func (h *HttpCtx) WithSession(session Session) *HttpCtx {
+	cp := *h
+	cp.session = session
+	return &cp
+}
+
+// Session returns the connection of the request, nil for plain HTTP calls.
+func (h *HttpCtx) Session() Session {
+	return h.session
+}
@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"notification-service-api/internal/shared/rpc/respond"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var paramsValidator *validator.Validate
+
+// SetValidator enables validation of params in Typed methods, before the handler is called.
+func SetValidator(v *validator.Validate) { paramsValidator = v }
+
+func validateParams(p any) *respond.RPCError {
+	if paramsValidator == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(p)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := paramsValidator.Struct(p)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", err.Error())
+	}
+
+	fields := make([]respond.FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		path := jsonPath(fe.Namespace())
+		fields = append(fields, respond.FieldError{
+			Field:    fe.Field(),
+			JSONPath: path,
+			Rule:     fe.Tag(),
+			Param:    fe.Param(),
+			Message:  fieldErrorMessage(path, fe),
+		})
+	}
+
+	return respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", fields)
+}
+
+// ParseUUID parses an id param. The validator checks it already, this keeps a bad id an invalid params error
+// instead of a panic when a method runs without one.
+func ParseUUID(path string, raw string) (uuid.UUID, *respond.RPCError) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, respond.NewRPCError(respond.InvalidParams, "invalid_params", "invalid params", []respond.FieldError{{
+			Field:    path[strings.LastIndex(path, ".")+1:],
+			JSONPath: path,
+			Rule:     "uuid",
+			Message:  path + " must be a valid UUID",
+		}})
+	}
+
+	return id, nil
+}
+
+// jsonPath drops the params struct name from the validator namespace: "Params.attachments[0].data" -> "attachments[0].data".
+func jsonPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+func fieldErrorMessage(path string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return path + " is required"
+	case "email":
+		return path + " must be a valid email address"
+	case "uuid", "uuid4":
+		return path + " must be a valid UUID"
+	case "url", "uri", "http_url":
+		return path + " must be a valid URL"
+	case "e164":
+		return path + " must be a phone number in E.164 format"
+	case "required_without":
+		return path + " is required when " + snakeCase(fe.Param()) + " is not set"
+	case "required_without_all":
+		return path + " is required when none of " + snakeCaseList(fe.Param()) + " is set"
+	case "required_with":
+		return path + " is required when " + snakeCase(fe.Param()) + " is set"
+	case "excluded_with":
+		return path + " cannot be used together with " + snakeCaseList(fe.Param())
+	case "gtfield":
+		return path + " must be after " + snakeCase(fe.Param())
+	case "timezone":
+		return path + " must be an IANA time zone such as Europe/Berlin"
+	case "printascii":
+		return path + " can only contain printable ASCII characters"
+	case "excludesall":
+		return path + " cannot contain any of " + strconv.Quote(fe.Param())
+	case "oneof":
+		return path + " must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min", "gte":
+		return path + " must be at least " + boundUnit(fe)
+	case "max", "lte":
+		return path + " must be at most " + boundUnit(fe)
+	case "len":
+		return path + " must be exactly " + boundUnit(fe)
+	default:
+		return fmt.Sprintf("%s failed on the '%s' rule", path, fe.Tag())
+	}
+}
+
+func boundUnit(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return fe.Param() + " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fe.Param() + " items"
+	default:
+		return fe.Param()
+	}
+}
+
+// snakeCase maps a struct field named in a rule param back to its json name: "UserID" -> "user_id".
+func snakeCase(field string) string {
+	var b strings.Builder
+	runes := []rune(field)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// snakeCaseList maps the space separated fields of a rule param: "HTML Text" -> "html, text".
+func snakeCaseList(fields string) string {
+	names := strings.Fields(fields)
+	for i, name := range names {
+		names[i] = snakeCase(name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,153 @@
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderKeyID     = "X-Signature-KeyId"
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderNonce     = "X-Signature-Nonce"
+	HeaderSignature = "X-Signature"
+
+	maxNonceLength = 128
+	noncePrefix    = "signature:nonce:"
+)
+
+var (
+	ErrMissing      = errors.New("signature headers are missing")
+	ErrUnknownKey   = errors.New("unknown signature key")
+	ErrClockSkew    = errors.New("signature timestamp is outside of the allowed window")
+	ErrInvalid      = errors.New("signature is invalid")
+	ErrReplayed     = errors.New("signature nonce was already used")
+	ErrInvalidNonce = errors.New("signature nonce is invalid")
+)
+
+// Key is a shared secret of a caller, Methods limits the JSON-RPC methods it may call.
+type Key struct {
+	ID      string
+	Secret  []byte
+	Methods []string
+}
+
+// Verifier checks requests signed as
+//
+//	hex(HMAC-SHA256(secret, METHOD + "\n" + REQUEST_URI + "\n" + TIMESTAMP + "\n" + NONCE + "\n" + hex(SHA256(body)))).
+//
+// A nonce is accepted once within the skew window, so a captured request cannot be replayed.
+type Verifier struct {
+	keys  map[string]Key
+	skew  time.Duration
+	redis *redis.Client
+}
+
+func NewVerifier(keys []Key, skew time.Duration, redisClient *redis.Client) *Verifier {
+	byID := make(map[string]Key, len(keys))
+	for _, k := range keys {
+		byID[k.ID] = k
+	}
+
+	return &Verifier{
+		keys:  byID,
+		skew:  skew,
+		redis: redisClient,
+	}
+}
+
+func (v *Verifier) Enabled() bool {
+	return len(v.keys) > 0
+}
+
+// IsSigned reports whether the request carries a signature, i.e. whether it should be verified at all.
+func IsSigned(r *http.Request) bool {
+	return r.Header.Get(HeaderSignature) != ""
+}
+
+func (v *Verifier) Verify(ctx context.Context, r *http.Request, body []byte) (*Key, error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	sig := r.Header.Get(HeaderSignature)
+
+	if keyID == "" || timestamp == "" || nonce == "" || sig == "" {
+		return nil, ErrMissing
+	}
+	if len(nonce) > maxNonceLength {
+		return nil, ErrInvalidNonce
+	}
+
+	key, ok := v.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrClockSkew
+	}
+	if diff := time.Since(time.Unix(unix, 0)); diff > v.skew || diff < -v.skew {
+		return nil, ErrClockSkew
+	}
+
+	expected := Sign(key.Secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	given, err := hex.DecodeString(strings.ToLower(sig))
+	if err != nil || !hmac.Equal(given, expected) {
+		return nil, ErrInvalid
+	}
+
+	// the nonce is only burnt for a valid signature, otherwise anybody could block nonces of a caller
+	fresh, err := v.redis.SetNX(ctx, noncePrefix+keyID+":"+nonce, 1, 2*v.skew).Result()
+	if err != nil {
+		return nil, fmt.Errorf("check signature nonce: %w", err)
+	}
+	if !fresh {
+		return nil, ErrReplayed
+	}
+
+	return &key, nil
+}
+
+// Sign returns the raw signature of a request, callers send it hex encoded in X-Signature.
+func Sign(secret []byte, method string, requestURI string, timestamp string, nonce string, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + requestURI + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:])))
+
+	return mac.Sum(nil)
+}
+
+// ParseKeys reads keys in the "id:secret[:method|method],id:secret" format of HMAC_KEYS.
+// A key without methods is allowed every method.
+func ParseKeys(raw string) ([]Key, error) {
+	var keys []Key
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid signature key %q, expected id:secret[:methods]", parts[0])
+		}
+
+		key := Key{ID: parts[0], Secret: []byte(parts[1]), Methods: []string{"*"}}
+		if len(parts) == 3 && parts[2] != "" {
+			key.Methods = strings.Split(parts[2], "|")
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,244 @@
+package signature
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers SET with NX, the only command the verifier sends, from memory.
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{listener: listener, values: make(map[string]string), ttls: make(map[string]string)}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+
+	return r
+}
+
+func (r *fakeRedis) client(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: r.listener.Addr().String()})
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, r.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (r *fakeRedis) exec(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		nx := false
+		for i, arg := range args[3:] {
+			nx = nx || strings.EqualFold(arg, "NX")
+			if (strings.EqualFold(arg, "EX") || strings.EqualFold(arg, "PX")) && i+4 < len(args) {
+				r.ttls[args[1]] = arg + " " + args[i+4]
+			}
+		}
+		if _, ok := r.values[args[1]]; ok && nx {
+			return "$-1\r\n"
+		}
+		r.values[args[1]] = args[2]
+		return "+OK\r\n"
+	default:
+		return "+OK\r\n"
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args = append(args, string(arg[:size]))
+	}
+
+	return args, nil
+}
+
+type signedRequest struct {
+	keyID     string
+	secret    string
+	timestamp string
+	nonce     string
+	body      string
+	// sent instead of body, the signature still covers body
+	sentBody string
+}
+
+func (s signedRequest) build() (*http.Request, []byte) {
+	body := []byte(s.body)
+	if s.sentBody != "" {
+		body = []byte(s.sentBody)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/rpc?v=1", strings.NewReader(string(body)))
+	r.Header.Set(HeaderKeyID, s.keyID)
+	r.Header.Set(HeaderTimestamp, s.timestamp)
+	r.Header.Set(HeaderNonce, s.nonce)
+	r.Header.Set(HeaderSignature, hex.EncodeToString(Sign([]byte(s.secret), http.MethodPost, "/rpc?v=1", s.timestamp, s.nonce, []byte(s.body))))
+
+	return r, body
+}
+
+func TestVerifierVerify(t *testing.T) {
+	const skew = 5 * time.Minute
+	verifier := NewVerifier([]Key{{ID: "billing", Secret: []byte("s3cret"), Methods: []string{"email.send"}}}, skew, newFakeRedis(t).client(t))
+
+	at := func(d time.Duration) string {
+		return strconv.FormatInt(time.Now().Add(d).Unix(), 10)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(s *signedRequest)
+		wantErr error
+	}{
+		{name: "valid"},
+		{name: "timestamp in the past within skew", mutate: func(s *signedRequest) { s.timestamp = at(-skew + 10*time.Second) }},
+		{name: "timestamp in the future within skew", mutate: func(s *signedRequest) { s.timestamp = at(skew - 10*time.Second) }},
+		{name: "timestamp too old", mutate: func(s *signedRequest) { s.timestamp = at(-skew - 10*time.Second) }, wantErr: ErrClockSkew},
+		{name: "timestamp too far ahead", mutate: func(s *signedRequest) { s.timestamp = at(skew + 10*time.Second) }, wantErr: ErrClockSkew},
+		{name: "timestamp not a number", mutate: func(s *signedRequest) { s.timestamp = "yesterday" }, wantErr: ErrClockSkew},
+		{name: "wrong secret", mutate: func(s *signedRequest) { s.secret = "guess" }, wantErr: ErrInvalid},
+		{name: "tampered body", mutate: func(s *signedRequest) { s.sentBody = `{"jsonrpc":"2.0","x":1}` }, wantErr: ErrInvalid},
+		{name: "unknown key", mutate: func(s *signedRequest) { s.keyID = "other" }, wantErr: ErrUnknownKey},
+		{name: "missing nonce", mutate: func(s *signedRequest) { s.nonce = "" }, wantErr: ErrMissing},
+		{name: "nonce too long", mutate: func(s *signedRequest) { s.nonce = strings.Repeat("n", maxNonceLength+1) }, wantErr: ErrInvalidNonce},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := signedRequest{keyID: "billing", secret: "s3cret", timestamp: at(0), nonce: fmt.Sprintf("n-%d", i), body: `{"jsonrpc":"2.0"}`}
+			if tt.mutate != nil {
+				tt.mutate(&request)
+			}
+
+			r, body := request.build()
+			key, err := verifier.Verify(context.Background(), r, body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (key == nil || key.ID != "billing") {
+				t.Fatalf("key = %+v", key)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsReusedNonce(t *testing.T) {
+	const skew = time.Minute
+	fake := newFakeRedis(t)
+	verifier := NewVerifier([]Key{{ID: "billing", Secret: []byte("s3cret")}, {ID: "crm", Secret: []byte("other")}}, skew, fake.client(t))
+
+	verify := func(s signedRequest) error {
+		r, body := s.build()
+		_, err := verifier.Verify(context.Background(), r, body)
+		return err
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	request := signedRequest{keyID: "billing", secret: "s3cret", timestamp: now, nonce: "n-1", body: `{}`}
+
+	// an invalid signature must not burn the nonce of the caller
+	forged := request
+	forged.secret = "guess"
+	if err := verify(forged); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("forged request: err = %v, want %v", err, ErrInvalid)
+	}
+
+	if err := verify(request); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if err := verify(request); !errors.Is(err, ErrReplayed) {
+		t.Fatalf("replayed request: err = %v, want %v", err, ErrReplayed)
+	}
+
+	// a fresh signature over a new timestamp does not make a used nonce usable again
+	later := request
+	later.timestamp = strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10)
+	if err := verify(later); !errors.Is(err, ErrReplayed) {
+		t.Fatalf("nonce reused with a new timestamp: err = %v, want %v", err, ErrReplayed)
+	}
+
+	// nonces belong to a key
+	other := request
+	other.keyID, other.secret = "crm", "other"
+	if err := verify(other); err != nil {
+		t.Fatalf("same nonce of another key: %v", err)
+	}
+
+	// the nonce has to outlive every timestamp the skew window still accepts
+	fake.mu.Lock()
+	ttl := fake.ttls[noncePrefix+"billing:n-1"]
+	fake.mu.Unlock()
+	if want := fmt.Sprintf("ex %d", int(2*skew/time.Second)); !strings.EqualFold(ttl, want) {
+		t.Fatalf("nonce ttl = %q, want %q", ttl, want)
+	}
+}
@@ -13,6 +13,6 @@ func NewSystemHandler() *SystemHandler {
 	return &SystemHandler{}
 }
 
-func (h *SystemHandler) Ping(c *rpc.HttpCtx, params dto.PingParams) (any, *respond.RPCError) {
-	return dto.PingDTO{Pong: true, Timestamp: params.Timestamp}, nil
+func (h *SystemHandler) Ping(c *rpc.HttpCtx, params dto.PingParams) (*dto.PingDTO, *respond.RPCError) {
+	return &dto.PingDTO{Pong: true, Timestamp: params.Timestamp}, nil
 }
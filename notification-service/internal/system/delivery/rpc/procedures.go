@@ -9,5 +9,6 @@ import (
 func InitSystemProcedures(dependencies *di.Dependencies) {
 	systemHandler := NewSystemHandler()
 
-	dependencies.Registry.Register("system.ping", rpc.Typed[dto.PingParams](systemHandler.Ping))
+	dependencies.Registry.Register("system.ping", rpc.Typed[dto.PingParams](systemHandler.Ping).
+		WithSummary("Health check, echoes the given timestamp."))
 }
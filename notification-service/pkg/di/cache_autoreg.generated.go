@@ -4,8 +4,10 @@
 package di
 
 import (
+	entity "notification-service-api/internal/auth/domain/entity"
 	_ "notification-service-api/pkg/cache"
 )
 
 func init() {
+	(entity.APIKey{}).RegisterForCache()
 }
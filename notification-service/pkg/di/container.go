@@ -6,31 +6,60 @@ import (
 	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"net/url"
+	authApp "notification-service-api/internal/auth/app"
+	authRepository "notification-service-api/internal/auth/infra/repository"
 	"notification-service-api/internal/notifications/app"
+	"notification-service-api/internal/notifications/domain"
+	"notification-service-api/internal/notifications/infra/chatops"
 	"notification-service-api/internal/notifications/infra/email"
+	"notification-service-api/internal/notifications/infra/events"
 	"notification-service-api/internal/notifications/infra/monitoring"
+	"notification-service-api/internal/notifications/infra/push"
+	"notification-service-api/internal/notifications/infra/repository"
+	"notification-service-api/internal/notifications/infra/sms"
 	"notification-service-api/internal/notifications/infra/telegram"
+	"notification-service-api/internal/notifications/infra/webhook"
+	"notification-service-api/internal/shared/idempotency"
 	"notification-service-api/internal/shared/queue"
+	"notification-service-api/internal/shared/queue/notifications"
+	"notification-service-api/internal/shared/ratelimit"
 	"notification-service-api/internal/shared/rpc"
+	"notification-service-api/internal/shared/signature"
 	"notification-service-api/pkg/cache"
 	"notification-service-api/pkg/utils"
 	"os"
 )
 
 type Dependencies struct {
-	Logger           *zap.Logger
-	Redis            *redis.Client
-	DB               *gorm.DB
-	RabbitMQ         *utils.RabbitMQConnection
-	Validator        *validator.Validate
-	Registry         *rpc.Registry
-	TelegramService  *app.TelegramService
-	EmailService     *app.EmailService
-	Config           *utils.Config
-	Influx           *utils.InfluxDB
-	InfluxMonitoring *monitoring.InfluxMonitoring
-	SMTPClient       *utils.SMTPClient
-	MultiCache       *cache.MultiCache
+	Logger              *zap.Logger
+	Redis               *redis.Client
+	DB                  *gorm.DB
+	RabbitMQ            *utils.RabbitMQConnection
+	Validator           *validator.Validate
+	Registry            *rpc.Registry
+	TelegramService     *app.TelegramService
+	TelegramLinkService *app.TelegramLinkService
+	TelegramAPIs        map[string]*telegram.TGApi
+	TelegramPollers     map[string]*telegram.Poller
+	EmailService        *app.EmailService
+	SMSService          *app.SMSService
+	PushService         *app.PushService
+	WebhookService      *app.WebhookService
+	ChatOpsService      *app.ChatOpsService
+	StatusService       *app.NotificationStatusService
+	StatusSubscriptions *app.StatusSubscriptions
+	Config              *utils.Config
+	JWT                 *utils.JWTVerifier
+	Signatures          *signature.Verifier
+	RateLimiter         *ratelimit.Limiter
+	RateLimits          ratelimit.Rules
+	Influx              *utils.InfluxDB
+	InfluxMonitoring    *monitoring.InfluxMonitoring
+	SMTPClient          *utils.SMTPClient
+	MultiCache          *cache.MultiCache
+	Idempotency         *idempotency.Store
+	APIKeyService       *authApp.APIKeyService
 }
 
 func InitDependencies() *Dependencies {
@@ -44,9 +73,6 @@ func InitDependencies() *Dependencies {
 	logger.Info("Init DB")
 	dbConn := utils.InitDBConnection(os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASS"), os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
 
-	logger.Info("Init migrations")
-	utils.InitMigrations(dbConn)
-
 	logger.Info("Init RabbitMQ")
 	rabbitmqConn := utils.ConnectRabbitMQ(os.Getenv("RABBITMQ_URL"), logger)
 
@@ -64,6 +90,35 @@ func InitDependencies() *Dependencies {
 	logger.Info("Init configuration")
 	config := utils.LoadConfig()
 
+	logger.Info("Init migrations")
+	utils.InitMigrations(dbConn, config.TelegramDefaultBot)
+
+	logger.Info("Init JWT")
+	var jwtOptions []utils.JWTOption
+	if config.JWTJWKSFile != "" {
+		jwks, err := utils.LoadJWKSFile(config.JWTJWKSFile)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to load JWKS: %v", err))
+		}
+		jwtOptions = append(jwtOptions, utils.WithJWKS(jwks))
+	}
+	jwtOptions = append(jwtOptions, utils.WithJWTIssuer(config.JWTIssuer), utils.WithJWTAudience(config.JWTAudience))
+	jwtVerifier := utils.NewJWTVerifier([]byte(config.JWTSecret), jwtOptions...)
+
+	logger.Info("Init request signing")
+	signatureKeys, err := signature.ParseKeys(config.HMACKeys)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Failed to parse HMAC_KEYS: %v", err))
+	}
+	signatures := signature.NewVerifier(signatureKeys, config.HMACMaxSkew, redisConn)
+
+	logger.Info("Init rate limits")
+	rateLimits, err := ratelimit.ParseRules(config.RateLimitDefault)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Failed to parse RATE_LIMIT_DEFAULT: %v", err))
+	}
+	rateLimiter := ratelimit.NewLimiter(redisConn)
+
 	logger.Info("Init InfluxDB")
 	influx, err := utils.InitInfluxUDP(os.Getenv("INFLUX_UDP_HOST"))
 	if err != nil {
@@ -87,32 +142,237 @@ func InitDependencies() *Dependencies {
 	}
 
 	validate := utils.InitValidator()
+	rpc.SetValidator(validate)
 
 	registry := rpc.NewRegistry()
+	registry.SetInfo(rpc.OpenRPCInfo{
+		Title:       "Notification Service API",
+		Description: "Simple service for notifications.",
+		Version:     "1.0.0",
+	})
 
 	influxMonitoring := monitoring.NewInfluxMonitoring(influx, logger, os.Getenv("SERVICE_ENV"))
 
-	tgApi := telegram.NewTGApiClient()
-	tgService := app.NewTelegramService(tgApi, rabbitmqConn, influxMonitoring)
+	idempotencyStore := idempotency.NewStore(dbConn, config.IdempotencyTTL, 2*config.RPCMethodTimeout)
+
+	apiKeyRepository := authRepository.NewAPIKeyRepository(dbConn)
+	apiKeyService := authApp.NewAPIKeyService(apiKeyRepository, multiCache, logger)
+
+	notificationRepository := repository.NewNotificationRepository(dbConn)
+	statusEvents := events.NewRedisStatusEvents(redisConn)
+	statusService := app.NewNotificationStatusService(notificationRepository, statusEvents, logger)
+	statusSubscriptions := app.NewStatusSubscriptions(statusEvents, logger)
+
+	logger.Info("Init telegram bots")
+	tgBots, tgApis := initTelegramBots(config, rateLimiter, influxMonitoring, logger)
+	tgPollers := make(map[string]*telegram.Poller, len(tgApis))
+	for name, api := range tgApis {
+		tgPollers[name] = telegram.NewPoller(api, redisConn, logger.With(zap.String("bot", name)))
+	}
+	tgMessageRepository := repository.NewTelegramMessageRepository(dbConn)
+	tgBindingRepository := repository.NewTelegramBindingRepository(dbConn)
+	tgService := app.NewTelegramService(tgBots, tgMessageRepository, tgBindingRepository, config.TelegramPlainTextFallback, rabbitmqConn, statusService)
+	tgLinkService := app.NewTelegramLinkService(tgBots, telegram.NewRedisLinkStore(redisConn), tgBindingRepository, config.TelegramLinkTTL, logger)
 
 	emailApi := email.NewEmailAPI(smtpClient)
-	emailService := app.NewEmailService(emailApi, rabbitmqConn, influxMonitoring)
+	emailService := app.NewEmailService(emailApi, rabbitmqConn, influxMonitoring, statusService)
+
+	var smsProvider app.SMSPort
+	switch config.SMSProvider {
+	case "http":
+		if config.SMSHTTPURL == "" {
+			logger.Fatal("SMS_HTTP_URL is required for SMS_PROVIDER=http")
+		}
+		smsProvider = sms.NewHTTPProvider(config.SMSHTTPURL, config.SMSHTTPToken, config.SMSSender, config.SMSHTTPTimeout)
+	case "fake":
+		if utils.IsProd() {
+			logger.Fatal("SMS_PROVIDER=fake drops every message, it cannot be used in production")
+		}
+		smsProvider = sms.NewFakeProvider(config.SMSSender, logger)
+	case "":
+		logger.Warn("SMS_PROVIDER is not set, sms.send is disabled")
+	default:
+		logger.Fatal(fmt.Sprintf("Unknown SMS_PROVIDER: %s", config.SMSProvider))
+	}
+	smsService := app.NewSMSService(smsProvider, rabbitmqConn, influxMonitoring, statusService)
+
+	logger.Info("Init push providers")
+	pushProviders := initPushProviders(config, logger)
+	deviceTokenRepository := repository.NewDeviceTokenRepository(dbConn)
+	pushService := app.NewPushService(pushProviders, deviceTokenRepository, rabbitmqConn, influxMonitoring, statusService)
+
+	// receivers could not tell unsigned webhooks from forged ones, so the channel needs a secret
+	var webhookClient app.WebhookPort
+	if config.WebhookSecret != "" {
+		webhookClient = webhook.NewClient(config.WebhookSecret, config.WebhookTimeout, config.WebhookAllowPrivate)
+	} else {
+		logger.Warn("WEBHOOK_SECRET is not set, webhook.send is disabled")
+	}
+	webhookService := app.NewWebhookService(webhookClient, config.WebhookAllowedHosts, rabbitmqConn, influxMonitoring, statusService)
+
+	logger.Info("Init chat-ops webhooks")
+	chatOpsService := app.NewChatOpsService(initChatOpsChannels(config, logger), rabbitmqConn, influxMonitoring, statusService)
 
 	logger.Info("Init dependencies successfully")
 
 	return &Dependencies{
-		Logger:           logger,
-		Redis:            redisConn,
-		DB:               dbConn,
-		RabbitMQ:         rabbitmqConn,
-		Validator:        validate,
-		Registry:         registry,
-		TelegramService:  tgService,
-		EmailService:     emailService,
-		Config:           config,
-		Influx:           influx,
-		InfluxMonitoring: influxMonitoring,
-		SMTPClient:       smtpClient,
-		MultiCache:       multiCache,
+		Logger:              logger,
+		Redis:               redisConn,
+		DB:                  dbConn,
+		RabbitMQ:            rabbitmqConn,
+		Validator:           validate,
+		Registry:            registry,
+		TelegramService:     tgService,
+		TelegramLinkService: tgLinkService,
+		TelegramAPIs:        tgApis,
+		TelegramPollers:     tgPollers,
+		EmailService:        emailService,
+		SMSService:          smsService,
+		PushService:         pushService,
+		WebhookService:      webhookService,
+		ChatOpsService:      chatOpsService,
+		StatusService:       statusService,
+		StatusSubscriptions: statusSubscriptions,
+		Config:              config,
+		JWT:                 jwtVerifier,
+		Signatures:          signatures,
+		RateLimiter:         rateLimiter,
+		RateLimits:          rateLimits,
+		Influx:              influx,
+		InfluxMonitoring:    influxMonitoring,
+		SMTPClient:          smtpClient,
+		MultiCache:          multiCache,
+		Idempotency:         idempotencyStore,
+		APIKeyService:       apiKeyService,
+	}
+}
+
+// initPushProviders enables a platform only when it is configured, sending to a device of a disabled platform fails.
+func initPushProviders(config *utils.Config, logger *zap.Logger) map[domain.Platform]app.PushPort {
+	providers := make(map[domain.Platform]app.PushPort)
+
+	if config.FCMCredentialsFile != "" || config.FCMAccessToken != "" {
+		options := push.FCMOptions{
+			BaseURL:     config.FCMBaseURL,
+			TokenURL:    config.FCMTokenURL,
+			ProjectID:   config.FCMProjectID,
+			AccessToken: config.FCMAccessToken,
+			Timeout:     config.PushTimeout,
+		}
+		if config.FCMCredentialsFile != "" {
+			credentials, err := push.LoadFCMCredentials(config.FCMCredentialsFile)
+			if err != nil {
+				logger.Fatal(fmt.Sprintf("Failed to load FCM credentials: %v", err))
+			}
+			options.Credentials = credentials
+		}
+
+		fcm, err := push.NewFCMProvider(options)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to init FCM: %v", err))
+		}
+		providers[domain.PlatformFCM] = fcm
+	}
+
+	if config.APNsKeyFile != "" {
+		key, err := push.LoadAPNsKey(config.APNsKeyFile)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to load APNs key: %v", err))
+		}
+
+		apns, err := push.NewAPNsProvider(push.APNsOptions{
+			BaseURL: config.APNsBaseURL,
+			KeyID:   config.APNsKeyID,
+			TeamID:  config.APNsTeamID,
+			Topic:   config.APNsTopic,
+			Key:     key,
+			Timeout: config.PushTimeout,
+		})
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to init APNs: %v", err))
+		}
+		providers[domain.PlatformAPNs] = apns
+	}
+
+	return providers
+}
+
+func initChatOpsChannels(config *utils.Config, logger *zap.Logger) map[domain.Channel]app.ChatOpsChannel {
+	channels := []struct {
+		channel    domain.Channel
+		env        string
+		raw        string
+		adapter    app.ChatOpsPort
+		routingKey string
+	}{
+		{domain.ChannelSlack, "SLACK_WEBHOOKS", config.SlackWebhooks, chatops.NewSlack(config.ChatOpsTimeout), notifications.RoutingSlackSend},
+		{domain.ChannelDiscord, "DISCORD_WEBHOOKS", config.DiscordWebhooks, chatops.NewDiscord(config.ChatOpsTimeout), notifications.RoutingDiscordSend},
+		{domain.ChannelMattermost, "MATTERMOST_WEBHOOKS", config.MattermostWebhooks, chatops.NewMattermost(config.ChatOpsTimeout), notifications.RoutingMattermostSend},
+	}
+
+	result := make(map[domain.Channel]app.ChatOpsChannel, len(channels))
+	for _, c := range channels {
+		targets, err := chatops.ParseTargets(c.raw)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to parse %s: %v", c.env, err))
+		}
+
+		result[c.channel] = app.ChatOpsChannel{Adapter: c.adapter, Targets: targets, RoutingKey: c.routingKey}
+	}
+
+	return result
+}
+
+// initTelegramBots builds the registry of TELEGRAM_BOTS, every bot with its own client, send limits and monitoring tags.
+func initTelegramBots(config *utils.Config, limiter *ratelimit.Limiter, influxMonitoring *monitoring.InfluxMonitoring, logger *zap.Logger) (*app.TelegramBots, map[string]*telegram.TGApi) {
+	bots := make([]app.TelegramBot, 0, len(config.TelegramBots))
+	apis := make(map[string]*telegram.TGApi, len(config.TelegramBots))
+
+	for _, bot := range config.TelegramBots {
+		if _, ok := apis[bot.Name]; ok {
+			logger.Fatal(fmt.Sprintf("Telegram bot %s is listed twice in TELEGRAM_BOTS", bot.Name))
+		}
+		if bot.Token == "" {
+			logger.Warn(fmt.Sprintf("Telegram bot %s has no token, its notifications will fail", bot.Name))
+		}
+
+		parse := func(setting string, raw string) ratelimit.Limit {
+			limit, err := ratelimit.ParseLimit(raw)
+			if err != nil {
+				logger.Fatal(fmt.Sprintf("Failed to parse %s of telegram bot %s: %v", setting, bot.Name, err))
+			}
+			return limit
+		}
+
+		var proxy *url.URL
+		if bot.Proxy != "" {
+			parsed, err := url.Parse(bot.Proxy)
+			if err != nil || parsed.Host == "" {
+				logger.Fatal(fmt.Sprintf("Failed to parse PROXY of telegram bot %s", bot.Name))
+			}
+			proxy = parsed
+		}
+
+		api := telegram.NewTGApiClient(bot.Name, bot.Token, bot.APIURL, bot.Timeout, proxy)
+		apis[bot.Name] = api
+		bots = append(bots, app.TelegramBot{
+			Name:     bot.Name,
+			Username: bot.Username,
+			API:      api,
+			Throttle: telegram.NewThrottle(limiter, bot.Name,
+				parse("RATE_GLOBAL", bot.RateGlobal),
+				parse("RATE_CHAT", bot.RateChat),
+				parse("RATE_GROUP", bot.RateGroup),
+			),
+			Monitoring: influxMonitoring.With(map[string]string{"bot": bot.Name}),
+			Local:      bot.Local,
+			LocalFiles: bot.LocalFiles,
+		})
 	}
+
+	if _, ok := apis[config.TelegramDefaultBot]; !ok {
+		logger.Fatal(fmt.Sprintf("TELEGRAM_DEFAULT_BOT %s is not one of TELEGRAM_BOTS", config.TelegramDefaultBot))
+	}
+
+	return app.NewTelegramBots(bots, config.TelegramDefaultBot), apis
 }
@@ -3,14 +3,94 @@ package utils
 import (
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	IsSecure    bool
-	MasterToken string
+	IsSecure       bool
+	MasterToken    string
+	IdempotencyTTL time.Duration
+
+	RPCBatchParallelism int
+	RPCBatchMaxSize     int
+	RPCMethodTimeout    time.Duration
+	RPCMaxBodySize      int64
+
+	WSAllowedOrigins []string
+
+	JWTSecret   string
+	JWTJWKSFile string
+	JWTIssuer   string
+	JWTAudience string
+
+	HMACKeys    string
+	HMACMaxSkew time.Duration
+
+	RateLimitDefault string
+
+	SMSProvider    string
+	SMSHTTPURL     string
+	SMSHTTPToken   string
+	SMSHTTPTimeout time.Duration
+	SMSSender      string
+
+	PushTimeout        time.Duration
+	FCMCredentialsFile string
+	FCMProjectID       string
+	FCMBaseURL         string
+	FCMTokenURL        string
+	FCMAccessToken     string
+	APNsKeyFile        string
+	APNsKeyID          string
+	APNsTeamID         string
+	APNsTopic          string
+	APNsBaseURL        string
+
+	WebhookSecret       string
+	WebhookTimeout      time.Duration
+	WebhookAllowedHosts []string
+	WebhookAllowPrivate bool
+
+	TelegramBots              []TelegramBotConfig
+	TelegramDefaultBot        string
+	TelegramPlainTextFallback bool
+	TelegramUpdates           string
+	TelegramWebhookURL        string
+	TelegramWebhookSecret     string
+	TelegramLinkTTL           time.Duration
+
+	ChatOpsTimeout     time.Duration
+	SlackWebhooks      string
+	DiscordWebhooks    string
+	MattermostWebhooks string
+}
+
+// TelegramBotConfig is a bot of TELEGRAM_BOTS. Its settings are read from TELEGRAM_BOT_<NAME>_*,
+// with the TELEGRAM_* values as defaults.
+type TelegramBotConfig struct {
+	Name       string
+	Token      string
+	Username   string
+	RateGlobal string
+	RateChat   string
+	RateGroup  string
+	APIURL     string
+	Timeout    time.Duration
+	Proxy      string
+	Local      bool
+	LocalFiles string
 }
 
 func LoadConfig() *Config {
+	telegramBots := getTelegramBots()
+	// an empty TELEGRAM_DEFAULT_BOT= is left unset, not a bot named ""
+	telegramDefaultBot := os.Getenv("TELEGRAM_DEFAULT_BOT")
+	if telegramDefaultBot == "" {
+		telegramDefaultBot = telegramBots[0].Name
+	}
+
 	isSecure := os.Getenv("IS_SECURE") == "true"
 	masterToken := os.Getenv("MASTER_TOKEN")
 	if masterToken == "" {
@@ -18,9 +98,149 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		IsSecure:    isSecure,
-		MasterToken: masterToken,
+		IsSecure:       isSecure,
+		MasterToken:    masterToken,
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		RPCBatchParallelism: getEnvInt("RPC_BATCH_PARALLELISM", 16),
+		RPCBatchMaxSize:     getEnvInt("RPC_BATCH_MAX_SIZE", 500),
+		RPCMethodTimeout:    getEnvDuration("RPC_METHOD_TIMEOUT", 10*time.Second),
+		RPCMaxBodySize:      int64(getEnvInt("RPC_MAX_BODY_MB", 100)) << 20,
+
+		WSAllowedOrigins: getEnvList("WS_ALLOWED_ORIGINS"),
+
+		JWTSecret:   os.Getenv("APP_SECRET_KEY"),
+		JWTJWKSFile: os.Getenv("JWT_JWKS_FILE"),
+		JWTIssuer:   os.Getenv("JWT_ISSUER"),
+		JWTAudience: os.Getenv("JWT_AUDIENCE"),
+
+		HMACKeys:    os.Getenv("HMAC_KEYS"),
+		HMACMaxSkew: getEnvDuration("HMAC_MAX_SKEW", 5*time.Minute),
+
+		RateLimitDefault: getEnv("RATE_LIMIT_DEFAULT", "*=600/m"),
+
+		SMSProvider:    os.Getenv("SMS_PROVIDER"),
+		SMSHTTPURL:     os.Getenv("SMS_HTTP_URL"),
+		SMSHTTPToken:   os.Getenv("SMS_HTTP_TOKEN"),
+		SMSHTTPTimeout: getEnvDuration("SMS_HTTP_TIMEOUT", 10*time.Second),
+		SMSSender:      os.Getenv("SMS_SENDER"),
+
+		PushTimeout:        getEnvDuration("PUSH_TIMEOUT", 10*time.Second),
+		FCMCredentialsFile: os.Getenv("FCM_CREDENTIALS_FILE"),
+		FCMProjectID:       os.Getenv("FCM_PROJECT_ID"),
+		FCMBaseURL:         os.Getenv("FCM_BASE_URL"),
+		FCMTokenURL:        os.Getenv("FCM_TOKEN_URL"),
+		FCMAccessToken:     os.Getenv("FCM_ACCESS_TOKEN"),
+		APNsKeyFile:        os.Getenv("APNS_KEY_FILE"),
+		APNsKeyID:          os.Getenv("APNS_KEY_ID"),
+		APNsTeamID:         os.Getenv("APNS_TEAM_ID"),
+		APNsTopic:          os.Getenv("APNS_TOPIC"),
+		APNsBaseURL:        os.Getenv("APNS_BASE_URL"),
+
+		WebhookSecret:       os.Getenv("WEBHOOK_SECRET"),
+		WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookAllowedHosts: getEnvList("WEBHOOK_ALLOWED_HOSTS"),
+		WebhookAllowPrivate: os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true",
+
+		TelegramBots:              telegramBots,
+		TelegramDefaultBot:        telegramDefaultBot,
+		TelegramPlainTextFallback: getEnv("TELEGRAM_PLAIN_TEXT_FALLBACK", "true") == "true",
+		TelegramUpdates:           os.Getenv("TELEGRAM_UPDATES"),
+		TelegramWebhookURL:        os.Getenv("TELEGRAM_WEBHOOK_URL"),
+		TelegramWebhookSecret:     os.Getenv("TELEGRAM_WEBHOOK_SECRET"),
+		TelegramLinkTTL:           getEnvDuration("TELEGRAM_LINK_TTL", 24*time.Hour),
+
+		ChatOpsTimeout:     getEnvDuration("CHATOPS_TIMEOUT", 10*time.Second),
+		SlackWebhooks:      os.Getenv("SLACK_WEBHOOKS"),
+		DiscordWebhooks:    os.Getenv("DISCORD_WEBHOOKS"),
+		MattermostWebhooks: os.Getenv("MATTERMOST_WEBHOOKS"),
+	}
+}
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
 	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		GetLogger().Sugar().Warnf("%s has invalid number %q, using %d", key, value, fallback)
+		return fallback
+	}
+
+	return n
+}
+
+func getEnv(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func getEnvList(key string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// getTelegramBots reads the bots named in TELEGRAM_BOTS. Without it TELEGRAM_TOKEN and TELEGRAM_BOT_USERNAME
+// are a single bot named default, a named bot never falls back to them so it cannot send as another bot.
+func getTelegramBots() []TelegramBotConfig {
+	names := getEnvList("TELEGRAM_BOTS")
+	if len(names) == 0 {
+		bot := getTelegramBot("default", "TELEGRAM_")
+		bot.Token = os.Getenv("TELEGRAM_TOKEN")
+		bot.Username = os.Getenv("TELEGRAM_BOT_USERNAME")
+		return []TelegramBotConfig{bot}
+	}
+
+	bots := make([]TelegramBotConfig, 0, len(names))
+	for _, name := range names {
+		prefix := "TELEGRAM_BOT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		bot := getTelegramBot(name, prefix)
+		bot.Token = os.Getenv(prefix + "TOKEN")
+		bot.Username = os.Getenv(prefix + "USERNAME")
+		bots = append(bots, bot)
+	}
+
+	return bots
+}
+
+// getTelegramBot reads the settings of a bot that default to the TELEGRAM_* values.
+func getTelegramBot(name string, prefix string) TelegramBotConfig {
+	return TelegramBotConfig{
+		Name:       name,
+		RateGlobal: getEnv(prefix+"RATE_GLOBAL", getEnv("TELEGRAM_RATE_GLOBAL", "30/s")),
+		RateChat:   getEnv(prefix+"RATE_CHAT", getEnv("TELEGRAM_RATE_CHAT", "1/s")),
+		RateGroup:  getEnv(prefix+"RATE_GROUP", getEnv("TELEGRAM_RATE_GROUP", "20/m")),
+		APIURL:     getEnv(prefix+"API_URL", getEnv("TELEGRAM_API_URL", "https://api.telegram.org")),
+		Timeout:    getEnvDuration(prefix+"TIMEOUT", getEnvDuration("TELEGRAM_TIMEOUT", 60*time.Second)),
+		Proxy:      getEnv(prefix+"PROXY", os.Getenv("TELEGRAM_PROXY")),
+		Local:      getEnv(prefix+"LOCAL", getEnv("TELEGRAM_LOCAL", "false")) == "true",
+		LocalFiles: getEnv(prefix+"LOCAL_FILES", os.Getenv("TELEGRAM_LOCAL_FILES")),
+	}
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		GetLogger().Sugar().Warnf("%s has invalid duration %q, using %s", key, value, fallback)
+		return fallback
+	}
+
+	return d
 }
 
 func LoadEnv() {
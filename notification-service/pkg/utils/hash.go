@@ -7,7 +7,10 @@ import (
 	"time"
 )
 
-var jwtSecret = []byte(os.Getenv("APP_SECRET_KEY"))
+// jwtSecret is read on use: a package level variable would be initialized before LoadEnv reads .env
+func jwtSecret() []byte {
+	return []byte(os.Getenv("APP_SECRET_KEY"))
+}
 
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
@@ -23,31 +26,21 @@ func GenerateToken(userID string) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 
 	claims := &jwt.MapClaims{
+		"sub":     userID,
 		"user_id": userID,
 		"exp":     expirationTime.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString(jwtSecret())
 }
 
+// ValidateToken checks an HS256 token signed with APP_SECRET_KEY and returns its subject.
 func ValidateToken(tokenStr string) (string, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return jwtSecret, nil
-	})
-
+	claims, err := NewJWTVerifier(jwtSecret()).Verify(tokenStr)
 	if err != nil {
 		return "", err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if userID, ok := claims["user_id"].(string); ok {
-			return userID, nil
-		}
-	}
-
-	return "", jwt.ErrTokenNotValidYet
+	return claims.Subject, nil
 }
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"testing"
+	"time"
+)
+
+func TestJWTVerifierVerify(t *testing.T) {
+	secret := []byte("test-secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := func(mutate func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":   "user-1",
+			"scope": "notifications.read notifications.write",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, key any, c jwt.MapClaims, kid string) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	hmacOnly := NewJWTVerifier(secret)
+	jwksOnly := NewJWTVerifier(nil, WithJWKS(map[string]crypto.PublicKey{"k1": &rsaKey.PublicKey}))
+	both := NewJWTVerifier(secret, WithJWKS(map[string]crypto.PublicKey{"k1": &rsaKey.PublicKey}))
+
+	tests := []struct {
+		name     string
+		verifier *JWTVerifier
+		token    string
+		wantErr  error
+	}{
+		{
+			name:     "valid hs256",
+			verifier: hmacOnly,
+			token:    sign(jwt.SigningMethodHS256, secret, claims(nil), ""),
+		},
+		{
+			name:     "valid rs256 by kid",
+			verifier: jwksOnly,
+			token:    sign(jwt.SigningMethodRS256, rsaKey, claims(nil), "k1"),
+		},
+		{
+			name:     "expired",
+			verifier: hmacOnly,
+			token: sign(jwt.SigningMethodHS256, secret, claims(func(c jwt.MapClaims) {
+				c["iat"] = now.Add(-2 * time.Hour).Unix()
+				c["exp"] = now.Add(-time.Hour).Unix()
+			}), ""),
+			wantErr: jwt.ErrTokenExpired,
+		},
+		{
+			name:     "expired within leeway",
+			verifier: hmacOnly,
+			token: sign(jwt.SigningMethodHS256, secret, claims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-10 * time.Second).Unix()
+			}), ""),
+		},
+		{
+			name:     "missing exp",
+			verifier: hmacOnly,
+			token: sign(jwt.SigningMethodHS256, secret, claims(func(c jwt.MapClaims) {
+				delete(c, "exp")
+			}), ""),
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:     "issued in the future",
+			verifier: hmacOnly,
+			token: sign(jwt.SigningMethodHS256, secret, claims(func(c jwt.MapClaims) {
+				c["iat"] = now.Add(time.Hour).Unix()
+			}), ""),
+			wantErr: jwt.ErrTokenUsedBeforeIssued,
+		},
+		{
+			name:     "missing sub",
+			verifier: hmacOnly,
+			token: sign(jwt.SigningMethodHS256, secret, claims(func(c jwt.MapClaims) {
+				delete(c, "sub")
+			}), ""),
+			wantErr: jwt.ErrTokenInvalidClaims,
+		},
+		{
+			name:     "alg none",
+			verifier: both,
+			token:    sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims(nil), ""),
+			wantErr:  jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			// the public key is no secret, an hs256 token keyed with it must not pass as rs256
+			name:     "hs256 signed with the public key",
+			verifier: jwksOnly,
+			token:    sign(jwt.SigningMethodHS256, publicDER, claims(nil), "k1"),
+			wantErr:  ErrJWTKeyNotFound,
+		},
+		{
+			name:     "hs256 signed with the public key and a secret configured",
+			verifier: both,
+			token:    sign(jwt.SigningMethodHS256, publicDER, claims(nil), "k1"),
+			wantErr:  jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:     "algorithm outside the allowed list",
+			verifier: hmacOnly,
+			token:    sign(jwt.SigningMethodHS512, secret, claims(nil), ""),
+			wantErr:  jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:     "unknown kid",
+			verifier: jwksOnly,
+			token:    sign(jwt.SigningMethodRS256, rsaKey, claims(nil), "k2"),
+			wantErr:  ErrJWTKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.verifier.Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got.Subject != "user-1" || len(got.Scopes) != 2 {
+				t.Fatalf("claims = %+v", got)
+			}
+		})
+	}
+}
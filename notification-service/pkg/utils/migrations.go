@@ -1,9 +1,57 @@
 package utils
 
 import (
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	authEntity "notification-service-api/internal/auth/domain/entity"
+	"notification-service-api/internal/notifications/domain/entity"
+	idempotencyEntity "notification-service-api/internal/shared/idempotency/entity"
 )
 
-func InitMigrations(db *gorm.DB) {
-	db.AutoMigrate()
+// InitMigrations migrates the schema, defaultBot owns the telegram rows stored before bots had names.
+func InitMigrations(db *gorm.DB, defaultBot string) {
+	backfillTelegramBot(db, &entity.TelegramMessage{}, defaultBot)
+	backfillTelegramBot(db, &entity.TelegramBinding{}, defaultBot)
+	// a user is linked once per bot now, the index of one binding per owner and user has to go
+	if db.Migrator().HasIndex(&entity.TelegramBinding{}, "idx_telegram_bindings_owner_user") {
+		if err := db.Migrator().DropIndex(&entity.TelegramBinding{}, "idx_telegram_bindings_owner_user"); err != nil {
+			GetLogger().Error("failed to drop telegram bindings index", zap.Error(err))
+		}
+	}
+
+	if err := db.AutoMigrate(
+		&entity.Notification{},
+		&entity.DeviceToken{},
+		&entity.TelegramMessage{},
+		&entity.TelegramBinding{},
+		&idempotencyEntity.Idempotency{},
+		&authEntity.APIKey{},
+	); err != nil {
+		GetLogger().Error("failed to run migrations", zap.Error(err))
+	}
+}
+
+// backfillTelegramBot adds the bot column to a table from before named bots and gives its rows the default bot,
+// AutoMigrate then makes it not null.
+func backfillTelegramBot(db *gorm.DB, model any, defaultBot string) {
+	migrator := db.Migrator()
+	if !migrator.HasTable(model) || migrator.HasColumn(model, "Bot") {
+		return
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		GetLogger().Error("failed to parse telegram model", zap.Error(err))
+		return
+	}
+	table := stmt.Schema.Table
+
+	if err := db.Exec("ALTER TABLE ? ADD COLUMN bot varchar(64)", clause.Table{Name: table}).Error; err != nil {
+		GetLogger().Error("failed to add telegram bot column", zap.String("table", table), zap.Error(err))
+		return
+	}
+	if err := db.Table(table).Where("bot IS NULL").Update("bot", defaultBot).Error; err != nil {
+		GetLogger().Error("failed to backfill telegram bot column", zap.String("table", table), zap.Error(err))
+	}
 }
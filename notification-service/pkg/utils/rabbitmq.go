@@ -24,6 +24,60 @@ type RabbitMQConnection struct {
 
 type HandlerFunc func(ctx context.Context, d amqp.Delivery) error
 
+// PermanentError marks a handler failure that a retry cannot fix, the delivery goes straight to the DLQ.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// DelayError asks the consumer to redeliver the message after After, without counting a retry attempt.
+type DelayError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *DelayError) Error() string {
+	return fmt.Sprintf("%v (retry after %s)", e.Err, e.After)
+}
+
+func (e *DelayError) Unwrap() error {
+	return e.Err
+}
+
+func Delay(err error, after time.Duration) error {
+	return &DelayError{Err: err, After: after}
+}
+
+func DelayFor(err error) (time.Duration, bool) {
+	var delay *DelayError
+	if errors.As(err, &delay) {
+		return delay.After, true
+	}
+	return 0, false
+}
+
+// DeliveryHook is notified after a failed delivery was moved to the retry queue or to the DLQ.
+type DeliveryHook func(ctx context.Context, d amqp.Delivery, attempts int64, err error)
+
 type ConsumeOptions struct {
 	Queue           string
 	Workers         int
@@ -34,6 +88,11 @@ type ConsumeOptions struct {
 	RetryMax        int64
 	RetryRoutingKey string
 	DLQRoutingKey   string
+	// DelayRoutingKey is the routing key of the queue on the notifications exchange, a DelayError handler
+	// result republishes the message through a delay queue to it, without it a DelayError is a normal retry.
+	DelayRoutingKey string
+	OnRetry         DeliveryHook
+	OnDead          DeliveryHook
 }
 
 var (
@@ -62,6 +121,11 @@ func GetRabbitMQInstance() *RabbitMQConnection {
 }
 
 func (r *RabbitMQConnection) Publish(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	// a cancelled caller, e.g. a timed out RPC, has answered already and must not publish after that
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	ch, ok := r.nextChan()
 	if !ok {
 		return amqp.ErrClosed
@@ -100,6 +164,45 @@ func (r *RabbitMQConnection) PublishMsgpack(ctx context.Context, exchange, routi
 	return r.Publish(ctx, exchange, routingKey, msg)
 }
 
+// delayQueue names the delay queue of exchange/routingKey for delay and returns its arguments.
+func delayQueue(exchange, routingKey string, delay time.Duration) (string, amqp.Table) {
+	delayMs := delayTTL(delay).Milliseconds()
+
+	return fmt.Sprintf("%s.%s.delay.%d", exchange, routingKey, delayMs), amqp.Table{
+		"x-message-ttl":             delayMs,
+		"x-dead-letter-exchange":    exchange,
+		"x-dead-letter-routing-key": routingKey,
+		"x-expires":                 delayMs + time.Minute.Milliseconds(),
+	}
+}
+
+// delayTTL rounds delay up to whole seconds, Telegram's retry_after is whole seconds already,
+// so a message waits exactly as asked and never comes back early.
+func delayTTL(delay time.Duration) time.Duration {
+	return max(time.Second, (delay + time.Second - 1).Truncate(time.Second))
+}
+
+// PublishDelayed routes the message to exchange/routingKey after delay, rounded up to whole seconds.
+// Every delay gets its own queue, declared on first use, so all messages of a queue share one TTL and expire in order;
+// unused delay queues are deleted by the broker.
+func (r *RabbitMQConnection) PublishDelayed(ctx context.Context, exchange, routingKey string, delay time.Duration, msg amqp.Publishing) error {
+	queue, args := delayQueue(exchange, routingKey, delay)
+
+	ch, ok := r.nextChan()
+	if !ok {
+		return amqp.ErrClosed
+	}
+
+	_, err := ch.QueueDeclare(queue, true, false, false, false, args)
+	if err != nil {
+		r.reopenChannel(ch)
+		return fmt.Errorf("declare delay queue: %w", err)
+	}
+
+	// the default exchange routes by queue name
+	return r.Publish(ctx, "", queue, msg)
+}
+
 func (r *RabbitMQConnection) Consume(ctx context.Context, opts ConsumeOptions, handler HandlerFunc) error {
 	if opts.Workers <= 0 {
 		opts.Workers = 1
@@ -333,6 +436,30 @@ func (r *RabbitMQConnection) runConsumerWorker(
 				attempts := getRetryCount(d.Headers) + 1
 
 				if err := handler(ctx, d); err != nil {
+					if after, ok := DelayFor(err); ok && opts.DelayRoutingKey != "" {
+						pubErr := r.PublishDelayed(ctx, notifications.ExchangeNotifications, opts.DelayRoutingKey, after, amqp.Publishing{
+							DeliveryMode:  amqp.Persistent,
+							ContentType:   d.ContentType,
+							Body:          d.Body,
+							Headers:       d.Headers,
+							CorrelationId: d.CorrelationId,
+							MessageId:     d.MessageId,
+							Timestamp:     time.Now(),
+						})
+						if pubErr != nil {
+							_ = d.Nack(false, true)
+							localLogger.Error(fmt.Sprintf("[consumer:%d] publish to delay queue failed: %v", workerID, pubErr))
+							continue
+						}
+
+						_ = d.Ack(false)
+						localLogger.Info(fmt.Sprintf("[consumer:%d] delayed for %s", workerID, after))
+						if opts.OnRetry != nil {
+							opts.OnRetry(ctx, d, attempts-1, err)
+						}
+						continue
+					}
+
 					if opts.RetryRoutingKey == "" && opts.DLQRoutingKey == "" {
 						_ = d.Ack(false)
 						localLogger.Error(
@@ -343,7 +470,7 @@ func (r *RabbitMQConnection) runConsumerWorker(
 						continue
 					}
 
-					if attempts >= opts.RetryMax {
+					if attempts >= opts.RetryMax || IsPermanent(err) {
 						pubErr := r.Publish(ctx, notifications.ExchangeDLX, opts.DLQRoutingKey, amqp.Publishing{
 							DeliveryMode:  amqp.Persistent,
 							ContentType:   d.ContentType,
@@ -360,6 +487,9 @@ func (r *RabbitMQConnection) runConsumerWorker(
 						}
 						_ = d.Ack(false)
 						localLogger.Info(fmt.Sprintf("[consumer:%d] publish to DLX succeeded", workerID))
+						if opts.OnDead != nil {
+							opts.OnDead(ctx, d, attempts, err)
+						}
 
 						continue
 					}
@@ -381,6 +511,9 @@ func (r *RabbitMQConnection) runConsumerWorker(
 
 					_ = d.Ack(false)
 					localLogger.Info(fmt.Sprintf("[consumer:%d] publish to retry succeeded", workerID))
+					if opts.OnRetry != nil {
+						opts.OnRetry(ctx, d, attempts, err)
+					}
 					continue
 				}
 
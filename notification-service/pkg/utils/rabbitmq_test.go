@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayQueueHonorsTheRequestedDelay(t *testing.T) {
+	tests := []struct {
+		delay time.Duration
+		want  int64
+	}{
+		{delay: 0, want: 1000},
+		{delay: time.Second, want: 1000},
+		{delay: 1500 * time.Millisecond, want: 2000},
+		{delay: 11 * time.Second, want: 11000},
+		{delay: 61 * time.Second, want: 61000},
+		{delay: 3 * time.Hour, want: 3 * 3600 * 1000},
+	}
+
+	for _, tt := range tests {
+		queue, args := delayQueue("notifications", "telegram.send", tt.delay)
+		if ttl := args["x-message-ttl"]; ttl != tt.want {
+			t.Errorf("delay %s: x-message-ttl = %v, want %d", tt.delay, ttl, tt.want)
+		}
+		if args["x-dead-letter-exchange"] != "notifications" || args["x-dead-letter-routing-key"] != "telegram.send" {
+			t.Errorf("delay %s: messages are not routed back, args %v", tt.delay, args)
+		}
+		if other, _ := delayQueue("notifications", "telegram.send", tt.delay+time.Second); tt.delay > 0 && other == queue {
+			t.Errorf("delay %s shares queue %s with a delay one second longer", tt.delay, queue)
+		}
+	}
+}
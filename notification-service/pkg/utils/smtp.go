@@ -9,22 +9,29 @@ import (
 	"time"
 )
 
+const calendarContentType mail.ContentType = "text/calendar; method=REQUEST"
+
 type SMTPClient struct {
 	client *mail.Client
 	from   string
 }
 
+// MailAttachment is embedded as multipart/related when Inline, the HTML body shows it by cid:ContentID.
 type MailAttachment struct {
 	Filename    string
 	ContentType string
 	Data        []byte
+	ContentID   string
+	Inline      bool
 }
 
+// MailMessage is sent as multipart/alternative when it has both bodies or a Calendar, an iCalendar REQUEST.
 type MailMessage struct {
 	To          string
 	Subject     string
-	Body        string
-	ContentType string
+	Text        string
+	HTML        string
+	Calendar    string
 	ReplyTo     *string
 	From        *string
 	CC          []string
@@ -93,9 +100,28 @@ func (s *SMTPClient) Send(message *MailMessage) error {
 	}
 
 	msg.Subject(message.Subject)
-	msg.SetBodyString(parseBodyContentType(message.ContentType), message.Body)
+	// clients show the last alternative they support, so the HTML part goes after the text one
+	switch {
+	case message.Text != "" && message.HTML != "":
+		msg.SetBodyString(mail.TypeTextPlain, message.Text)
+		msg.AddAlternativeString(mail.TypeTextHTML, message.HTML)
+	case message.HTML != "":
+		msg.SetBodyString(mail.TypeTextHTML, message.HTML)
+	default:
+		msg.SetBodyString(mail.TypeTextPlain, message.Text)
+	}
+	if message.Calendar != "" {
+		msg.AddAlternativeString(calendarContentType, message.Calendar)
+	}
 
 	for _, a := range message.Attachments {
+		if a.Inline {
+			if err := msg.EmbedReader(inlineName(a), bytes.NewReader(a.Data), inlineOptions(a)...); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if err := msg.AttachReader(a.Filename, bytes.NewReader(a.Data), mail.WithFileContentType(mail.ContentType(a.ContentType))); err != nil {
 			return err
 		}
@@ -111,6 +137,22 @@ func (s *SMTPClient) Send(message *MailMessage) error {
 	return nil
 }
 
+// inlineName falls back to the content id, go-mail needs a name for every embedded file.
+func inlineName(a MailAttachment) string {
+	if a.Filename != "" {
+		return a.Filename
+	}
+	return a.ContentID
+}
+
+func inlineOptions(a MailAttachment) []mail.FileOption {
+	options := []mail.FileOption{mail.WithFileContentType(mail.ContentType(a.ContentType))}
+	if a.ContentID != "" {
+		options = append(options, mail.WithFileContentID("<"+a.ContentID+">"))
+	}
+	return options
+}
+
 func tlsPolicy(mode string) mail.TLSPolicy {
 	switch mode {
 	case "required":
@@ -121,14 +163,3 @@ func tlsPolicy(mode string) mail.TLSPolicy {
 		return mail.NoTLS
 	}
 }
-
-func parseBodyContentType(ct string) mail.ContentType {
-	switch ct {
-	case "text/html":
-		return mail.TypeTextHTML
-	case "text/plain":
-		fallthrough
-	default:
-		return mail.TypeTextPlain
-	}
-}
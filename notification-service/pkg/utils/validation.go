@@ -2,6 +2,8 @@ package utils
 
 import (
 	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
 )
 
 var validate *validator.Validate
@@ -9,6 +11,18 @@ var validate *validator.Validate
 func InitValidator() *validator.Validate {
 	validate = validator.New()
 
+	// errors report the names clients send, not the Go field names
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
 	return validate
 }
 